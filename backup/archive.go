@@ -0,0 +1,229 @@
+// Package backup exports the resources of a Novu environment into an archive
+// and restores them into another environment.
+//
+// An archive holds the layouts, feeds, tenants, integrations, topics with
+// their subscribers and the subscribers with their preferences. Secret
+// integration credentials are never exported: they are supplied again when
+// restoring, or the integrations are restored inactive. Restoring creates the
+// resources missing from the target environment and maps the ids of the
+// source environment to the ids of the target one.
+package backup
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"path"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/novuhq/go-novu/lib"
+)
+
+// Version is the version of the archive format.
+const Version = 1
+
+// Archive is the content of an environment.
+type Archive struct {
+	Version      int                  `json:"version"`
+	CreatedAt    time.Time            `json:"createdAt"`
+	Layouts      []lib.LayoutResponse `json:"layouts"`
+	Feeds        []Feed               `json:"feeds"`
+	Tenants      []lib.Tenant         `json:"tenants"`
+	Integrations []Integration        `json:"integrations"`
+	Topics       []Topic              `json:"topics"`
+	Subscribers  []Subscriber         `json:"subscribers"`
+}
+
+type Feed struct {
+	Id         string `json:"_id"`
+	Name       string `json:"name"`
+	Identifier string `json:"identifier,omitempty"`
+}
+
+// Integration is an integration without its secret credentials.
+type Integration struct {
+	lib.Integration
+	// Secrets lists the credentials removed from the archive.
+	Secrets []lib.CredentialKey `json:"secrets,omitempty"`
+}
+
+type Topic struct {
+	Key         string   `json:"key"`
+	Name        string   `json:"name"`
+	Subscribers []string `json:"subscribers"`
+}
+
+type Subscriber struct {
+	lib.Subscriber
+	Preferences []lib.SubscriberPreference `json:"preferences,omitempty"`
+}
+
+// The files of a tar archive. Subscribers are written one per line.
+const (
+	archiveFile      = "archive.json"
+	layoutsFile      = "layouts.json"
+	feedsFile        = "feeds.json"
+	tenantsFile      = "tenants.json"
+	integrationsFile = "integrations.json"
+	topicsFile       = "topics.json"
+	subscribersFile  = "subscribers.jsonl"
+)
+
+type archiveHeader struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// WriteJSON writes the archive as a single JSON document.
+func (a *Archive) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(a)
+}
+
+// WriteTar writes the archive as a gzipped tar holding a file per kind of
+// resource, which keeps large environments diffable and streamable.
+func (a *Archive) WriteTar(w io.Writer) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	files := []struct {
+		name string
+		v    interface{}
+	}{
+		{archiveFile, archiveHeader{Version: a.Version, CreatedAt: a.CreatedAt}},
+		{layoutsFile, a.Layouts},
+		{feedsFile, a.Feeds},
+		{tenantsFile, a.Tenants},
+		{integrationsFile, a.Integrations},
+		{topicsFile, a.Topics},
+	}
+	for _, f := range files {
+		bb, err := json.MarshalIndent(f.v, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := writeTarFile(tw, f.name, a.CreatedAt, bb); err != nil {
+			return err
+		}
+	}
+
+	var subscribers bytes.Buffer
+	encoder := json.NewEncoder(&subscribers)
+	for _, s := range a.Subscribers {
+		if err := encoder.Encode(s); err != nil {
+			return err
+		}
+	}
+	if err := writeTarFile(tw, subscribersFile, a.CreatedAt, subscribers.Bytes()); err != nil {
+		return err
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+func writeTarFile(tw *tar.Writer, name string, modTime time.Time, data []byte) error {
+	err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(data)), ModTime: modTime, Typeflag: tar.TypeReg})
+	if err != nil {
+		return err
+	}
+	_, err = tw.Write(data)
+	return err
+}
+
+// Read reads an archive written by WriteJSON or WriteTar, gzipped or not.
+func Read(r io.Reader) (*Archive, error) {
+	br := bufio.NewReader(r)
+	magic, _ := br.Peek(2)
+	if bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid archive")
+		}
+		br = bufio.NewReader(gz)
+	}
+
+	var a *Archive
+	var err error
+	if isTar(br) {
+		a, err = readTar(tar.NewReader(br))
+	} else {
+		a = &Archive{}
+		err = json.NewDecoder(br).Decode(a)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid archive")
+	}
+
+	if a.Version == 0 {
+		return nil, errors.New("invalid archive: no version")
+	}
+	if a.Version > Version {
+		return nil, errors.Errorf("archive version %d is not supported, upgrade to read it", a.Version)
+	}
+	return a, nil
+}
+
+// isTar looks for the magic of the ustar header.
+func isTar(br *bufio.Reader) bool {
+	header, _ := br.Peek(512)
+	return len(header) == 512 && bytes.HasPrefix(header[257:], []byte("ustar"))
+}
+
+func readTar(tr *tar.Reader) (*Archive, error) {
+	a := &Archive{}
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return a, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var v interface{}
+		switch path.Base(h.Name) {
+		case archiveFile:
+			var header archiveHeader
+			if err := json.NewDecoder(tr).Decode(&header); err != nil {
+				return nil, errors.Wrap(err, h.Name)
+			}
+			a.Version, a.CreatedAt = header.Version, header.CreatedAt
+			continue
+		case subscribersFile:
+			decoder := json.NewDecoder(tr)
+			for decoder.More() {
+				var s Subscriber
+				if err := decoder.Decode(&s); err != nil {
+					return nil, errors.Wrap(err, h.Name)
+				}
+				a.Subscribers = append(a.Subscribers, s)
+			}
+			continue
+		case layoutsFile:
+			v = &a.Layouts
+		case feedsFile:
+			v = &a.Feeds
+		case tenantsFile:
+			v = &a.Tenants
+		case integrationsFile:
+			v = &a.Integrations
+		case topicsFile:
+			v = &a.Topics
+		default:
+			// Files of newer versions of the format are ignored.
+			continue
+		}
+		if err := json.NewDecoder(tr).Decode(v); err != nil {
+			return nil, errors.Wrap(err, h.Name)
+		}
+	}
+}
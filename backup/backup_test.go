@@ -0,0 +1,327 @@
+package backup_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/novuhq/go-novu/backup"
+	"github.com/novuhq/go-novu/lib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var sourceResponses = map[string]string{
+	"/v1/layouts": `{"data": [
+		{"_id": "src-layout-1", "identifier": "default", "name": "Default", "content": "<div>{{{body}}}</div>", "isDefault": true},
+		{"_id": "src-layout-2", "identifier": "plain", "name": "Plain", "content": "{{{body}}}"}
+	], "totalCount": 2}`,
+	"/v1/feeds":   `{"data": [{"_id": "src-feed-1", "name": "News", "identifier": "news"}]}`,
+	"/v1/tenants": `{"data": [{"_id": "src-t-1", "identifier": "acme", "name": "Acme", "data": {"plan": "enterprise"}}], "hasMore": false}`,
+	"/v1/integrations": `{"data": [
+		{"_id": "src-int-1", "identifier": "sendgrid-main", "name": "SendGrid", "providerId": "sendgrid", "channel": "email", "active": true,
+		 "credentials": {"apiKey": "SG.secret", "from": "notifications@example.com", "senderName": "Example"}},
+		{"_id": "src-int-2", "identifier": "sendgrid-backup", "name": "SendGrid backup", "providerId": "sendgrid", "channel": "email", "active": true,
+		 "credentials": {"apiKey": "SG.other", "from": "backup@example.com"}},
+		{"_id": "src-int-3", "identifier": "old", "providerId": "mailgun", "channel": "email", "deleted": true}
+	]}`,
+	"/v1/topics":      `{"data": [{"key": "beta", "name": "Beta testers"}], "totalCount": 1}`,
+	"/v1/topics/beta": `{"key": "beta", "name": "Beta testers", "subscribers": ["sub-1", "sub-2"]}`,
+	"/v1/subscribers": `{"data": [
+		{"_id": "src-s-1", "subscriberId": "sub-1", "email": "jane@example.com", "firstName": "Jane",
+		 "channels": [{"providerId": "slack", "integrationIdentifier": "slack-main", "credentials": {"webhookUrl": "https://hooks.slack.com/x"}}]},
+		{"_id": "src-s-2", "subscriberId": "sub-2", "email": "john@example.com"}
+	], "page": 0, "pageSize": 100, "hasMore": false}`,
+	"/v1/subscribers/sub-1/preferences": `{"data": [
+		{"template": {"_id": "src-wf-1", "name": "Welcome"}, "preference": {"enabled": true, "channels": {"email": false, "in_app": true}}},
+		{"template": {"_id": "src-wf-2", "name": "Password reset", "critical": true}, "preference": {"enabled": true, "channels": {"email": true}}},
+		{"template": {"_id": "src-wf-3", "name": "Removed"}, "preference": {"enabled": false, "channels": {}}}
+	]}`,
+	"/v1/subscribers/sub-1/preferences/global": `{"data": [{"preference": {"enabled": true, "channels": {"sms": false}}}]}`,
+	"/v1/subscribers/sub-2/preferences":        `{"data": []}`,
+	"/v1/subscribers/sub-2/preferences/global": `{"data": []}`,
+}
+
+// targetResponses is an environment holding the default layout and the main
+// SendGrid integration.
+var targetResponses = map[string]string{
+	"/v1/layouts": `{"data": [{"_id": "dst-layout-1", "identifier": "default", "name": "Default", "isDefault": true}], "totalCount": 1}`,
+	"/v1/feeds":   `{"data": []}`,
+	"/v1/tenants": `{"data": [], "hasMore": false}`,
+	"/v1/integrations": `{"data": [{"_id": "dst-int-1", "identifier": "sendgrid-main", "providerId": "sendgrid", "channel": "email", "active": true,
+		"credentials": {"apiKey": "SG.live"}}]}`,
+	"/v1/topics":      `{"data": [], "totalCount": 0}`,
+	"/v1/subscribers": `{"data": [{"_id": "dst-s-2", "subscriberId": "sub-2"}], "hasMore": false}`,
+	"/v1/workflows":   `{"data": [{"_id": "dst-wf-1", "name": "Welcome"}, {"_id": "dst-wf-2", "name": "Password reset"}], "totalCount": 2}`,
+}
+
+type request struct {
+	Method string
+	Path   string
+	Body   interface{}
+}
+
+// newFakeNovu serves the GET responses by path. Other requests are recorded,
+// and fail with a 400 when responses has a "METHOD path" entry.
+func newFakeNovu(t *testing.T, responses map[string]string) (*lib.APIClient, *[]request) {
+	var requests []request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodGet {
+			resp, ok := responses[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`{"message": "not found"}`))
+				return
+			}
+			w.Write([]byte(resp))
+			return
+		}
+
+		req := request{Method: r.Method, Path: r.URL.Path}
+		if bb, _ := io.ReadAll(r.Body); len(bb) > 0 {
+			require.NoError(t, json.Unmarshal(bb, &req.Body))
+		}
+		requests = append(requests, req)
+		if resp, ok := responses[r.Method+" "+r.URL.Path]; ok {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(resp))
+			return
+		}
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/topics":
+			w.WriteHeader(http.StatusCreated)
+		case r.URL.Path == "/v1/subscribers/bulk":
+			w.Write([]byte(`{"data": {"created": [{"subscriberId": "sub-1"}], "updated": [], "failed": []}}`))
+			return
+		case strings.Contains(r.URL.Path, "/preferences/"):
+			w.Write([]byte(`{"data": []}`))
+			return
+		}
+		id := strings.ReplaceAll(strings.TrimPrefix(r.URL.Path, "/v1/"), "/", "-") + "-new"
+		w.Write([]byte(`{"data": {"_id": "` + id + `"}}`))
+	}))
+	t.Cleanup(server.Close)
+	return lib.NewAPIClient("api-key", &lib.Config{BackendURL: lib.MustParseURL(server.URL)}), &requests
+}
+
+func exportSource(t *testing.T) *backup.Archive {
+	client, _ := newFakeNovu(t, sourceResponses)
+	now := time.Date(2023, 9, 1, 12, 0, 0, 0, time.UTC)
+	a, err := backup.Export(context.Background(), client, &backup.ExportOptions{Now: func() time.Time { return now }})
+	require.NoError(t, err)
+	return a
+}
+
+func TestExport(t *testing.T) {
+	a := exportSource(t)
+
+	assert.Equal(t, backup.Version, a.Version)
+	assert.Len(t, a.Layouts, 2)
+	assert.Equal(t, []backup.Feed{{Id: "src-feed-1", Name: "News", Identifier: "news"}}, a.Feeds)
+	assert.Equal(t, "acme", a.Tenants[0].Identifier)
+	assert.Equal(t, []backup.Topic{{Key: "beta", Name: "Beta testers", Subscribers: []string{"sub-1", "sub-2"}}}, a.Topics)
+
+	t.Run("Integrations are exported without secrets", func(t *testing.T) {
+		require.Len(t, a.Integrations, 2)
+		main := a.Integrations[0]
+		assert.Equal(t, []lib.CredentialKey{lib.CredentialApiKey}, main.Secrets)
+		assert.Empty(t, main.Credentials.ApiKey)
+		assert.Equal(t, "notifications@example.com", main.Credentials.From)
+
+		var buf bytes.Buffer
+		require.NoError(t, a.WriteJSON(&buf))
+		assert.NotContains(t, buf.String(), "SG.")
+	})
+
+	t.Run("Subscribers are exported with their preferences", func(t *testing.T) {
+		require.Len(t, a.Subscribers, 2)
+		jane := a.Subscribers[0]
+		assert.Equal(t, "jane@example.com", jane.Email)
+		assert.Equal(t, "https://hooks.slack.com/x", jane.Channels[0].Credentials.WebhookUrl)
+		require.Len(t, jane.Preferences, 4)
+		assert.Equal(t, lib.PreferenceLevelGlobal, jane.Preferences[0].Level)
+		assert.Equal(t, "Welcome", jane.Preferences[1].Template.Name)
+	})
+}
+
+func TestArchive_ReadWrite(t *testing.T) {
+	a := exportSource(t)
+
+	writers := map[string]func(w io.Writer) error{
+		"json": a.WriteJSON,
+		"tar":  a.WriteTar,
+	}
+	for name, write := range writers {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, write(&buf))
+			read, err := backup.Read(&buf)
+			require.NoError(t, err)
+			assert.Equal(t, a, read)
+		})
+	}
+
+	t.Run("Newer versions are rejected", func(t *testing.T) {
+		_, err := backup.Read(strings.NewReader(`{"version": 2}`))
+		assert.ErrorContains(t, err, "not supported")
+	})
+
+	t.Run("Unversioned documents are rejected", func(t *testing.T) {
+		_, err := backup.Read(strings.NewReader(`{"layouts": []}`))
+		assert.Error(t, err)
+	})
+}
+
+func TestRestore(t *testing.T) {
+	a := exportSource(t)
+	client, requests := newFakeNovu(t, targetResponses)
+
+	result, err := backup.Restore(context.Background(), client, a, &backup.RestoreOptions{
+		Credentials: map[string]lib.IntegrationCredentials{"sendgrid-backup": {ApiKey: "SG.restored"}},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]int{"layout": 1, "feed": 1, "tenant": 1, "integration": 1, "subscriber": 1, "topic": 1}, result.Created)
+	assert.Equal(t, map[string]int{"layout": 1, "integration": 1, "subscriber": 1}, result.Skipped)
+	assert.Equal(t, map[string]string{
+		"src-layout-1": "dst-layout-1",
+		"src-layout-2": "layouts-new",
+		"src-feed-1":   "feeds-new",
+		"src-int-1":    "dst-int-1",
+		"src-int-2":    "integrations-new",
+		"src-wf-1":     "dst-wf-1",
+	}, result.IDs)
+	assert.Equal(t, []string{`preference of sub-1 for workflow "Removed" not restored: the workflow does not exist`}, result.Warnings)
+
+	assert.Equal(t, []request{
+		{Method: http.MethodPost, Path: "/v1/layouts", Body: map[string]interface{}{
+			"name": "Plain", "identifier": "plain", "description": "", "content": "{{{body}}}",
+		}},
+		{Method: http.MethodPost, Path: "/v1/feeds", Body: map[string]interface{}{"name": "News"}},
+		{Method: http.MethodPost, Path: "/v1/tenants", Body: map[string]interface{}{
+			"identifier": "acme", "name": "Acme", "data": map[string]interface{}{"plan": "enterprise"},
+		}},
+		{Method: http.MethodPost, Path: "/v1/integrations", Body: map[string]interface{}{
+			"name": "SendGrid backup", "identifier": "sendgrid-backup", "providerId": "sendgrid", "channel": "email",
+			"credentials": map[string]interface{}{"apiKey": "SG.restored", "from": "backup@example.com"},
+			"active":      true, "check": false,
+		}},
+		{Method: http.MethodPost, Path: "/v1/subscribers/bulk", Body: map[string]interface{}{
+			"subscribers": []interface{}{map[string]interface{}{"subscriberId": "sub-1", "email": "jane@example.com", "firstName": "Jane"}},
+		}},
+		{Method: http.MethodPut, Path: "/v1/subscribers/sub-1/credentials", Body: map[string]interface{}{
+			"providerId": "slack", "integrationIdentifier": "slack-main",
+			"credentials": map[string]interface{}{"webhookUrl": "https://hooks.slack.com/x"},
+		}},
+		{Method: http.MethodPatch, Path: "/v1/subscribers/sub-1/preferences", Body: map[string]interface{}{
+			"enabled": true, "preferences": []interface{}{map[string]interface{}{"type": "sms", "enabled": false}},
+		}},
+		{Method: http.MethodPatch, Path: "/v1/subscribers/sub-1/preferences/dst-wf-1", Body: map[string]interface{}{"enabled": true}},
+		{Method: http.MethodPatch, Path: "/v1/subscribers/sub-1/preferences/dst-wf-1", Body: map[string]interface{}{
+			"channel": []interface{}{map[string]interface{}{"type": "email", "enabled": false}},
+		}},
+		{Method: http.MethodPatch, Path: "/v1/subscribers/sub-1/preferences/dst-wf-1", Body: map[string]interface{}{
+			"channel": []interface{}{map[string]interface{}{"type": "in_app", "enabled": true}},
+		}},
+		{Method: http.MethodPost, Path: "/v1/topics", Body: map[string]interface{}{"key": "beta", "name": "Beta testers"}},
+		{Method: http.MethodPost, Path: "/v1/topics/beta/subscribers", Body: map[string]interface{}{"subscribers": []interface{}{"sub-1", "sub-2"}}},
+	}, *requests)
+}
+
+func TestRestore_Overwrite(t *testing.T) {
+	a := exportSource(t)
+	client, requests := newFakeNovu(t, targetResponses)
+
+	result, err := backup.Restore(context.Background(), client, a, &backup.RestoreOptions{Overwrite: true})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"layout": 1, "integration": 1}, result.Updated)
+	assert.Equal(t, []string{
+		"integration sendgrid-backup restored inactive: its secrets [apiKey] are not in the archive",
+		`preference of sub-1 for workflow "Removed" not restored: the workflow does not exist`,
+	}, result.Warnings)
+
+	var integrationUpdate, bulk request
+	for _, r := range *requests {
+		switch r.Path {
+		case "/v1/integrations/dst-int-1":
+			integrationUpdate = r
+		case "/v1/subscribers/bulk":
+			bulk = r
+		}
+	}
+	// The live secret is kept when none is supplied.
+	assert.Equal(t, map[string]interface{}{
+		"apiKey": "SG.live", "from": "notifications@example.com", "senderName": "Example",
+	}, integrationUpdate.Body.(map[string]interface{})["credentials"])
+	assert.Len(t, bulk.Body.(map[string]interface{})["subscribers"], 2)
+}
+
+func TestRestore_Rerun(t *testing.T) {
+	a := exportSource(t)
+	opts := &backup.RestoreOptions{Credentials: map[string]lib.IntegrationCredentials{"sendgrid-backup": {ApiKey: "SG.restored"}}}
+	responses := map[string]string{}
+	for path, resp := range targetResponses {
+		responses[path] = resp
+	}
+	paths := func(requests []request) []string {
+		var paths []string
+		for _, r := range requests {
+			paths = append(paths, r.Method+" "+r.Path)
+		}
+		return paths
+	}
+
+	// The first restore stops after creating sub-1, before its credentials.
+	responses["PUT /v1/subscribers/sub-1/credentials"] = `{"message": "invalid credentials"}`
+	client, requests := newFakeNovu(t, responses)
+	_, err := backup.Restore(context.Background(), client, a, opts)
+	assert.ErrorContains(t, err, "unable to restore subscribers: credentials of sub-1")
+	assert.Contains(t, paths(*requests), "POST /v1/subscribers/bulk")
+
+	// The second one stops after creating the topic, before its subscribers.
+	delete(responses, "PUT /v1/subscribers/sub-1/credentials")
+	responses["POST /v1/topics/beta/subscribers"] = `{"message": "unavailable"}`
+	responses["/v1/layouts"] = `{"data": [
+		{"_id": "dst-layout-1", "identifier": "default", "name": "Default", "isDefault": true},
+		{"_id": "dst-layout-2", "identifier": "plain", "name": "Plain"}
+	], "totalCount": 2}`
+	responses["/v1/feeds"] = `{"data": [{"_id": "dst-feed-1", "name": "News"}]}`
+	responses["/v1/tenants"] = `{"data": [{"identifier": "acme", "name": "Acme"}], "hasMore": false}`
+	responses["/v1/integrations"] = `{"data": [
+		{"_id": "dst-int-1", "identifier": "sendgrid-main", "providerId": "sendgrid"},
+		{"_id": "dst-int-2", "identifier": "sendgrid-backup", "providerId": "sendgrid"}
+	]}`
+	responses["/v1/subscribers"] = `{"data": [{"subscriberId": "sub-1"}, {"subscriberId": "sub-2"}], "hasMore": false}`
+	client, requests = newFakeNovu(t, responses)
+	_, err = backup.Restore(context.Background(), client, a, opts)
+	assert.ErrorContains(t, err, "unable to restore topics: topic beta")
+	assert.Equal(t, []string{
+		"PUT /v1/subscribers/sub-1/credentials",
+		"PATCH /v1/subscribers/sub-1/preferences",
+		"PATCH /v1/subscribers/sub-1/preferences/dst-wf-1",
+		"PATCH /v1/subscribers/sub-1/preferences/dst-wf-1",
+		"PATCH /v1/subscribers/sub-1/preferences/dst-wf-1",
+		"POST /v1/topics",
+		"POST /v1/topics/beta/subscribers",
+	}, paths(*requests))
+
+	// The last one adds the subscribers of the existing topic.
+	delete(responses, "POST /v1/topics/beta/subscribers")
+	responses["/v1/topics"] = `{"data": [{"key": "beta", "name": "Beta testers"}], "totalCount": 1}`
+	client, requests = newFakeNovu(t, responses)
+	result, err := backup.Restore(context.Background(), client, a, opts)
+	require.NoError(t, err)
+	assert.Empty(t, result.Created)
+	assert.Equal(t, map[string]int{"layout": 2, "feed": 1, "tenant": 1, "integration": 2, "subscriber": 2, "topic": 1}, result.Skipped)
+	assert.Equal(t, request{
+		Method: http.MethodPost, Path: "/v1/topics/beta/subscribers",
+		Body: map[string]interface{}{"subscribers": []interface{}{"sub-1", "sub-2"}},
+	}, (*requests)[len(*requests)-1])
+}
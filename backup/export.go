@@ -0,0 +1,159 @@
+package backup
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/novuhq/go-novu/internal/convert"
+	"github.com/novuhq/go-novu/lib"
+)
+
+const defaultPageSize = 100
+
+type ExportOptions struct {
+	// PageSize is the number of resources fetched per page. It defaults to
+	// 100.
+	PageSize int
+	// SkipSubscribers leaves the subscribers and their preferences out of the
+	// archive. Topics keep their subscriber ids.
+	SkipSubscribers bool
+	// SkipPreferences leaves the preferences of the subscribers out, which
+	// saves two requests per subscriber.
+	SkipPreferences bool
+	// Now returns the creation time of the archive. It defaults to time.Now.
+	Now func() time.Time
+}
+
+// Export reads the resources of the environment of client into an archive.
+func Export(ctx context.Context, client *lib.APIClient, opts *ExportOptions) (*Archive, error) {
+	o := ExportOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.PageSize <= 0 {
+		o.PageSize = defaultPageSize
+	}
+	if o.Now == nil {
+		o.Now = time.Now
+	}
+
+	a := &Archive{Version: Version, CreatedAt: o.Now().UTC()}
+	exporters := []struct {
+		kind   string
+		export func(ctx context.Context, client *lib.APIClient, o *ExportOptions, a *Archive) error
+	}{
+		{"layouts", exportLayouts},
+		{"feeds", exportFeeds},
+		{"tenants", exportTenants},
+		{"integrations", exportIntegrations},
+		{"topics", exportTopics},
+		{"subscribers", exportSubscribers},
+	}
+	for _, e := range exporters {
+		if err := e.export(ctx, client, &o, a); err != nil {
+			return nil, errors.Wrapf(err, "unable to export %s", e.kind)
+		}
+	}
+	return a, nil
+}
+
+func exportLayouts(ctx context.Context, client *lib.APIClient, o *ExportOptions, a *Archive) error {
+	return client.LayoutApi.IterateLayouts(ctx, o.PageSize, func(layout lib.LayoutResponse) error {
+		a.Layouts = append(a.Layouts, layout)
+		return nil
+	})
+}
+
+func exportFeeds(ctx context.Context, client *lib.APIClient, o *ExportOptions, a *Archive) error {
+	resp, err := client.FeedsApi.GetFeeds(ctx)
+	if err != nil {
+		return err
+	}
+	return convert.JSON(resp.Data, &a.Feeds)
+}
+
+func exportTenants(ctx context.Context, client *lib.APIClient, o *ExportOptions, a *Archive) error {
+	return client.TenantApi.IterateTenants(ctx, o.PageSize, func(tenant lib.Tenant) error {
+		a.Tenants = append(a.Tenants, tenant)
+		return nil
+	})
+}
+
+func exportIntegrations(ctx context.Context, client *lib.APIClient, o *ExportOptions, a *Archive) error {
+	resp, err := client.IntegrationsApi.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+	for _, i := range resp.Data {
+		if i.Deleted {
+			continue
+		}
+		integration, err := stripSecrets(i)
+		if err != nil {
+			return err
+		}
+		a.Integrations = append(a.Integrations, integration)
+	}
+	return nil
+}
+
+// stripSecrets removes the secret credentials of an integration.
+func stripSecrets(i lib.Integration) (Integration, error) {
+	integration := Integration{Integration: i}
+	values := map[string]interface{}{}
+	for key, value := range i.Credentials.Values() {
+		if key.IsSecret() {
+			integration.Secrets = append(integration.Secrets, key)
+			continue
+		}
+		values[string(key)] = value
+	}
+	sort.Slice(integration.Secrets, func(a, b int) bool { return integration.Secrets[a] < integration.Secrets[b] })
+	integration.Credentials = lib.IntegrationCredentials{}
+	return integration, convert.JSON(values, &integration.Credentials)
+}
+
+func exportTopics(ctx context.Context, client *lib.APIClient, o *ExportOptions, a *Archive) error {
+	return client.TopicsApi.IterateTopics(ctx, o.PageSize, func(t lib.GetTopicResponse) error {
+		// Listed topics do not hold their subscribers.
+		topic, err := client.TopicsApi.Get(ctx, t.Key)
+		if err != nil {
+			return errors.Wrapf(err, "topic %s", t.Key)
+		}
+		subscribers := topic.Subscribers
+		if subscribers == nil {
+			subscribers = []string{}
+		}
+		a.Topics = append(a.Topics, Topic{Key: topic.Key, Name: topic.Name, Subscribers: subscribers})
+		return nil
+	})
+}
+
+func exportSubscribers(ctx context.Context, client *lib.APIClient, o *ExportOptions, a *Archive) error {
+	if o.SkipSubscribers {
+		return nil
+	}
+	return client.SubscriberApi.IterateSubscribers(ctx, o.PageSize, func(s lib.Subscriber) error {
+		subscriber := Subscriber{Subscriber: s}
+		if !o.SkipPreferences {
+			preferences, err := client.SubscriberApi.GetPreferences(ctx, s.SubscriberId)
+			if err != nil {
+				return errors.Wrapf(err, "preferences of %s", s.SubscriberId)
+			}
+			global, err := client.SubscriberApi.GetGlobalPreferences(ctx, s.SubscriberId)
+			if err != nil {
+				return errors.Wrapf(err, "global preferences of %s", s.SubscriberId)
+			}
+			for _, p := range global.Data {
+				p.Level = lib.PreferenceLevelGlobal
+				subscriber.Preferences = append(subscriber.Preferences, p)
+			}
+			subscriber.Preferences = append(subscriber.Preferences, preferences.Data...)
+		}
+		a.Subscribers = append(a.Subscribers, subscriber)
+		return nil
+	})
+}
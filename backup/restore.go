@@ -0,0 +1,476 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/novuhq/go-novu/internal/convert"
+	"github.com/novuhq/go-novu/lib"
+)
+
+const defaultBatchSize = 500
+
+// The kinds of resources counted by RestoreResult.
+const (
+	KindLayout      = "layout"
+	KindFeed        = "feed"
+	KindTenant      = "tenant"
+	KindIntegration = "integration"
+	KindSubscriber  = "subscriber"
+	KindTopic       = "topic"
+)
+
+type RestoreOptions struct {
+	// Overwrite updates the resources of the target environment that exist in
+	// the archive. By default their fields are left untouched, but subscribers
+	// still get the credentials and preferences of the archive, topics its
+	// subscribers and the default layout is set, so that restoring again
+	// completes a restore that failed. Topics only gain the subscribers of the
+	// archive; none are removed.
+	Overwrite bool
+	// Credentials holds the secret credentials of integrations, by integration
+	// identifier. They are merged with the credentials of the archive.
+	// Integrations whose secrets are not supplied are created inactive.
+	Credentials map[string]lib.IntegrationCredentials
+	// PageSize is the number of resources fetched per page. It defaults to
+	// 100.
+	PageSize int
+	// BatchSize is the number of subscribers created per request. It defaults
+	// to 500.
+	BatchSize int
+}
+
+// RestoreResult reports what a restore did.
+type RestoreResult struct {
+	// Created, Updated and Skipped count the resources by kind.
+	Created map[string]int
+	Updated map[string]int
+	Skipped map[string]int
+	// IDs maps the ids of the archive to the ids of the target environment,
+	// for layouts, feeds, integrations and the workflows referenced by
+	// preferences.
+	IDs map[string]string
+	// Warnings lists what could not be restored as is.
+	Warnings []string
+}
+
+func (r *RestoreResult) warnf(format string, args ...interface{}) {
+	r.Warnings = append(r.Warnings, fmt.Sprintf(format, args...))
+}
+
+type restorer struct {
+	client *lib.APIClient
+	opts   RestoreOptions
+	result *RestoreResult
+}
+
+// Restore creates the resources of the archive missing from the environment
+// of client. Resources are matched by their key: the identifier of layouts,
+// tenants and integrations, the key of topics, the name of feeds and the
+// subscriber id of subscribers. Restore stops at the first failure and returns
+// the result so far; restoring the archive again creates what is missing and
+// applies the credentials, preferences and topic subscribers of the archive
+// anew.
+func Restore(ctx context.Context, client *lib.APIClient, a *Archive, opts *RestoreOptions) (*RestoreResult, error) {
+	r := &restorer{client: client, result: &RestoreResult{
+		Created: map[string]int{},
+		Updated: map[string]int{},
+		Skipped: map[string]int{},
+		IDs:     map[string]string{},
+	}}
+	if opts != nil {
+		r.opts = *opts
+	}
+	if r.opts.PageSize <= 0 {
+		r.opts.PageSize = defaultPageSize
+	}
+	if r.opts.BatchSize <= 0 {
+		r.opts.BatchSize = defaultBatchSize
+	}
+	if a.Version > Version {
+		return r.result, errors.Errorf("archive version %d is not supported", a.Version)
+	}
+
+	restorers := []struct {
+		kind    string
+		restore func(ctx context.Context, a *Archive) error
+	}{
+		{KindLayout, r.restoreLayouts},
+		{KindFeed, r.restoreFeeds},
+		{KindTenant, r.restoreTenants},
+		{KindIntegration, r.restoreIntegrations},
+		{KindSubscriber, r.restoreSubscribers},
+		{KindTopic, r.restoreTopics},
+	}
+	for _, rr := range restorers {
+		if err := rr.restore(ctx, a); err != nil {
+			return r.result, errors.Wrapf(err, "unable to restore %ss", rr.kind)
+		}
+	}
+	return r.result, nil
+}
+
+func (r *restorer) restoreLayouts(ctx context.Context, a *Archive) error {
+	live := map[string]lib.LayoutResponse{}
+	err := r.client.LayoutApi.IterateLayouts(ctx, r.opts.PageSize, func(l lib.LayoutResponse) error {
+		live[l.Identifier] = l
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, l := range a.Layouts {
+		request := lib.CreateLayoutRequest{
+			Name:        l.Name,
+			Identifier:  l.Identifier,
+			Description: l.Description,
+			Content:     l.Content,
+			Variables:   l.Variables,
+		}
+		existing, ok := live[l.Identifier]
+		switch {
+		case !ok:
+			resp, err := r.client.LayoutApi.Create(ctx, request)
+			if err != nil {
+				return errors.Wrapf(err, "layout %s", l.Identifier)
+			}
+			r.result.IDs[l.Id] = resp.Data.Id
+			r.result.Created[KindLayout]++
+		case r.opts.Overwrite:
+			if _, err := r.client.LayoutApi.Update(ctx, existing.Id, request); err != nil {
+				return errors.Wrapf(err, "layout %s", l.Identifier)
+			}
+			r.result.IDs[l.Id] = existing.Id
+			r.result.Updated[KindLayout]++
+		default:
+			r.result.IDs[l.Id] = existing.Id
+			r.result.Skipped[KindLayout]++
+		}
+		if l.IsDefault && !existing.IsDefault {
+			if err := r.client.LayoutApi.SetDefault(ctx, r.result.IDs[l.Id]); err != nil {
+				return errors.Wrapf(err, "layout %s", l.Identifier)
+			}
+		}
+	}
+	return nil
+}
+
+func (r *restorer) restoreFeeds(ctx context.Context, a *Archive) error {
+	resp, err := r.client.FeedsApi.GetFeeds(ctx)
+	if err != nil {
+		return err
+	}
+	var feeds []Feed
+	if err := convert.JSON(resp.Data, &feeds); err != nil {
+		return err
+	}
+	live := map[string]string{}
+	for _, f := range feeds {
+		live[f.Name] = f.Id
+	}
+
+	for _, f := range a.Feeds {
+		if id, ok := live[f.Name]; ok {
+			r.result.IDs[f.Id] = id
+			r.result.Skipped[KindFeed]++
+			continue
+		}
+		resp, err := r.client.FeedsApi.CreateFeed(ctx, f.Name)
+		if err != nil {
+			return errors.Wrapf(err, "feed %s", f.Name)
+		}
+		var created Feed
+		if err := convert.JSON(resp.Data, &created); err != nil {
+			return err
+		}
+		r.result.IDs[f.Id] = created.Id
+		r.result.Created[KindFeed]++
+	}
+	return nil
+}
+
+func (r *restorer) restoreTenants(ctx context.Context, a *Archive) error {
+	live := map[string]bool{}
+	err := r.client.TenantApi.IterateTenants(ctx, r.opts.PageSize, func(tenant lib.Tenant) error {
+		live[tenant.Identifier] = true
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, t := range a.Tenants {
+		switch {
+		case !live[t.Identifier]:
+			if _, err := r.client.TenantApi.UpsertTenant(ctx, lib.CreateTenantRequest{Identifier: t.Identifier, Name: t.Name, Data: t.Data}); err != nil {
+				return errors.Wrapf(err, "tenant %s", t.Identifier)
+			}
+			r.result.Created[KindTenant]++
+		case r.opts.Overwrite:
+			if _, err := r.client.TenantApi.UpdateTenant(ctx, t.Identifier, &lib.UpdateTenantRequest{Name: t.Name, Data: t.Data}); err != nil {
+				return errors.Wrapf(err, "tenant %s", t.Identifier)
+			}
+			r.result.Updated[KindTenant]++
+		default:
+			r.result.Skipped[KindTenant]++
+		}
+	}
+	return nil
+}
+
+func (r *restorer) restoreIntegrations(ctx context.Context, a *Archive) error {
+	resp, err := r.client.IntegrationsApi.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+	live := map[string]lib.Integration{}
+	for _, i := range resp.Data {
+		if i.Identifier != "" && !i.Deleted {
+			live[i.Identifier] = i
+		}
+	}
+
+	for _, i := range a.Integrations {
+		supplied, hasSecrets := r.opts.Credentials[i.Identifier]
+		existing, ok := live[i.Identifier]
+		switch {
+		case !ok:
+			credentials := lib.MergeCredentials(i.Credentials, supplied)
+			active := i.Active
+			if active && len(i.Secrets) > 0 && !hasSecrets {
+				active = false
+				r.result.warnf("integration %s restored inactive: its secrets %v are not in the archive", i.Identifier, i.Secrets)
+			}
+			created, err := r.client.IntegrationsApi.Create(ctx, lib.CreateIntegrationRequest{
+				Name:        i.Name,
+				Identifier:  i.Identifier,
+				ProviderID:  i.ProviderID,
+				Channel:     i.Channel,
+				Credentials: credentials,
+				Active:      active,
+				Conditions:  i.Conditions,
+			})
+			if err != nil {
+				return errors.Wrapf(err, "integration %s", i.Identifier)
+			}
+			r.result.IDs[i.Id] = created.Data.Id
+			r.result.Created[KindIntegration]++
+		case r.opts.Overwrite:
+			if existing.ProviderID != i.ProviderID {
+				return errors.Errorf("integration %s is a %s integration in the target environment, not %s", i.Identifier, existing.ProviderID, i.ProviderID)
+			}
+			// Updates replace every credential: the live secrets are kept
+			// unless new ones are supplied.
+			credentials := lib.MergeCredentials(existing.Credentials, i.Credentials, supplied)
+			_, err := r.client.IntegrationsApi.Update(ctx, existing.Id, lib.UpdateIntegrationRequest{
+				Name:        i.Name,
+				Identifier:  i.Identifier,
+				Credentials: credentials,
+				Active:      i.Active,
+				Conditions:  lib.IntegrationConditions(i.Conditions...),
+			})
+			if err != nil {
+				return errors.Wrapf(err, "integration %s", i.Identifier)
+			}
+			r.result.IDs[i.Id] = existing.Id
+			r.result.Updated[KindIntegration]++
+		default:
+			r.result.IDs[i.Id] = existing.Id
+			r.result.Skipped[KindIntegration]++
+		}
+	}
+	return nil
+}
+
+func (r *restorer) restoreSubscribers(ctx context.Context, a *Archive) error {
+	if len(a.Subscribers) == 0 {
+		return nil
+	}
+	live := map[string]bool{}
+	err := r.client.SubscriberApi.IterateSubscribers(ctx, r.opts.PageSize, func(s lib.Subscriber) error {
+		live[s.SubscriberId] = true
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	workflows, err := r.workflowIds(ctx)
+	if err != nil {
+		return err
+	}
+
+	// The profiles of existing subscribers are only updated with Overwrite.
+	var restored []Subscriber
+	for _, s := range a.Subscribers {
+		if live[s.SubscriberId] && !r.opts.Overwrite {
+			r.result.Skipped[KindSubscriber]++
+			continue
+		}
+		restored = append(restored, s)
+	}
+
+	for start := 0; start < len(restored); start += r.opts.BatchSize {
+		end := start + r.opts.BatchSize
+		if end > len(restored) {
+			end = len(restored)
+		}
+		payload := lib.SubscriberBulkPayload{}
+		for _, s := range restored[start:end] {
+			payload.Subscribers = append(payload.Subscribers, s.SubscriberPayload)
+		}
+		resp, err := r.client.SubscriberApi.BulkCreate(ctx, payload)
+		if err != nil {
+			return err
+		}
+		if n := len(resp.Data.Created); n > 0 {
+			r.result.Created[KindSubscriber] += n
+		}
+		if n := len(resp.Data.Updated); n > 0 {
+			r.result.Updated[KindSubscriber] += n
+		}
+		for _, failed := range resp.Data.Failed {
+			r.result.warnf("subscriber not restored: %v", failed)
+		}
+	}
+
+	// Credentials and preferences are set for every subscriber, including
+	// those created by an earlier restore that stopped before setting them.
+	for _, s := range a.Subscribers {
+		for _, channel := range s.Channels {
+			if _, err := r.client.SubscriberApi.UpdateCredentials(ctx, s.SubscriberId, channel); err != nil {
+				return errors.Wrapf(err, "credentials of %s", s.SubscriberId)
+			}
+		}
+		if err := r.restorePreferences(ctx, s, workflows); err != nil {
+			return errors.Wrapf(err, "preferences of %s", s.SubscriberId)
+		}
+	}
+	return nil
+}
+
+// workflowIds returns the ids of the workflows of the target environment, by
+// name. Preferences refer to workflows by id, which differ between
+// environments.
+func (r *restorer) workflowIds(ctx context.Context) (map[string]string, error) {
+	ids := map[string]string{}
+	err := r.client.WorkflowApi.IterateWorkflows(ctx, r.opts.PageSize, func(w lib.Workflow) error {
+		ids[w.Name] = w.Id
+		return nil
+	})
+	return ids, err
+}
+
+func (r *restorer) restorePreferences(ctx context.Context, s Subscriber, workflows map[string]string) error {
+	for _, p := range s.Preferences {
+		enabled := p.Preference.Enabled
+		channels := preferenceChannels(p.Preference.Channels)
+
+		if p.Level == lib.PreferenceLevelGlobal {
+			_, err := r.client.SubscriberApi.UpdateGlobalPreferences(ctx, s.SubscriberId, &lib.UpdateSubscriberGlobalPreferencesOptions{
+				Enabled:     &enabled,
+				Preferences: channels,
+			})
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		// The preferences of critical workflows cannot be changed.
+		if p.Template.Critical {
+			continue
+		}
+		id, ok := workflows[p.Template.Name]
+		if !ok {
+			r.result.warnf("preference of %s for workflow %q not restored: the workflow does not exist", s.SubscriberId, p.Template.Name)
+			continue
+		}
+		r.result.IDs[p.Template.ID] = id
+		_, err := r.client.SubscriberApi.UpdatePreferences(ctx, s.SubscriberId, id, &lib.UpdateSubscriberPreferencesOptions{Enabled: &enabled})
+		if err != nil {
+			return err
+		}
+		for _, c := range channels {
+			c := c
+			_, err := r.client.SubscriberApi.UpdatePreferences(ctx, s.SubscriberId, id, &lib.UpdateSubscriberPreferencesOptions{
+				Channel: []lib.UpdateSubscriberPreferencesChannel{c},
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// preferenceChannels lists the channels with a preference set.
+func preferenceChannels(c lib.Channel) []lib.UpdateSubscriberPreferencesChannel {
+	set := map[lib.ChannelType]*bool{
+		lib.EMAIL:  c.Email,
+		lib.SMS:    c.Sms,
+		lib.CHAT:   c.Chat,
+		lib.IN_APP: c.InApp,
+		lib.PUSH:   c.Push,
+	}
+	var channels []lib.UpdateSubscriberPreferencesChannel
+	for channel, enabled := range set {
+		if enabled != nil {
+			channels = append(channels, lib.UpdateSubscriberPreferencesChannel{Type: channel, Enabled: *enabled})
+		}
+	}
+	sort.Slice(channels, func(i, j int) bool { return channels[i].Type < channels[j].Type })
+	return channels
+}
+
+func (r *restorer) restoreTopics(ctx context.Context, a *Archive) error {
+	live := map[string]lib.GetTopicResponse{}
+	err := r.client.TopicsApi.IterateTopics(ctx, r.opts.PageSize, func(t lib.GetTopicResponse) error {
+		live[t.Key] = t
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, t := range a.Topics {
+		existing, ok := live[t.Key]
+		switch {
+		case !ok:
+			if err := r.client.TopicsApi.Create(ctx, t.Key, t.Name); err != nil {
+				return errors.Wrapf(err, "topic %s", t.Key)
+			}
+			if len(t.Subscribers) > 0 {
+				if err := r.client.TopicsApi.AddSubscribers(ctx, t.Key, t.Subscribers); err != nil {
+					return errors.Wrapf(err, "topic %s", t.Key)
+				}
+			}
+			r.result.Created[KindTopic]++
+		case r.opts.Overwrite:
+			if existing.Name != t.Name {
+				if _, err := r.client.TopicsApi.Rename(ctx, t.Key, t.Name); err != nil {
+					return errors.Wrapf(err, "topic %s", t.Key)
+				}
+			}
+			if len(t.Subscribers) > 0 {
+				if err := r.client.TopicsApi.AddSubscribers(ctx, t.Key, t.Subscribers); err != nil {
+					return errors.Wrapf(err, "topic %s", t.Key)
+				}
+			}
+			r.result.Updated[KindTopic]++
+		default:
+			// Adding subscribers is idempotent and completes the topics of
+			// an earlier restore that stopped before adding them.
+			if len(t.Subscribers) > 0 {
+				if err := r.client.TopicsApi.AddSubscribers(ctx, t.Key, t.Subscribers); err != nil {
+					return errors.Wrapf(err, "topic %s", t.Key)
+				}
+			}
+			r.result.Skipped[KindTopic]++
+		}
+	}
+	return nil
+}
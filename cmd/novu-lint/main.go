@@ -0,0 +1,141 @@
+// Command novu-lint checks the variables of Novu layouts, workflows and
+// templates against a sample trigger payload. It exits with status 1 when
+// issues of the kinds given to -fail-on are found, which makes it usable as a
+// CI check:
+//
+//	novu-lint -payload payload.json -layout layout.json -workflow workflow.json welcome.hbs
+//
+// Layouts and workflows are JSON documents as returned by the API, layouts
+// may also be given as raw Handlebars. Other arguments are Handlebars files.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	novu "github.com/novuhq/go-novu/lib"
+	"github.com/novuhq/go-novu/lint"
+)
+
+type files []string
+
+func (f *files) String() string     { return strings.Join(*f, ",") }
+func (f *files) Set(v string) error { *f = append(*f, v); return nil }
+
+func main() {
+	var layouts, workflows files
+	payloadFile := flag.String("payload", "", "JSON file with a sample trigger payload")
+	failOn := flag.String("fail-on", "missing,mistyped", "comma separated issue kinds that fail the check: missing, mistyped, unused")
+	flag.Var(&layouts, "layout", "layout JSON or Handlebars file, can be repeated")
+	flag.Var(&workflows, "workflow", "workflow JSON file, can be repeated")
+	flag.Parse()
+
+	if *payloadFile == "" {
+		fmt.Fprintln(os.Stderr, "novu-lint: -payload is required")
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	issues, err := run(*payloadFile, layouts, workflows, flag.Args())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "novu-lint:", err)
+		os.Exit(2)
+	}
+
+	failing := map[lint.IssueKind]bool{}
+	for _, kind := range strings.Split(*failOn, ",") {
+		if kind = strings.TrimSpace(kind); kind != "" {
+			failing[lint.IssueKind(kind)] = true
+		}
+	}
+	failed := false
+	for _, issue := range issues {
+		fmt.Println(issue)
+		failed = failed || failing[issue.Kind]
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func run(payloadFile string, layouts, workflows, templates []string) ([]lint.Issue, error) {
+	var payload interface{}
+	if err := readJSON(payloadFile, &payload); err != nil {
+		return nil, err
+	}
+
+	var sources []lint.Source
+	for _, file := range layouts {
+		source, err := layoutSource(file)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, source)
+	}
+	for _, file := range workflows {
+		var workflow struct {
+			novu.Workflow
+			Data *novu.Workflow `json:"data"`
+		}
+		if err := readJSON(file, &workflow); err != nil {
+			return nil, err
+		}
+		if workflow.Data != nil {
+			workflow.Workflow = *workflow.Data
+		}
+		if workflow.Name == "" {
+			workflow.Name = filepath.Base(file)
+		}
+		sources = append(sources, lint.WorkflowSources(workflow.Name, workflow.Steps)...)
+	}
+	for _, file := range templates {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, lint.Source{Name: file, Content: string(content)})
+	}
+
+	report, err := lint.Lint(payload, sources...)
+	if err != nil {
+		return nil, err
+	}
+	return report.Issues, nil
+}
+
+func layoutSource(file string) (lint.Source, error) {
+	if filepath.Ext(file) != ".json" {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return lint.Source{}, err
+		}
+		return lint.Source{Name: file, Content: string(content)}, nil
+	}
+
+	var layout struct {
+		novu.LayoutResponse
+		Data *novu.LayoutResponse `json:"data"`
+	}
+	if err := readJSON(file, &layout); err != nil {
+		return lint.Source{}, err
+	}
+	if layout.Data != nil {
+		layout.LayoutResponse = *layout.Data
+	}
+	return lint.LayoutSource(layout.LayoutResponse), nil
+}
+
+func readJSON(file string, v interface{}) error {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(content, v); err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+	return nil
+}
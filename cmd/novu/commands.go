@@ -0,0 +1,874 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/novuhq/go-novu/backup"
+	novu "github.com/novuhq/go-novu/lib"
+)
+
+var groups = []*group{
+	{name: "trigger", summary: "trigger a workflow", commands: []*command{triggerCommand}},
+	{name: "subscribers", summary: "manage subscribers", commands: subscriberCommands},
+	{name: "topics", summary: "manage topics and their subscribers", commands: topicCommands},
+	{name: "layouts", summary: "manage layouts", commands: layoutCommands},
+	{name: "tenants", summary: "manage tenants", commands: tenantCommands},
+	{name: "feeds", summary: "manage feeds", commands: feedCommands},
+	{name: "integrations", summary: "manage integrations", commands: integrationCommands},
+	{name: "messages", summary: "list and delete messages", commands: messageCommands},
+	{name: "changes", summary: "list and promote pending changes", commands: changeCommands},
+	{name: "backup", summary: "export and restore the environment", commands: backupCommands},
+}
+
+// exactArgs checks the number of positional arguments of a command.
+func exactArgs(args []string, n int) error {
+	if len(args) != n {
+		return errors.Wrapf(errUsage, "expected %d arguments, got %d", n, len(args))
+	}
+	return nil
+}
+
+// jsonValue decodes a JSON flag value, reading it from a file when it starts
+// with @.
+func jsonValue(value string, v interface{}) error {
+	data := []byte(value)
+	if strings.HasPrefix(value, "@") {
+		var err error
+		if data, err = os.ReadFile(value[1:]); err != nil {
+			return err
+		}
+	}
+	return errors.Wrap(json.Unmarshal(data, v), "invalid JSON")
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+var triggerCommand = func() *command {
+	var to, topic, payload, overrides, transactionId, tenant, actor string
+	return &command{
+		args:    "<workflow>",
+		summary: "Trigger a workflow for subscribers or topics.",
+		flags: func(fs *flag.FlagSet) {
+			fs.StringVar(&to, "to", "", "comma separated subscriber ids")
+			fs.StringVar(&topic, "topic", "", "comma separated topic keys")
+			fs.StringVar(&payload, "payload", "", "payload as JSON, or @file")
+			fs.StringVar(&overrides, "overrides", "", "overrides as JSON, or @file")
+			fs.StringVar(&transactionId, "transaction-id", "", "transaction id, generated by Novu when empty")
+			fs.StringVar(&tenant, "tenant", "", "tenant identifier")
+			fs.StringVar(&actor, "actor", "", "subscriber id of the actor")
+		},
+		run: func(ctx context.Context, e *env, args []string) error {
+			if err := exactArgs(args, 1); err != nil {
+				return err
+			}
+			var recipients []interface{}
+			for _, id := range splitList(to) {
+				recipients = append(recipients, id)
+			}
+			for _, key := range splitList(topic) {
+				recipients = append(recipients, map[string]string{"type": "Topic", "topicKey": key})
+			}
+			if len(recipients) == 0 {
+				return errors.Wrap(errUsage, "-to or -topic is required")
+			}
+
+			data := novu.ITriggerPayloadOptions{To: recipients, TransactionId: transactionId}
+			if payload != "" {
+				if err := jsonValue(payload, &data.Payload); err != nil {
+					return errors.Wrap(err, "-payload")
+				}
+			}
+			if overrides != "" {
+				if err := jsonValue(overrides, &data.Overrides); err != nil {
+					return errors.Wrap(err, "-overrides")
+				}
+			}
+			if tenant != "" {
+				data.Tenant = &novu.TriggerTenant{Identifier: tenant}
+			}
+			if actor != "" {
+				data.Actor = actor
+			}
+
+			resp, err := e.client.EventApi.Trigger(ctx, args[0], data)
+			if err != nil {
+				return err
+			}
+			return e.out.print(resp.Data, "transactionId", "status", "acknowledged")
+		},
+	}
+}()
+
+var pageFlags = func(page, limit *int) func(fs *flag.FlagSet) {
+	return func(fs *flag.FlagSet) {
+		fs.IntVar(page, "page", 0, "page to list")
+		fs.IntVar(limit, "limit", 20, "number of items per page")
+	}
+}
+
+var subscriberColumns = []string{"subscriberId", "firstName", "lastName", "email", "phone"}
+
+var subscriberCommands = []*command{
+	func() *command {
+		var page, limit int
+		return &command{
+			name:    "list",
+			summary: "List subscribers.",
+			flags:   pageFlags(&page, &limit),
+			run: func(ctx context.Context, e *env, args []string) error {
+				resp, err := e.client.SubscriberApi.List(ctx, page, limit)
+				if err != nil {
+					return err
+				}
+				return e.out.print(resp.Data, subscriberColumns...)
+			},
+		}
+	}(),
+	{
+		name:    "get",
+		args:    "<subscriber-id>",
+		summary: "Show a subscriber.",
+		run: func(ctx context.Context, e *env, args []string) error {
+			if err := exactArgs(args, 1); err != nil {
+				return err
+			}
+			resp, err := e.client.SubscriberApi.Get(ctx, args[0])
+			if err != nil {
+				return err
+			}
+			return e.out.print(resp.Data, subscriberColumns...)
+		},
+	},
+	func() *command {
+		var subscriber novu.SubscriberPayload
+		var data string
+		return &command{
+			name:    "create",
+			args:    "<subscriber-id>",
+			summary: "Create or update a subscriber.",
+			flags: func(fs *flag.FlagSet) {
+				subscriber.Data = nil
+				fs.StringVar(&subscriber.Email, "email", "", "email address")
+				fs.StringVar(&subscriber.FirstName, "first-name", "", "first name")
+				fs.StringVar(&subscriber.LastName, "last-name", "", "last name")
+				fs.StringVar(&subscriber.Phone, "phone", "", "phone number")
+				fs.StringVar(&subscriber.Avatar, "avatar", "", "avatar url")
+				fs.StringVar(&subscriber.Locale, "locale", "", "locale")
+				fs.StringVar(&data, "data", "", "custom data as JSON, or @file")
+			},
+			run: func(ctx context.Context, e *env, args []string) error {
+				if err := exactArgs(args, 1); err != nil {
+					return err
+				}
+				if data != "" {
+					if err := jsonValue(data, &subscriber.Data); err != nil {
+						return errors.Wrap(err, "-data")
+					}
+				}
+				resp, err := e.client.SubscriberApi.Identify(ctx, args[0], subscriber)
+				if err != nil {
+					return err
+				}
+				return e.out.print(resp.Data, subscriberColumns...)
+			},
+		}
+	}(),
+	{
+		name:    "delete",
+		args:    "<subscriber-id>",
+		summary: "Delete a subscriber.",
+		run: func(ctx context.Context, e *env, args []string) error {
+			if err := exactArgs(args, 1); err != nil {
+				return err
+			}
+			resp, err := e.client.SubscriberApi.Delete(ctx, args[0])
+			if err != nil {
+				return err
+			}
+			return e.out.print(resp.Data)
+		},
+	},
+	{
+		name:    "preferences",
+		args:    "<subscriber-id>",
+		summary: "Show the workflow preferences of a subscriber.",
+		run: func(ctx context.Context, e *env, args []string) error {
+			if err := exactArgs(args, 1); err != nil {
+				return err
+			}
+			resp, err := e.client.SubscriberApi.GetPreferences(ctx, args[0])
+			if err != nil {
+				return err
+			}
+			return e.out.print(resp.Data, "template.name", "preference.enabled", "preference.channels")
+		},
+	},
+	func() *command {
+		var opts novu.SubscriberImportOptions
+		var format, columns string
+		return &command{
+			name:    "import",
+			args:    "<file>",
+			summary: "Create or update the subscribers of a CSV or JSONL file, resuming from the checkpoint of an interrupted import.",
+			flags: func(fs *flag.FlagSet) {
+				fs.StringVar(&format, "format", "", "file format: csv or jsonl, defaults to jsonl for .jsonl files and csv otherwise")
+				fs.StringVar(&columns, "columns", "", "CSV header to field mapping as JSON, such as {\"Mail\": \"email\", \"Plan\": \"data.plan\"}, or @file")
+				fs.IntVar(&opts.BatchSize, "batch-size", novu.MaxSubscriberBulkSize, "number of subscribers per request")
+				fs.IntVar(&opts.Concurrency, "concurrency", novu.DefaultBulkConcurrency, "number of requests in flight")
+				fs.StringVar(&opts.CheckpointFile, "checkpoint", "", "checkpoint file, defaults to the file name followed by .checkpoint")
+			},
+			run: func(ctx context.Context, e *env, args []string) error {
+				if err := exactArgs(args, 1); err != nil {
+					return err
+				}
+				if columns != "" {
+					if err := jsonValue(columns, &opts.Columns); err != nil {
+						return errors.Wrap(err, "-columns")
+					}
+				}
+				opts.Format = novu.SubscriberImportFormat(format)
+				if format == "" {
+					opts.Format = novu.SubscriberImportCSV
+					if strings.HasSuffix(args[0], ".jsonl") {
+						opts.Format = novu.SubscriberImportJSONL
+					}
+				}
+				if opts.CheckpointFile == "" {
+					opts.CheckpointFile = args[0] + ".checkpoint"
+				}
+				opts.OnBatch = func(batch novu.SubscriberImportBatch) {
+					for _, failure := range batch.Failed {
+						if failure.Record > 0 {
+							fmt.Fprintf(e.stderr, "record %d: %s\n", failure.Record, failure.Message)
+						} else {
+							fmt.Fprintf(e.stderr, "subscriber %s: %s\n", failure.SubscriberId, failure.Message)
+						}
+					}
+				}
+
+				f, err := os.Open(args[0])
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				result, err := e.client.SubscriberApi.Import(ctx, f, &opts)
+				if err != nil {
+					if result != nil && result.Records > 0 {
+						fmt.Fprintf(e.stderr, "Imported %d records, run the command again to resume.\n", result.Records)
+					}
+					return err
+				}
+				return e.out.print(result, "records", "created", "updated", "failed")
+			},
+		}
+	}(),
+}
+
+var topicColumns = []string{"key", "name", "subscribers"}
+
+var topicCommands = []*command{
+	func() *command {
+		var page, limit int
+		return &command{
+			name:    "list",
+			summary: "List topics.",
+			flags:   pageFlags(&page, &limit),
+			run: func(ctx context.Context, e *env, args []string) error {
+				resp, err := e.client.TopicsApi.List(ctx, &novu.ListTopicsOptions{Page: &page, PageSize: &limit})
+				if err != nil {
+					return err
+				}
+				return e.out.print(resp.Data, topicColumns...)
+			},
+		}
+	}(),
+	{
+		name:    "get",
+		args:    "<key>",
+		summary: "Show a topic and its subscribers.",
+		run: func(ctx context.Context, e *env, args []string) error {
+			if err := exactArgs(args, 1); err != nil {
+				return err
+			}
+			resp, err := e.client.TopicsApi.Get(ctx, args[0])
+			if err != nil {
+				return err
+			}
+			return e.out.print(resp, topicColumns...)
+		},
+	},
+	{
+		name:    "create",
+		args:    "<key> <name>",
+		summary: "Create a topic.",
+		run: func(ctx context.Context, e *env, args []string) error {
+			if err := exactArgs(args, 2); err != nil {
+				return err
+			}
+			return e.client.TopicsApi.Create(ctx, args[0], args[1])
+		},
+	},
+	{
+		name:    "rename",
+		args:    "<key> <name>",
+		summary: "Rename a topic.",
+		run: func(ctx context.Context, e *env, args []string) error {
+			if err := exactArgs(args, 2); err != nil {
+				return err
+			}
+			resp, err := e.client.TopicsApi.Rename(ctx, args[0], args[1])
+			if err != nil {
+				return err
+			}
+			return e.out.print(resp, topicColumns...)
+		},
+	},
+	{
+		name:    "delete",
+		args:    "<key>",
+		summary: "Delete a topic.",
+		run: func(ctx context.Context, e *env, args []string) error {
+			if err := exactArgs(args, 1); err != nil {
+				return err
+			}
+			return e.client.TopicsApi.Delete(ctx, args[0])
+		},
+	},
+	{
+		name:    "add",
+		args:    "<key> <subscriber-id>...",
+		summary: "Add subscribers to a topic.",
+		run: func(ctx context.Context, e *env, args []string) error {
+			if len(args) < 2 {
+				return errors.Wrap(errUsage, "expected a topic key and subscriber ids")
+			}
+			return e.client.TopicsApi.AddSubscribers(ctx, args[0], args[1:])
+		},
+	},
+	{
+		name:    "remove",
+		args:    "<key> <subscriber-id>...",
+		summary: "Remove subscribers from a topic.",
+		run: func(ctx context.Context, e *env, args []string) error {
+			if len(args) < 2 {
+				return errors.Wrap(errUsage, "expected a topic key and subscriber ids")
+			}
+			return e.client.TopicsApi.RemoveSubscribers(ctx, args[0], args[1:])
+		},
+	},
+}
+
+var layoutColumns = []string{"_id", "identifier", "name", "isDefault", "updatedAt"}
+
+var layoutCommands = []*command{
+	func() *command {
+		var page, limit int
+		return &command{
+			name:    "list",
+			summary: "List layouts.",
+			flags:   pageFlags(&page, &limit),
+			run: func(ctx context.Context, e *env, args []string) error {
+				resp, err := e.client.LayoutApi.List(ctx, &novu.LayoutRequestOptions{Page: &page, PageSize: &limit})
+				if err != nil {
+					return err
+				}
+				return e.out.print(resp.Data, layoutColumns...)
+			},
+		}
+	}(),
+	{
+		name:    "get",
+		args:    "<layout-id>",
+		summary: "Show a layout.",
+		run: func(ctx context.Context, e *env, args []string) error {
+			if err := exactArgs(args, 1); err != nil {
+				return err
+			}
+			resp, err := e.client.LayoutApi.Get(ctx, args[0])
+			if err != nil {
+				return err
+			}
+			return e.out.print(resp, layoutColumns...)
+		},
+	},
+	{
+		name:    "create",
+		args:    "<layout.json>",
+		summary: "Create a layout from a JSON file with its name, identifier, content and variables.",
+		run: func(ctx context.Context, e *env, args []string) error {
+			if err := exactArgs(args, 1); err != nil {
+				return err
+			}
+			var request novu.CreateLayoutRequest
+			if err := jsonValue("@"+args[0], &request); err != nil {
+				return err
+			}
+			resp, err := e.client.LayoutApi.Create(ctx, request)
+			if err != nil {
+				return err
+			}
+			return e.out.print(resp.Data)
+		},
+	},
+	{
+		name:    "set-default",
+		args:    "<layout-id>",
+		summary: "Make a layout the default one.",
+		run: func(ctx context.Context, e *env, args []string) error {
+			if err := exactArgs(args, 1); err != nil {
+				return err
+			}
+			return e.client.LayoutApi.SetDefault(ctx, args[0])
+		},
+	},
+	{
+		name:    "delete",
+		args:    "<layout-id>",
+		summary: "Delete a layout.",
+		run: func(ctx context.Context, e *env, args []string) error {
+			if err := exactArgs(args, 1); err != nil {
+				return err
+			}
+			return e.client.LayoutApi.Delete(ctx, args[0])
+		},
+	},
+}
+
+var tenantColumns = []string{"identifier", "name", "data", "updatedAt"}
+
+var tenantCommands = []*command{
+	func() *command {
+		var page, limit int
+		return &command{
+			name:    "list",
+			summary: "List tenants.",
+			flags:   pageFlags(&page, &limit),
+			run: func(ctx context.Context, e *env, args []string) error {
+				resp, err := e.client.TenantApi.GetTenants(ctx, page, limit)
+				if err != nil {
+					return err
+				}
+				return e.out.print(resp.Data, tenantColumns...)
+			},
+		}
+	}(),
+	{
+		name:    "get",
+		args:    "<identifier>",
+		summary: "Show a tenant.",
+		run: func(ctx context.Context, e *env, args []string) error {
+			if err := exactArgs(args, 1); err != nil {
+				return err
+			}
+			resp, err := e.client.TenantApi.GetTenant(ctx, args[0])
+			if err != nil {
+				return err
+			}
+			return e.out.print(resp.Data, tenantColumns...)
+		},
+	},
+	func() *command {
+		var data string
+		return &command{
+			name:    "create",
+			args:    "<identifier> <name>",
+			summary: "Create a tenant, or update the tenant with the same identifier.",
+			flags: func(fs *flag.FlagSet) {
+				fs.StringVar(&data, "data", "", "custom data as JSON, or @file")
+			},
+			run: func(ctx context.Context, e *env, args []string) error {
+				if err := exactArgs(args, 2); err != nil {
+					return err
+				}
+				request := novu.CreateTenantRequest{Identifier: args[0], Name: args[1]}
+				if data != "" {
+					if err := jsonValue(data, &request.Data); err != nil {
+						return errors.Wrap(err, "-data")
+					}
+				}
+				resp, err := e.client.TenantApi.UpsertTenant(ctx, request)
+				if err != nil {
+					return err
+				}
+				return e.out.print(resp.Data, tenantColumns...)
+			},
+		}
+	}(),
+	{
+		name:    "delete",
+		args:    "<identifier>",
+		summary: "Delete a tenant.",
+		run: func(ctx context.Context, e *env, args []string) error {
+			if err := exactArgs(args, 1); err != nil {
+				return err
+			}
+			return e.client.TenantApi.DeleteTenant(ctx, args[0])
+		},
+	},
+}
+
+var feedColumns = []string{"_id", "identifier", "name"}
+
+var feedCommands = []*command{
+	{
+		name:    "list",
+		summary: "List feeds.",
+		run: func(ctx context.Context, e *env, args []string) error {
+			resp, err := e.client.FeedsApi.GetFeeds(ctx)
+			if err != nil {
+				return err
+			}
+			return e.out.print(resp.Data, feedColumns...)
+		},
+	},
+	{
+		name:    "create",
+		args:    "<name>",
+		summary: "Create a feed.",
+		run: func(ctx context.Context, e *env, args []string) error {
+			if err := exactArgs(args, 1); err != nil {
+				return err
+			}
+			resp, err := e.client.FeedsApi.CreateFeed(ctx, args[0])
+			if err != nil {
+				return err
+			}
+			return e.out.print(resp.Data, feedColumns...)
+		},
+	},
+	{
+		name:    "delete",
+		args:    "<feed-id>",
+		summary: "Delete a feed.",
+		run: func(ctx context.Context, e *env, args []string) error {
+			if err := exactArgs(args, 1); err != nil {
+				return err
+			}
+			_, err := e.client.FeedsApi.DeleteFeed(ctx, args[0])
+			return err
+		},
+	},
+}
+
+// Credentials are left out of the table and masked in JSON.
+var integrationColumns = []string{"_id", "identifier", "providerId", "channel", "active", "primary"}
+
+type maskedIntegration struct {
+	novu.Integration
+	Credentials interface{} `json:"credentials"`
+}
+
+var integrationCommands = []*command{
+	func() *command {
+		var active bool
+		return &command{
+			name:    "list",
+			summary: "List integrations, with their secrets masked.",
+			flags: func(fs *flag.FlagSet) {
+				fs.BoolVar(&active, "active", false, "only list active integrations")
+			},
+			run: func(ctx context.Context, e *env, args []string) error {
+				list := e.client.IntegrationsApi.GetAll
+				if active {
+					list = e.client.IntegrationsApi.GetActive
+				}
+				resp, err := list(ctx)
+				if err != nil {
+					return err
+				}
+				integrations := make([]maskedIntegration, 0, len(resp.Data))
+				for _, i := range resp.Data {
+					integrations = append(integrations, maskedIntegration{Integration: i, Credentials: i.Credentials.MarshalLog()})
+				}
+				return e.out.print(integrations, integrationColumns...)
+			},
+		}
+	}(),
+	{
+		name:    "set-primary",
+		args:    "<integration-id>",
+		summary: "Make an integration the primary one of its channel.",
+		run: func(ctx context.Context, e *env, args []string) error {
+			if err := exactArgs(args, 1); err != nil {
+				return err
+			}
+			_, err := e.client.IntegrationsApi.SetIntegrationAsPrimary(ctx, args[0])
+			return err
+		},
+	},
+	{
+		name:    "delete",
+		args:    "<integration-id>",
+		summary: "Delete an integration.",
+		run: func(ctx context.Context, e *env, args []string) error {
+			if err := exactArgs(args, 1); err != nil {
+				return err
+			}
+			_, err := e.client.IntegrationsApi.Delete(ctx, args[0])
+			return err
+		},
+	},
+}
+
+var messageCommands = []*command{
+	func() *command {
+		var q novu.MessagesQueryParams
+		var transactionIds string
+		return &command{
+			name:    "list",
+			summary: "List messages.",
+			flags: func(fs *flag.FlagSet) {
+				fs.StringVar(&q.SubscriberId, "subscriber", "", "subscriber id")
+				fs.StringVar(&q.Channel, "channel", "", "channel: in_app, email, sms, chat or push")
+				fs.StringVar(&transactionIds, "transaction-id", "", "comma separated transaction ids")
+				pageFlags(&q.Page, &q.Limit)(fs)
+			},
+			run: func(ctx context.Context, e *env, args []string) error {
+				q.TransactionId = splitList(transactionIds)
+				resp, err := e.client.MessagesApi.ListMessages(ctx, q)
+				if err != nil {
+					return err
+				}
+				return e.out.print(resp.Data, "_id", "channel", "status", "transactionId", "createdAt")
+			},
+		}
+	}(),
+	{
+		name:    "delete",
+		args:    "<message-id>",
+		summary: "Delete a message.",
+		run: func(ctx context.Context, e *env, args []string) error {
+			if err := exactArgs(args, 1); err != nil {
+				return err
+			}
+			_, err := e.client.MessagesApi.DeleteMessage(ctx, args[0])
+			return err
+		},
+	},
+	func() *command {
+		var channel string
+		return &command{
+			name:    "delete-transaction",
+			args:    "<transaction-id>",
+			summary: "Delete the messages of a transaction.",
+			flags: func(fs *flag.FlagSet) {
+				fs.StringVar(&channel, "channel", "", "only delete the messages of this channel")
+			},
+			run: func(ctx context.Context, e *env, args []string) error {
+				if err := exactArgs(args, 1); err != nil {
+					return err
+				}
+				return e.client.MessagesApi.DeleteMessagesByTransactionId(ctx, args[0], novu.ChannelType(channel))
+			},
+		}
+	}(),
+}
+
+var changeColumns = []string{"_id", "type", "_entityId", "createdAt"}
+
+var changeCommands = []*command{
+	func() *command {
+		var page, limit int
+		return &command{
+			name:    "list",
+			summary: "List the changes pending promotion.",
+			flags:   pageFlags(&page, &limit),
+			run: func(ctx context.Context, e *env, args []string) error {
+				resp, err := e.client.ChangesApi.GetChanges(ctx, novu.ChangesGetQuery{Page: page, Limit: limit, Promoted: "false"})
+				if err != nil {
+					return err
+				}
+				return e.out.print(resp.Data, changeColumns...)
+			},
+		}
+	}(),
+	{
+		name:    "count",
+		summary: "Count the changes pending promotion.",
+		run: func(ctx context.Context, e *env, args []string) error {
+			resp, err := e.client.ChangesApi.GetChangesCount(ctx)
+			if err != nil {
+				return err
+			}
+			return e.out.print(map[string]int{"count": resp.Data})
+		},
+	},
+	{
+		name:    "apply",
+		args:    "<change-id>...",
+		summary: "Promote the given changes.",
+		run: func(ctx context.Context, e *env, args []string) error {
+			if len(args) == 0 {
+				return errors.Wrap(errUsage, "expected change ids")
+			}
+			resp, err := e.client.ChangesApi.ApplyBulkChanges(ctx, novu.ChangesBulkApplyPayload{ChangeIds: args})
+			if err != nil {
+				return err
+			}
+			return e.out.print(resp.Data, changeColumns...)
+		},
+	},
+	func() *command {
+		var dryRun bool
+		var types string
+		return &command{
+			name:    "promote",
+			summary: "Promote every pending change in dependency order.",
+			flags: func(fs *flag.FlagSet) {
+				fs.BoolVar(&dryRun, "dry-run", false, "print the plan without applying it")
+				fs.StringVar(&types, "type", "", "comma separated change types to promote, with their dependencies")
+			},
+			run: func(ctx context.Context, e *env, args []string) error {
+				opts := &novu.PromotionOptions{}
+				for _, t := range splitList(types) {
+					opts.Types = append(opts.Types, novu.ChangeType(t))
+				}
+				plan, err := e.client.ChangesApi.PlanPromotion(ctx, opts)
+				if err != nil {
+					return err
+				}
+				result, err := e.client.ChangesApi.Promote(ctx, plan, &novu.PromoteOptions{DryRun: dryRun, Output: e.out.w})
+				if err != nil {
+					return err
+				}
+				if !dryRun {
+					fmt.Fprintf(e.out.w, "Promoted %d changes in %d batches.\n", len(result.Applied), result.Batches)
+				}
+				return nil
+			},
+		}
+	}(),
+}
+
+var backupCommands = []*command{
+	func() *command {
+		var format string
+		var opts backup.ExportOptions
+		return &command{
+			name:    "export",
+			args:    "<file>",
+			summary: "Export the environment into an archive, written to stdout when the file is -.",
+			flags: func(fs *flag.FlagSet) {
+				fs.StringVar(&format, "format", "", "archive format: tar or json, defaults to json for .json files and tar otherwise")
+				fs.BoolVar(&opts.SkipSubscribers, "skip-subscribers", false, "leave the subscribers out")
+				fs.BoolVar(&opts.SkipPreferences, "skip-preferences", false, "leave the preferences of the subscribers out")
+			},
+			run: func(ctx context.Context, e *env, args []string) error {
+				if err := exactArgs(args, 1); err != nil {
+					return err
+				}
+				if format == "" {
+					format = "tar"
+					if strings.HasSuffix(args[0], ".json") || args[0] == "-" {
+						format = "json"
+					}
+				}
+				if format != "tar" && format != "json" {
+					return errors.Wrapf(errUsage, "unknown format %q", format)
+				}
+
+				a, err := backup.Export(ctx, e.client, &opts)
+				if err != nil {
+					return err
+				}
+				write := a.WriteTar
+				if format == "json" {
+					write = a.WriteJSON
+				}
+				summary := e.out.w
+				if args[0] == "-" {
+					if err := write(e.out.w); err != nil {
+						return err
+					}
+					summary = e.stderr
+				} else {
+					f, err := os.Create(args[0])
+					if err != nil {
+						return err
+					}
+					if err := write(f); err != nil {
+						f.Close()
+						return err
+					}
+					if err := f.Close(); err != nil {
+						return err
+					}
+				}
+				fmt.Fprintf(summary, "Exported %d layouts, %d feeds, %d tenants, %d integrations, %d topics and %d subscribers.\n",
+					len(a.Layouts), len(a.Feeds), len(a.Tenants), len(a.Integrations), len(a.Topics), len(a.Subscribers))
+				return nil
+			},
+		}
+	}(),
+	func() *command {
+		var credentials string
+		var opts backup.RestoreOptions
+		return &command{
+			name:    "restore",
+			args:    "<file>",
+			summary: "Restore an archive into the environment, reading it from stdin when the file is -.",
+			flags: func(fs *flag.FlagSet) {
+				fs.BoolVar(&opts.Overwrite, "overwrite", false, "update the resources that already exist")
+				fs.StringVar(&credentials, "credentials", "", "secret integration credentials by identifier as JSON, or @file")
+			},
+			run: func(ctx context.Context, e *env, args []string) error {
+				if err := exactArgs(args, 1); err != nil {
+					return err
+				}
+				if credentials != "" {
+					if err := jsonValue(credentials, &opts.Credentials); err != nil {
+						return errors.Wrap(err, "-credentials")
+					}
+				}
+
+				r := io.Reader(os.Stdin)
+				if args[0] != "-" {
+					f, err := os.Open(args[0])
+					if err != nil {
+						return err
+					}
+					defer f.Close()
+					r = f
+				}
+				a, err := backup.Read(r)
+				if err != nil {
+					return err
+				}
+
+				result, err := backup.Restore(ctx, e.client, a, &opts)
+				for _, warning := range result.Warnings {
+					fmt.Fprintln(e.stderr, "warning:", warning)
+				}
+				if err != nil {
+					return err
+				}
+				var rows []map[string]interface{}
+				for _, kind := range []string{backup.KindLayout, backup.KindFeed, backup.KindTenant, backup.KindIntegration, backup.KindSubscriber, backup.KindTopic} {
+					rows = append(rows, map[string]interface{}{
+						"kind":    kind,
+						"created": result.Created[kind],
+						"updated": result.Updated[kind],
+						"skipped": result.Skipped[kind],
+					})
+				}
+				return e.out.print(rows, "kind", "created", "updated", "skipped")
+			},
+		}
+	}(),
+}
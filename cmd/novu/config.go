@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+
+	novu "github.com/novuhq/go-novu/lib"
+)
+
+const defaultProfile = "default"
+
+type profile struct {
+	APIKey     string `json:"apiKey"`
+	BackendURL string `json:"backendUrl,omitempty"`
+}
+
+type configFile struct {
+	Profiles map[string]profile `json:"profiles"`
+}
+
+// loadConfig resolves the API key and url from the environment and the
+// profile. A missing configuration file is only an error when a profile is
+// explicitly selected. The environment overrides the profile, unless the
+// profile was selected with the -profile flag.
+func loadConfig(getenv func(string) string, name string, flagged bool) (profile, error) {
+	var p profile
+	explicit := name != ""
+	if !explicit {
+		name = defaultProfile
+	}
+
+	path := getenv("NOVU_CONFIG")
+	if path == "" {
+		if dir, err := os.UserConfigDir(); err == nil {
+			path = filepath.Join(dir, "novu", "config.json")
+		}
+	}
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		var file configFile
+		if err := json.Unmarshal(data, &file); err != nil {
+			return p, errors.Wrapf(err, "invalid configuration file %s", path)
+		}
+		found, ok := file.Profiles[name]
+		if !ok && explicit {
+			return p, errors.Errorf("no profile %q in %s", name, path)
+		}
+		p = found
+	case explicit || !os.IsNotExist(err):
+		return p, errors.Wrap(err, "unable to read the configuration file")
+	}
+
+	if flagged {
+		return p, nil
+	}
+	if key := getenv("NOVU_API_KEY"); key != "" {
+		p.APIKey = key
+	}
+	if url := getenv("NOVU_BACKEND_URL"); url != "" {
+		p.BackendURL = url
+	}
+	return p, nil
+}
+
+func (p profile) client() (*novu.APIClient, error) {
+	cfg := &novu.Config{}
+	if p.BackendURL != "" {
+		u, err := url.Parse(p.BackendURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, errors.Errorf("invalid backend url %q", p.BackendURL)
+		}
+		cfg.BackendURL = u
+	}
+	return novu.NewAPIClient(p.APIKey, cfg), nil
+}
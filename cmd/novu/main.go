@@ -0,0 +1,191 @@
+// Command novu manages a Novu environment from the command line.
+//
+//	novu [-profile name] [-output table|json] <group> <command> [flags] [args]
+//
+// The API key is read from the -api-key flag, the profile selected with
+// -profile, the NOVU_API_KEY environment variable or the profile selected
+// with NOVU_PROFILE, in that order; the same goes for the backend url.
+// Profiles live in $NOVU_CONFIG, by default novu/config.json in the user
+// configuration directory:
+//
+//	{"profiles": {"default": {"apiKey": "...", "backendUrl": "https://eu.api.novu.co"}}}
+//
+// Failed API calls print the Novu error as JSON on stderr and exit with
+// status 1; invalid usage exits with status 2.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	novu "github.com/novuhq/go-novu/lib"
+)
+
+// errUsage is returned by commands called with invalid arguments.
+var errUsage = errors.New("invalid usage")
+
+// env is what commands run with.
+type env struct {
+	client *novu.APIClient
+	out    *printer
+	stderr io.Writer
+}
+
+type command struct {
+	name    string
+	args    string
+	summary string
+	// flags declares the flags of the command; run is called after they are
+	// parsed with the remaining arguments.
+	flags func(fs *flag.FlagSet)
+	run   func(ctx context.Context, e *env, args []string) error
+}
+
+type group struct {
+	name     string
+	summary  string
+	commands []*command
+}
+
+func main() {
+	os.Exit(run(context.Background(), os.Args[1:], os.Stdout, os.Stderr, os.Getenv))
+}
+
+func run(ctx context.Context, args []string, stdout, stderr io.Writer, getenv func(string) string) int {
+	fs := flag.NewFlagSet("novu", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	profile := fs.String("profile", getenv("NOVU_PROFILE"), "profile of the configuration file to use")
+	apiKey := fs.String("api-key", "", "Novu API key, defaults to NOVU_API_KEY or the profile")
+	backendURL := fs.String("backend-url", "", "Novu API url, defaults to NOVU_BACKEND_URL or the profile")
+	output := fs.String("output", "table", "output format: table or json")
+	fs.Usage = func() { usage(fs) }
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *output != "table" && *output != "json" {
+		fmt.Fprintf(stderr, "novu: unknown output format %q\n", *output)
+		return 2
+	}
+
+	g, cmd, cmdArgs, ok := lookup(fs.Args())
+	if !ok {
+		fs.Usage()
+		return 2
+	}
+	cmdFlags := flag.NewFlagSet(g.name+" "+cmd.name, flag.ContinueOnError)
+	cmdFlags.SetOutput(stderr)
+	cmdFlags.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: novu %s %s [flags] %s\n\n%s\n", g.name, cmd.name, cmd.args, cmd.summary)
+		cmdFlags.PrintDefaults()
+	}
+	if cmd.flags != nil {
+		cmd.flags(cmdFlags)
+	}
+	if err := cmdFlags.Parse(cmdArgs); err != nil {
+		return 2
+	}
+
+	flagged := false
+	fs.Visit(func(f *flag.Flag) { flagged = flagged || f.Name == "profile" })
+	cfg, err := loadConfig(getenv, *profile, flagged)
+	if err != nil {
+		fmt.Fprintln(stderr, "novu:", err)
+		return 2
+	}
+	if *apiKey != "" {
+		cfg.APIKey = *apiKey
+	}
+	if *backendURL != "" {
+		cfg.BackendURL = *backendURL
+	}
+	if cfg.APIKey == "" {
+		fmt.Fprintln(stderr, "novu: no API key, set NOVU_API_KEY, -api-key or a profile")
+		return 2
+	}
+
+	client, err := cfg.client()
+	if err != nil {
+		fmt.Fprintln(stderr, "novu:", err)
+		return 2
+	}
+	e := &env{client: client, out: &printer{w: stdout, format: *output}, stderr: stderr}
+	err = cmd.run(ctx, e, cmdFlags.Args())
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, errUsage):
+		cmdFlags.Usage()
+		return 2
+	}
+
+	var apiErr *novu.APIError
+	if errors.As(err, &apiErr) {
+		printAPIError(stderr, apiErr)
+		return 1
+	}
+	fmt.Fprintln(stderr, "novu:", err)
+	return 1
+}
+
+// printAPIError prints the error document returned by Novu.
+func printAPIError(w io.Writer, err *novu.APIError) {
+	var document map[string]interface{}
+	if json.Unmarshal(err.Body, &document) != nil {
+		document = map[string]interface{}{"statusCode": err.StatusCode, "message": string(err.Body)}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(document)
+}
+
+func lookup(args []string) (*group, *command, []string, bool) {
+	if len(args) == 0 {
+		return nil, nil, nil, false
+	}
+	for _, g := range groups {
+		if g.name != args[0] {
+			continue
+		}
+		// Groups of a single command, such as trigger, take no command name.
+		if len(g.commands) == 1 && g.commands[0].name == "" {
+			return g, g.commands[0], args[1:], true
+		}
+		if len(args) < 2 {
+			return nil, nil, nil, false
+		}
+		for _, cmd := range g.commands {
+			if cmd.name == args[1] {
+				return g, cmd, args[2:], true
+			}
+		}
+	}
+	return nil, nil, nil, false
+}
+
+func usage(fs *flag.FlagSet) {
+	w := fs.Output()
+	fmt.Fprintln(w, "Usage: novu [flags] <group> <command> [flags] [args]")
+	fmt.Fprintln(w, "\nFlags:")
+	fs.PrintDefaults()
+	fmt.Fprintln(w, "\nCommands:")
+	for _, g := range groups {
+		names := make([]string, 0, len(g.commands))
+		for _, cmd := range g.commands {
+			names = append(names, cmd.name)
+		}
+		sort.Strings(names)
+		fmt.Fprintf(w, "  %-13s %s", g.name, g.summary)
+		if len(names) > 1 || names[0] != "" {
+			fmt.Fprintf(w, " (%s)", strings.Join(names, ", "))
+		}
+		fmt.Fprintln(w)
+	}
+}
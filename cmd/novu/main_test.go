@@ -0,0 +1,204 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordedRequest struct {
+	Method, Path, Authorization string
+	Body                        map[string]interface{}
+}
+
+func newServer(t *testing.T, requests *[]recordedRequest) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := recordedRequest{Method: r.Method, Path: r.URL.Path, Authorization: r.Header.Get("Authorization")}
+		if bb, _ := io.ReadAll(r.Body); len(bb) > 0 && r.Method != http.MethodGet {
+			require.NoError(t, json.Unmarshal(bb, &req.Body))
+		}
+		*requests = append(*requests, req)
+
+		switch r.URL.Path {
+		case "/v1/events/trigger":
+			w.Write([]byte(`{"data": {"acknowledged": true, "status": "processed", "transactionId": "tx-1"}}`))
+		case "/v1/tenants":
+			w.Write([]byte(`{"data": [{"identifier": "acme", "name": "Acme", "data": {"plan": "pro"}}, {"identifier": "globex", "name": "Globex"}], "hasMore": false}`))
+		case "/v1/integrations":
+			w.Write([]byte(`{"data": [{"_id": "int-1", "identifier": "sendgrid", "providerId": "sendgrid", "channel": "email", "active": true, "credentials": {"apiKey": "SG.secret", "from": "a@example.com"}}]}`))
+		case "/v1/subscribers/bulk":
+			w.Write([]byte(`{"data": {"created": [{"subscriberId": "sub-1"}], "updated": [{"subscriberId": "sub-2"}], "failed": []}}`))
+		case "/v1/layouts", "/v1/feeds", "/v1/topics", "/v1/subscribers", "/v1/workflows":
+			w.Write([]byte(`{"data": [], "totalCount": 0, "hasMore": false}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"statusCode": 404, "message": "Topic not found", "error": "Not Found"}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func runCLI(t *testing.T, env map[string]string, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := run(context.Background(), args, &stdout, &stderr, func(key string) string { return env[key] })
+	return code, stdout.String(), stderr.String()
+}
+
+func TestTrigger(t *testing.T) {
+	var requests []recordedRequest
+	server := newServer(t, &requests)
+	env := map[string]string{"NOVU_API_KEY": "env-key", "NOVU_BACKEND_URL": server.URL, "NOVU_CONFIG": filepath.Join(t.TempDir(), "missing.json")}
+
+	code, stdout, stderr := runCLI(t, env, "trigger", "-to", "sub-1,sub-2", "-topic", "beta", "-payload", `{"name": "Jane"}`, "-tenant", "acme", "welcome")
+	require.Equal(t, 0, code, stderr)
+	assert.Equal(t, "TRANSACTIONID  STATUS     ACKNOWLEDGED\ntx-1           processed  true\n", stdout)
+
+	require.Len(t, requests, 1)
+	assert.Equal(t, "ApiKey env-key", requests[0].Authorization)
+	assert.Equal(t, map[string]interface{}{
+		"name":    "welcome",
+		"to":      []interface{}{"sub-1", "sub-2", map[string]interface{}{"type": "Topic", "topicKey": "beta"}},
+		"payload": map[string]interface{}{"name": "Jane"},
+		"tenant":  "acme",
+	}, requests[0].Body)
+}
+
+func TestOutputFormats(t *testing.T) {
+	var requests []recordedRequest
+	server := newServer(t, &requests)
+	env := map[string]string{"NOVU_API_KEY": "env-key", "NOVU_BACKEND_URL": server.URL, "NOVU_CONFIG": filepath.Join(t.TempDir(), "missing.json")}
+
+	code, stdout, stderr := runCLI(t, env, "tenants", "list")
+	require.Equal(t, 0, code, stderr)
+	assert.Equal(t, "IDENTIFIER  NAME    DATA            UPDATEDAT\nacme        Acme    {\"plan\":\"pro\"}  -\nglobex      Globex  -               -\n", stdout)
+
+	code, stdout, stderr = runCLI(t, env, "-output", "json", "integrations", "list")
+	require.Equal(t, 0, code, stderr)
+	var integrations []map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(stdout), &integrations))
+	assert.Equal(t, map[string]interface{}{"apiKey": "********", "from": "a@example.com"}, integrations[0]["credentials"])
+}
+
+func TestErrors(t *testing.T) {
+	var requests []recordedRequest
+	server := newServer(t, &requests)
+	env := map[string]string{"NOVU_API_KEY": "env-key", "NOVU_BACKEND_URL": server.URL, "NOVU_CONFIG": filepath.Join(t.TempDir(), "missing.json")}
+
+	code, _, stderr := runCLI(t, env, "topics", "get", "unknown")
+	assert.Equal(t, 1, code)
+	var document map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(stderr), &document))
+	assert.Equal(t, map[string]interface{}{"statusCode": 404.0, "message": "Topic not found", "error": "Not Found"}, document)
+
+	code, _, stderr = runCLI(t, env, "topics", "get")
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr, "Usage: novu topics get [flags] <key>")
+
+	code, _, _ = runCLI(t, env, "topics", "unknown")
+	assert.Equal(t, 2, code)
+
+	code, _, stderr = runCLI(t, map[string]string{"NOVU_CONFIG": env["NOVU_CONFIG"]}, "tenants", "list")
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr, "no API key")
+}
+
+func TestProfiles(t *testing.T) {
+	var requests []recordedRequest
+	server := newServer(t, &requests)
+	config := filepath.Join(t.TempDir(), "config.json")
+	require.NoError(t, os.WriteFile(config, []byte(`{"profiles": {
+		"default": {"apiKey": "default-key", "backendUrl": "`+server.URL+`"},
+		"staging": {"apiKey": "staging-key", "backendUrl": "`+server.URL+`"}
+	}}`), 0o600))
+
+	code, _, stderr := runCLI(t, map[string]string{"NOVU_CONFIG": config}, "tenants", "list")
+	require.Equal(t, 0, code, stderr)
+	code, _, stderr = runCLI(t, map[string]string{"NOVU_CONFIG": config, "NOVU_PROFILE": "staging"}, "tenants", "list")
+	require.Equal(t, 0, code, stderr)
+	// The environment overrides the profile, unless it is given by -profile.
+	code, _, stderr = runCLI(t, map[string]string{"NOVU_CONFIG": config, "NOVU_PROFILE": "staging", "NOVU_API_KEY": "env-key"}, "tenants", "list")
+	require.Equal(t, 0, code, stderr)
+	code, _, stderr = runCLI(t, map[string]string{"NOVU_CONFIG": config, "NOVU_API_KEY": "env-key", "NOVU_BACKEND_URL": "http://unused.invalid"}, "-profile", "staging", "tenants", "list")
+	require.Equal(t, 0, code, stderr)
+	code, _, stderr = runCLI(t, map[string]string{"NOVU_CONFIG": config}, "-profile", "staging", "-api-key", "flag-key", "tenants", "list")
+	require.Equal(t, 0, code, stderr)
+
+	var keys []string
+	for _, r := range requests {
+		keys = append(keys, r.Authorization)
+	}
+	assert.Equal(t, []string{"ApiKey default-key", "ApiKey staging-key", "ApiKey env-key", "ApiKey staging-key", "ApiKey flag-key"}, keys)
+
+	code, _, stderr = runCLI(t, map[string]string{"NOVU_CONFIG": config}, "-backend-url", "://novu", "tenants", "list")
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr, `invalid backend url "://novu"`)
+	code, _, stderr = runCLI(t, map[string]string{"NOVU_CONFIG": config, "NOVU_BACKEND_URL": "api.novu.co"}, "tenants", "list")
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr, `invalid backend url "api.novu.co"`)
+
+	code, _, stderr = runCLI(t, map[string]string{"NOVU_CONFIG": config}, "-profile", "production", "tenants", "list")
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr, `no profile "production"`)
+}
+
+func TestBackup(t *testing.T) {
+	var requests []recordedRequest
+	server := newServer(t, &requests)
+	dir := t.TempDir()
+	env := map[string]string{"NOVU_API_KEY": "env-key", "NOVU_BACKEND_URL": server.URL, "NOVU_CONFIG": filepath.Join(dir, "missing.json")}
+	archive := filepath.Join(dir, "backup.tar.gz")
+
+	code, stdout, stderr := runCLI(t, env, "backup", "export", archive)
+	require.Equal(t, 0, code, stderr)
+	assert.Equal(t, "Exported 0 layouts, 0 feeds, 2 tenants, 1 integrations, 0 topics and 0 subscribers.\n", stdout)
+
+	code, stdout, stderr = runCLI(t, env, "backup", "restore", archive)
+	require.Equal(t, 0, code, stderr)
+	assert.Equal(t, `KIND         CREATED  UPDATED  SKIPPED
+layout       0        0        0
+feed         0        0        0
+tenant       0        0        2
+integration  0        0        1
+subscriber   0        0        0
+topic        0        0        0
+`, stdout)
+	for _, r := range requests {
+		assert.Equal(t, http.MethodGet, r.Method, "restoring into the same environment changes nothing")
+	}
+
+	code, stdout, stderr = runCLI(t, env, "backup", "export", "-skip-subscribers", "-")
+	require.Equal(t, 0, code, stderr)
+	assert.Contains(t, stdout, `"version": 1`)
+	assert.NotContains(t, stdout, "SG.secret")
+	assert.Contains(t, stderr, "Exported")
+}
+
+func TestImportSubscribers(t *testing.T) {
+	var requests []recordedRequest
+	server := newServer(t, &requests)
+	dir := t.TempDir()
+	env := map[string]string{"NOVU_API_KEY": "env-key", "NOVU_BACKEND_URL": server.URL, "NOVU_CONFIG": filepath.Join(dir, "missing.json")}
+	file := filepath.Join(dir, "users.csv")
+	require.NoError(t, os.WriteFile(file, []byte("id,mail,plan\nsub-1,jane@example.com,pro\n,missing@example.com,\nsub-2,john@example.com,free\n"), 0o600))
+
+	code, stdout, stderr := runCLI(t, env, "subscribers", "import", "-columns", `{"id": "subscriberId", "mail": "email"}`, file)
+	require.Equal(t, 0, code, stderr)
+	assert.Equal(t, "record 2: subscriberId is required\n", stderr)
+	assert.Equal(t, "RECORDS  CREATED  UPDATED  FAILED\n3        1        1        1\n", stdout)
+	require.Len(t, requests, 1)
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"subscriberId": "sub-1", "email": "jane@example.com", "data": map[string]interface{}{"plan": "pro"}},
+		map[string]interface{}{"subscriberId": "sub-2", "email": "john@example.com", "data": map[string]interface{}{"plan": "free"}},
+	}, requests[0].Body["subscribers"])
+	assert.NoFileExists(t, file+".checkpoint")
+}
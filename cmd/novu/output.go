@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// printer writes results as indented JSON or as a table.
+type printer struct {
+	w      io.Writer
+	format string
+}
+
+// print writes v. Tables show the given columns of each object of v, or its
+// scalar fields when no columns are given.
+func (p *printer) print(v interface{}, columns ...string) error {
+	if p.format == "json" {
+		encoder := json.NewEncoder(p.w)
+		encoder.SetIndent("", "  ")
+		encoder.SetEscapeHTML(false)
+		return encoder.Encode(v)
+	}
+
+	bb, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var document interface{}
+	if err := json.Unmarshal(bb, &document); err != nil {
+		return err
+	}
+
+	var rows []map[string]interface{}
+	switch d := document.(type) {
+	case []interface{}:
+		for _, item := range d {
+			row, ok := item.(map[string]interface{})
+			if !ok {
+				row = map[string]interface{}{"value": item}
+			}
+			rows = append(rows, row)
+		}
+	case map[string]interface{}:
+		rows = append(rows, d)
+	case nil:
+	default:
+		rows = append(rows, map[string]interface{}{"value": d})
+	}
+	if len(rows) == 0 {
+		_, err := fmt.Fprintln(p.w, "No results.")
+		return err
+	}
+	if len(columns) == 0 {
+		columns = scalarKeys(rows[0])
+	}
+
+	tw := tabwriter.NewWriter(p.w, 0, 4, 2, ' ', 0)
+	header := make([]string, len(columns))
+	for i, c := range columns {
+		header[i] = strings.ToUpper(c)
+	}
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, row := range rows {
+		cells := make([]string, len(columns))
+		for i, c := range columns {
+			cells[i] = cell(lookupPath(row, c))
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+	return tw.Flush()
+}
+
+// lookupPath returns the value of a dotted path in row.
+func lookupPath(row map[string]interface{}, path string) interface{} {
+	var v interface{} = row
+	for _, key := range strings.Split(path, ".") {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		v = m[key]
+	}
+	return v
+}
+
+func cell(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "-"
+	case string:
+		if v == "" {
+			return "-"
+		}
+		return v
+	case float64, bool:
+		return fmt.Sprint(v)
+	}
+	bb, _ := json.Marshal(v)
+	return string(bb)
+}
+
+func scalarKeys(row map[string]interface{}) []string {
+	var keys []string
+	for k, v := range row {
+		switch v.(type) {
+		case map[string]interface{}, []interface{}:
+			continue
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
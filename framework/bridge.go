@@ -0,0 +1,358 @@
+package framework
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/novuhq/go-novu/lib"
+)
+
+const (
+	// FrameworkVersion is the version of the bridge protocol implemented.
+	FrameworkVersion = "2024-06-26"
+	// SDKVersion identifies this SDK to Novu.
+	SDKVersion = "go-novu"
+
+	SignatureHeader       = "Novu-Signature"
+	legacySignatureHeader = "X-Novu-Signature"
+
+	ActionHealthCheck = "health-check"
+	ActionDiscover    = "discover"
+	ActionExecute     = "execute"
+	ActionPreview     = "preview"
+
+	defaultTolerance    = 5 * time.Minute
+	defaultMaxBodyBytes = 1 << 20
+)
+
+type HandlerOptions struct {
+	// SkipAuthentication accepts unsigned requests, for local development
+	// with the Novu Studio only.
+	SkipAuthentication bool
+	// Tolerance is how far the timestamp of a signature may be from the
+	// current time. It defaults to 5 minutes.
+	Tolerance    time.Duration
+	MaxBodyBytes int64
+	// Now replaces time.Now, for tests.
+	Now func() time.Time
+}
+
+// Handler is the bridge endpoint called by Novu.
+type Handler struct {
+	secret    []byte
+	workflows []*Workflow
+	byID      map[string]*Workflow
+	opts      HandlerOptions
+}
+
+// NewHandler returns a bridge serving workflows. Requests must be signed with
+// secretKey, the secret key of the Novu environment.
+func NewHandler(secretKey string, opts *HandlerOptions, workflows ...*Workflow) (*Handler, error) {
+	h := &Handler{secret: []byte(secretKey), workflows: workflows, byID: map[string]*Workflow{}}
+	if opts != nil {
+		h.opts = *opts
+	}
+	if h.opts.Tolerance <= 0 {
+		h.opts.Tolerance = defaultTolerance
+	}
+	if h.opts.MaxBodyBytes <= 0 {
+		h.opts.MaxBodyBytes = defaultMaxBodyBytes
+	}
+	if h.opts.Now == nil {
+		h.opts.Now = time.Now
+	}
+	if secretKey == "" && !h.opts.SkipAuthentication {
+		return nil, errors.New("framework: a secret key is required")
+	}
+
+	for _, w := range workflows {
+		if w.ID == "" {
+			return nil, errors.New("framework: workflow without id")
+		}
+		if _, ok := h.byID[w.ID]; ok {
+			return nil, errors.Errorf("framework: duplicate workflow %q", w.ID)
+		}
+		steps := map[string]bool{}
+		for _, s := range w.Steps {
+			if s.ID == "" || steps[s.ID] {
+				return nil, errors.Errorf("framework: workflow %q has a step without id or a duplicate step %q", w.ID, s.ID)
+			}
+			if s.Resolve == nil {
+				return nil, errors.Errorf("framework: step %q of workflow %q has no resolver", s.ID, w.ID)
+			}
+			steps[s.ID] = true
+		}
+		h.byID[w.ID] = w
+	}
+	return h, nil
+}
+
+// Signature returns the value of the Novu-Signature header for body signed
+// at t with secretKey.
+func Signature(secretKey string, t time.Time, body []byte) string {
+	ts := strconv.FormatInt(t.UnixMilli(), 10)
+	return "t=" + ts + ",v1=" + sign(secretKey, ts, body)
+}
+
+func sign(secretKey, ts string, body []byte) string {
+	if len(body) == 0 {
+		body = []byte("{}")
+	}
+	mac := hmac.New(sha256.New, []byte(secretKey))
+	mac.Write([]byte(ts + "."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// bridgeError is the error body of the bridge.
+type bridgeError struct {
+	status  int
+	Code    string      `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+func (e *bridgeError) Error() string {
+	return e.Message
+}
+
+func newBridgeError(status int, code, format string, args ...interface{}) *bridgeError {
+	return &bridgeError{status: status, Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, "+SignatureHeader+", "+legacySignatureHeader)
+	w.Header().Set("Novu-Framework-SDK", SDKVersion)
+	w.Header().Set("Novu-Framework-Version", FrameworkVersion)
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	resp, err := h.serve(r)
+	if err != nil {
+		var be *bridgeError
+		if !errors.As(err, &be) {
+			be = newBridgeError(http.StatusInternalServerError, "BridgeError", "%v", err)
+		}
+		writeJSON(w, be.status, be)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func (h *Handler) serve(r *http.Request) (interface{}, error) {
+	body, err := io.ReadAll(http.MaxBytesReader(nil, r.Body, h.opts.MaxBodyBytes))
+	if err != nil {
+		return nil, newBridgeError(http.StatusBadRequest, "InvalidRequestError", "read body: %v", err)
+	}
+	if err := h.authenticate(r, body); err != nil {
+		return nil, err
+	}
+
+	action := r.URL.Query().Get("action")
+	switch {
+	case r.Method == http.MethodGet && action == ActionHealthCheck:
+		return h.healthCheck(), nil
+	case r.Method == http.MethodGet && action == ActionDiscover:
+		return h.discover(), nil
+	case r.Method == http.MethodPost && (action == ActionExecute || action == ActionPreview):
+		return h.execute(r, body, action == ActionPreview)
+	}
+	return nil, newBridgeError(http.StatusBadRequest, "MethodNotAllowedError", "action %q is not supported with %s", action, r.Method)
+}
+
+func (h *Handler) authenticate(r *http.Request, body []byte) error {
+	if h.opts.SkipAuthentication {
+		return nil
+	}
+	header := r.Header.Get(SignatureHeader)
+	if header == "" {
+		header = r.Header.Get(legacySignatureHeader)
+	}
+	if header == "" {
+		return newBridgeError(http.StatusUnauthorized, "SignatureNotFoundError", "signature not found, send it in the %s header", SignatureHeader)
+	}
+
+	var ts, signature string
+	for _, part := range strings.Split(header, ",") {
+		k, v, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch k {
+		case "t":
+			ts = v
+		case "v1":
+			signature = v
+		}
+	}
+	ms, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil || signature == "" {
+		return newBridgeError(http.StatusBadRequest, "SignatureInvalidError", "signature is invalid, expected t=<timestamp>,v1=<signature>")
+	}
+	if math.Abs(float64(h.opts.Now().UnixMilli()-ms)) > float64(h.opts.Tolerance.Milliseconds()) {
+		return newBridgeError(http.StatusUnauthorized, "SignatureExpiredError", "signature expired")
+	}
+	if !hmac.Equal([]byte(signature), []byte(sign(string(h.secret), ts, body))) {
+		return newBridgeError(http.StatusUnauthorized, "SignatureMismatchError", "signature does not match the expected signature, check the secret key")
+	}
+	return nil
+}
+
+type healthCheckResponse struct {
+	Status           string `json:"status"`
+	SDKVersion       string `json:"sdkVersion"`
+	FrameworkVersion string `json:"frameworkVersion"`
+	Discovered       struct {
+		Workflows int `json:"workflows"`
+		Steps     int `json:"steps"`
+	} `json:"discovered"`
+}
+
+func (h *Handler) healthCheck() healthCheckResponse {
+	resp := healthCheckResponse{Status: "ok", SDKVersion: SDKVersion, FrameworkVersion: FrameworkVersion}
+	resp.Discovered.Workflows = len(h.workflows)
+	for _, w := range h.workflows {
+		resp.Discovered.Steps += len(w.Steps)
+	}
+	return resp
+}
+
+type discoverResponse struct {
+	Workflows []discoverWorkflow `json:"workflows"`
+}
+
+func (h *Handler) discover() discoverResponse {
+	resp := discoverResponse{Workflows: []discoverWorkflow{}}
+	for _, w := range h.workflows {
+		resp.Workflows = append(resp.Workflows, w.discover())
+	}
+	return resp
+}
+
+// ExecuteRequest is the body of the execute and preview actions.
+type ExecuteRequest struct {
+	WorkflowID string                 `json:"workflowId"`
+	StepID     string                 `json:"stepId"`
+	Action     string                 `json:"action,omitempty"`
+	Subscriber lib.SubscriberPayload  `json:"subscriber"`
+	Payload    map[string]interface{} `json:"payload"`
+	Controls   map[string]interface{} `json:"controls"`
+	State      []ExecutedStep         `json:"state"`
+}
+
+// ExecutedStep is the state of a step executed before the current one.
+type ExecutedStep struct {
+	StepID  string                 `json:"stepId"`
+	Outputs map[string]interface{} `json:"outputs"`
+	State   struct {
+		Status string `json:"status"`
+		Error  string `json:"error,omitempty"`
+	} `json:"state"`
+}
+
+// ExecuteResponse is the result of the execute and preview actions.
+type ExecuteResponse struct {
+	Outputs   map[string]interface{} `json:"outputs"`
+	Providers map[string]interface{} `json:"providers"`
+	Options   struct {
+		Skip bool `json:"skip"`
+	} `json:"options"`
+	Metadata struct {
+		Status   string `json:"status"`
+		Error    bool   `json:"error"`
+		Duration int64  `json:"duration"`
+	} `json:"metadata"`
+}
+
+func (h *Handler) execute(r *http.Request, body []byte, preview bool) (*ExecuteResponse, error) {
+	var req ExecuteRequest
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, &req); err != nil {
+			return nil, newBridgeError(http.StatusBadRequest, "InvalidRequestError", "invalid body: %v", err)
+		}
+	}
+	if id := r.URL.Query().Get("workflowId"); id != "" {
+		req.WorkflowID = id
+	}
+	if id := r.URL.Query().Get("stepId"); id != "" {
+		req.StepID = id
+	}
+
+	workflow, ok := h.byID[req.WorkflowID]
+	if !ok {
+		return nil, newBridgeError(http.StatusNotFound, "WorkflowNotFoundError", "workflow %q not found", req.WorkflowID)
+	}
+	step := workflow.step(req.StepID)
+	if step == nil {
+		return nil, newBridgeError(http.StatusNotFound, "StepNotFoundError", "step %q not found in workflow %q", req.StepID, req.WorkflowID)
+	}
+
+	in := &StepInput{
+		WorkflowID: workflow.ID,
+		StepID:     step.ID,
+		Payload:    req.Payload,
+		Subscriber: req.Subscriber,
+		Controls:   req.Controls,
+		Results:    map[string]map[string]interface{}{},
+		Preview:    preview,
+	}
+	if in.Payload == nil {
+		in.Payload = map[string]interface{}{}
+	}
+	if in.Controls == nil {
+		in.Controls = map[string]interface{}{}
+	}
+	for _, s := range req.State {
+		in.Results[s.StepID] = s.Outputs
+	}
+
+	start := h.opts.Now()
+	resp := &ExecuteResponse{Outputs: map[string]interface{}{}, Providers: map[string]interface{}{}}
+	if step.Skip != nil && !preview {
+		skip, err := step.Skip(r.Context(), in)
+		if err != nil {
+			return nil, stepError(workflow, step, err)
+		}
+		resp.Options.Skip = skip
+	}
+	if !resp.Options.Skip {
+		outputs, err := step.Resolve(r.Context(), in)
+		if err != nil {
+			return nil, stepError(workflow, step, err)
+		}
+		if outputs != nil {
+			resp.Outputs = outputs
+		}
+		if step.Providers != nil {
+			resp.Providers = step.Providers
+		}
+	}
+	resp.Metadata.Status = "success"
+	resp.Metadata.Duration = h.opts.Now().Sub(start).Milliseconds()
+	return resp, nil
+}
+
+func stepError(w *Workflow, s *Step, err error) *bridgeError {
+	be := newBridgeError(http.StatusInternalServerError, "StepExecutionFailedError", "step %q of workflow %q failed: %v", s.ID, w.ID, err)
+	be.Data = map[string]string{"workflowId": w.ID, "stepId": s.ID}
+	return be
+}
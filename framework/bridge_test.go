@@ -0,0 +1,230 @@
+package framework_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/novuhq/go-novu/framework"
+	"github.com/novuhq/go-novu/lib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const secretKey = "novu-secret"
+
+var now = time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC)
+
+func commentWorkflow() *framework.Workflow {
+	return &framework.Workflow{
+		ID:   "comment-on-post",
+		Name: "Comment on post",
+		Tags: []string{"social"},
+		PayloadSchema: framework.Schema{
+			"type":       "object",
+			"properties": map[string]interface{}{"author": map[string]interface{}{"type": "string"}},
+			"required":   []string{"author"},
+		},
+		Steps: []*framework.Step{
+			{
+				ID:   "inbox",
+				Type: lib.StepTypeInApp,
+				Resolve: func(ctx context.Context, in *framework.StepInput) (map[string]interface{}, error) {
+					return map[string]interface{}{
+						"body": in.Payload["author"].(string) + " commented on your post, " + in.Subscriber.FirstName,
+					}, nil
+				},
+			},
+			{
+				ID:   "email",
+				Type: lib.StepTypeEmail,
+				Resolve: func(ctx context.Context, in *framework.StepInput) (map[string]interface{}, error) {
+					if in.Payload["author"] == "broken" {
+						return nil, errors.New("template error")
+					}
+					subject := "New comment"
+					if s, ok := in.Controls["subject"].(string); ok {
+						subject = s
+					}
+					return map[string]interface{}{"subject": subject, "body": "seen: " + in.Results["inbox"]["body"].(string)}, nil
+				},
+				Skip: func(ctx context.Context, in *framework.StepInput) (bool, error) {
+					return in.Payload["muted"] == true, nil
+				},
+				Providers: map[string]interface{}{"sendgrid": map[string]interface{}{"ipPoolName": "transactional"}},
+			},
+		},
+	}
+}
+
+func newHandler(t *testing.T) *framework.Handler {
+	h, err := framework.NewHandler(secretKey, &framework.HandlerOptions{Now: func() time.Time { return now }}, commentWorkflow())
+	require.NoError(t, err)
+	return h
+}
+
+func serve(h http.Handler, method, target, body string, signedAt time.Time) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if !signedAt.IsZero() {
+		req.Header.Set(framework.SignatureHeader, framework.Signature(secretKey, signedAt, []byte(body)))
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func decode(t *testing.T, rec *httptest.ResponseRecorder) map[string]interface{} {
+	var v map[string]interface{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &v), rec.Body.String())
+	return v
+}
+
+func TestHandlerHealthCheckAndDiscover(t *testing.T) {
+	h := newHandler(t)
+
+	rec := serve(h, http.MethodGet, "/api/novu?action=health-check", "", now)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	health := decode(t, rec)
+	assert.Equal(t, "ok", health["status"])
+	assert.Equal(t, map[string]interface{}{"workflows": 1.0, "steps": 2.0}, health["discovered"])
+	assert.Equal(t, framework.FrameworkVersion, rec.Header().Get("Novu-Framework-Version"))
+
+	rec = serve(h, http.MethodGet, "/api/novu?action=discover", "", now)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	discovered := decode(t, rec)["workflows"].([]interface{})
+	require.Len(t, discovered, 1)
+	workflow := discovered[0].(map[string]interface{})
+	assert.Equal(t, "comment-on-post", workflow["workflowId"])
+	assert.Equal(t, []interface{}{"author"}, workflow["payload"].(map[string]interface{})["schema"].(map[string]interface{})["required"])
+
+	steps := workflow["steps"].([]interface{})
+	require.Len(t, steps, 2)
+	email := steps[1].(map[string]interface{})
+	assert.Equal(t, "email", email["stepId"])
+	assert.Equal(t, "email", email["type"])
+	assert.Equal(t, []interface{}{map[string]interface{}{
+		"type":    "sendgrid",
+		"outputs": map[string]interface{}{"ipPoolName": "transactional"},
+	}}, email["providers"])
+}
+
+func TestHandlerExecute(t *testing.T) {
+	h := newHandler(t)
+	body := `{
+		"workflowId": "comment-on-post",
+		"stepId": "email",
+		"subscriber": {"subscriberId": "sub-1", "firstName": "John"},
+		"payload": {"author": "Jane"},
+		"controls": {"subject": "Jane commented"},
+		"state": [{"stepId": "inbox", "outputs": {"body": "Jane commented on your post, John"}, "state": {"status": "completed"}}]
+	}`
+
+	t.Run("Step is resolved", func(t *testing.T) {
+		rec := serve(h, http.MethodPost, "/api/novu?action=execute", body, now.Add(-time.Minute))
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		resp := decode(t, rec)
+		assert.Equal(t, map[string]interface{}{
+			"subject": "Jane commented",
+			"body":    "seen: Jane commented on your post, John",
+		}, resp["outputs"])
+		assert.Equal(t, map[string]interface{}{"sendgrid": map[string]interface{}{"ipPoolName": "transactional"}}, resp["providers"])
+		assert.Equal(t, false, resp["options"].(map[string]interface{})["skip"])
+		assert.Equal(t, "success", resp["metadata"].(map[string]interface{})["status"])
+	})
+
+	t.Run("Ids in the query take precedence", func(t *testing.T) {
+		body := `{"subscriber": {"subscriberId": "sub-1", "firstName": "John"}, "payload": {"author": "Jane"}}`
+		rec := serve(h, http.MethodPost, "/api/novu?action=preview&workflowId=comment-on-post&stepId=inbox", body, now)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.Equal(t, map[string]interface{}{"body": "Jane commented on your post, John"}, decode(t, rec)["outputs"])
+	})
+
+	t.Run("Skipped step is not resolved", func(t *testing.T) {
+		body := `{"workflowId": "comment-on-post", "stepId": "email", "payload": {"author": "broken", "muted": true}}`
+		rec := serve(h, http.MethodPost, "/api/novu?action=execute", body, now)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		resp := decode(t, rec)
+		assert.Equal(t, true, resp["options"].(map[string]interface{})["skip"])
+		assert.Empty(t, resp["outputs"])
+	})
+
+	t.Run("Failing step", func(t *testing.T) {
+		body := `{"workflowId": "comment-on-post", "stepId": "email", "payload": {"author": "broken"}}`
+		rec := serve(h, http.MethodPost, "/api/novu?action=execute", body, now)
+		require.Equal(t, http.StatusInternalServerError, rec.Code)
+		assert.Equal(t, "StepExecutionFailedError", decode(t, rec)["code"])
+	})
+
+	t.Run("Unknown workflow and step", func(t *testing.T) {
+		rec := serve(h, http.MethodPost, "/api/novu?action=execute&workflowId=unknown&stepId=email", "", now)
+		require.Equal(t, http.StatusNotFound, rec.Code)
+		assert.Equal(t, "WorkflowNotFoundError", decode(t, rec)["code"])
+
+		rec = serve(h, http.MethodPost, "/api/novu?action=execute&workflowId=comment-on-post&stepId=sms", "", now)
+		require.Equal(t, http.StatusNotFound, rec.Code)
+		assert.Equal(t, "StepNotFoundError", decode(t, rec)["code"])
+	})
+}
+
+func TestHandlerAuthentication(t *testing.T) {
+	h := newHandler(t)
+	body := `{"workflowId": "comment-on-post", "stepId": "inbox", "payload": {"author": "Jane"}}`
+
+	tests := []struct {
+		name   string
+		header string
+		status int
+		code   string
+	}{
+		{"Missing signature", "", http.StatusUnauthorized, "SignatureNotFoundError"},
+		{"Malformed signature", "v1=abc", http.StatusBadRequest, "SignatureInvalidError"},
+		{"Expired signature", framework.Signature(secretKey, now.Add(-time.Hour), []byte(body)), http.StatusUnauthorized, "SignatureExpiredError"},
+		{"Wrong secret", framework.Signature("other", now, []byte(body)), http.StatusUnauthorized, "SignatureMismatchError"},
+		{"Tampered body", framework.Signature(secretKey, now, []byte(`{}`)), http.StatusUnauthorized, "SignatureMismatchError"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/novu?action=execute", strings.NewReader(body))
+			if tt.header != "" {
+				req.Header.Set(framework.SignatureHeader, tt.header)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			require.Equal(t, tt.status, rec.Code)
+			assert.Equal(t, tt.code, decode(t, rec)["code"])
+		})
+	}
+
+	t.Run("Preflight is not authenticated", func(t *testing.T) {
+		rec := serve(h, http.MethodOptions, "/api/novu", "", time.Time{})
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		assert.Equal(t, "*", rec.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("Authentication can be skipped", func(t *testing.T) {
+		h, err := framework.NewHandler("", &framework.HandlerOptions{SkipAuthentication: true}, commentWorkflow())
+		require.NoError(t, err)
+		rec := serve(h, http.MethodGet, "/api/novu?action=health-check", "", time.Time{})
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+}
+
+func TestNewHandlerValidatesWorkflows(t *testing.T) {
+	_, err := framework.NewHandler(secretKey, nil, commentWorkflow(), commentWorkflow())
+	assert.ErrorContains(t, err, "duplicate workflow")
+
+	w := commentWorkflow()
+	w.Steps[1].ID = "inbox"
+	_, err = framework.NewHandler(secretKey, nil, w)
+	assert.ErrorContains(t, err, "duplicate step")
+
+	_, err = framework.NewHandler("", nil, commentWorkflow())
+	assert.ErrorContains(t, err, "secret key")
+}
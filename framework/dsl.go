@@ -0,0 +1,414 @@
+package framework
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/novuhq/go-novu/internal/convert"
+	"github.com/novuhq/go-novu/lib"
+)
+
+// Context is what the steps of a workflow with payload P are resolved with.
+type Context[P any] struct {
+	WorkflowID string
+	StepID     string
+	Payload    P
+	Subscriber lib.SubscriberPayload
+	Controls   map[string]interface{}
+	// Results holds the outputs of the steps executed before, by step id.
+	Results map[string]map[string]interface{}
+	Preview bool
+}
+
+// DigestEvent is an event collected by a digest step.
+type DigestEvent[P any] struct {
+	ID      string    `json:"id"`
+	Time    time.Time `json:"time"`
+	Payload P         `json:"payload"`
+}
+
+// DigestEvents returns the events collected by the digest step stepID.
+func (c *Context[P]) DigestEvents(stepID string) ([]DigestEvent[P], error) {
+	var events []DigestEvent[P]
+	result, ok := c.Results[stepID]
+	if !ok {
+		return events, nil
+	}
+	if err := convert.JSON(result["events"], &events); err != nil {
+		return nil, errors.Wrapf(err, "decode events of digest %q", stepID)
+	}
+	return events, nil
+}
+
+type EmailOutput struct {
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
+
+type SMSOutput struct {
+	Body string `json:"body"`
+}
+
+type ChatOutput struct {
+	Body string `json:"body"`
+}
+
+type PushOutput struct {
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
+
+type InAppOutput struct {
+	Subject         string                 `json:"subject,omitempty"`
+	Body            string                 `json:"body"`
+	Avatar          string                 `json:"avatar,omitempty"`
+	PrimaryAction   *InAppAction           `json:"primaryAction,omitempty"`
+	SecondaryAction *InAppAction           `json:"secondaryAction,omitempty"`
+	Redirect        *Redirect              `json:"redirect,omitempty"`
+	Data            map[string]interface{} `json:"data,omitempty"`
+}
+
+type InAppAction struct {
+	Label    string    `json:"label"`
+	Redirect *Redirect `json:"redirect,omitempty"`
+}
+
+type Redirect struct {
+	URL    string `json:"url"`
+	Target string `json:"target,omitempty"`
+}
+
+type TimeUnit string
+
+const (
+	Seconds TimeUnit = "seconds"
+	Minutes TimeUnit = "minutes"
+	Hours   TimeUnit = "hours"
+	Days    TimeUnit = "days"
+	Weeks   TimeUnit = "weeks"
+	Months  TimeUnit = "months"
+)
+
+type DelayOutput struct {
+	Amount int      `json:"amount"`
+	Unit   TimeUnit `json:"unit"`
+}
+
+// DigestOutput configures a digest step: it collects the events of Amount
+// Unit, or until the next Cron occurrence when Cron is set.
+type DigestOutput struct {
+	Amount    int      `json:"amount,omitempty"`
+	Unit      TimeUnit `json:"unit,omitempty"`
+	Cron      string   `json:"cron,omitempty"`
+	DigestKey string   `json:"digestKey,omitempty"`
+}
+
+type digestResult struct {
+	Events []DigestEvent[map[string]interface{}] `json:"events"`
+}
+
+// StepOption configures a step of a workflow built with Define.
+type StepOption func(*stepOptions)
+
+type stepOptions struct {
+	controls  Schema
+	providers map[string]interface{}
+	skip      interface{}
+}
+
+// WithControls sets the schema of the controls editable in the dashboard.
+func WithControls(schema Schema) StepOption {
+	return func(o *stepOptions) {
+		o.controls = schema
+	}
+}
+
+// WithProviders sets provider specific overrides, by provider id.
+func WithProviders(overrides map[string]interface{}) StepOption {
+	return func(o *stepOptions) {
+		o.providers = overrides
+	}
+}
+
+// SkipIf skips the step when fn returns true. P must be the payload type of
+// the workflow.
+func SkipIf[P any](fn func(ctx context.Context, c *Context[P]) (bool, error)) StepOption {
+	return func(o *stepOptions) {
+		o.skip = fn
+	}
+}
+
+// WorkflowBuilder defines a workflow whose trigger payload is a P.
+type WorkflowBuilder[P any] struct {
+	workflow *Workflow
+	err      error
+}
+
+// Define starts the definition of the workflow id. Its payload schema is
+// derived from P.
+func Define[P any](id string) *WorkflowBuilder[P] {
+	return &WorkflowBuilder[P]{workflow: &Workflow{ID: id, PayloadSchema: SchemaOf[P]()}}
+}
+
+func (b *WorkflowBuilder[P]) Name(name string) *WorkflowBuilder[P] {
+	b.workflow.Name = name
+	return b
+}
+
+func (b *WorkflowBuilder[P]) Description(description string) *WorkflowBuilder[P] {
+	b.workflow.Description = description
+	return b
+}
+
+func (b *WorkflowBuilder[P]) Tags(tags ...string) *WorkflowBuilder[P] {
+	b.workflow.Tags = append(b.workflow.Tags, tags...)
+	return b
+}
+
+func (b *WorkflowBuilder[P]) Preferences(preferences map[string]interface{}) *WorkflowBuilder[P] {
+	b.workflow.Preferences = preferences
+	return b
+}
+
+func (b *WorkflowBuilder[P]) Email(id string, fn func(ctx context.Context, c *Context[P]) (*EmailOutput, error), opts ...StepOption) *WorkflowBuilder[P] {
+	return addStep(b, id, lib.StepTypeEmail, fn, opts)
+}
+
+func (b *WorkflowBuilder[P]) SMS(id string, fn func(ctx context.Context, c *Context[P]) (*SMSOutput, error), opts ...StepOption) *WorkflowBuilder[P] {
+	return addStep(b, id, lib.StepTypeSms, fn, opts)
+}
+
+func (b *WorkflowBuilder[P]) InApp(id string, fn func(ctx context.Context, c *Context[P]) (*InAppOutput, error), opts ...StepOption) *WorkflowBuilder[P] {
+	return addStep(b, id, lib.StepTypeInApp, fn, opts)
+}
+
+func (b *WorkflowBuilder[P]) Chat(id string, fn func(ctx context.Context, c *Context[P]) (*ChatOutput, error), opts ...StepOption) *WorkflowBuilder[P] {
+	return addStep(b, id, lib.StepTypeChat, fn, opts)
+}
+
+func (b *WorkflowBuilder[P]) Push(id string, fn func(ctx context.Context, c *Context[P]) (*PushOutput, error), opts ...StepOption) *WorkflowBuilder[P] {
+	return addStep(b, id, lib.StepTypePush, fn, opts)
+}
+
+func (b *WorkflowBuilder[P]) Delay(id string, fn func(ctx context.Context, c *Context[P]) (*DelayOutput, error), opts ...StepOption) *WorkflowBuilder[P] {
+	return addStep(b, id, lib.StepTypeDelay, fn, opts)
+}
+
+func (b *WorkflowBuilder[P]) Digest(id string, fn func(ctx context.Context, c *Context[P]) (*DigestOutput, error), opts ...StepOption) *WorkflowBuilder[P] {
+	step := addStep(b, id, lib.StepTypeDigest, fn, opts)
+	if s := b.workflow.step(id); s != nil {
+		s.ResultSchema = SchemaOf[digestResult]()
+	}
+	return step
+}
+
+// Custom adds a step running fn, whose outputs are available to the next
+// steps in Context.Results.
+func (b *WorkflowBuilder[P]) Custom(id string, fn func(ctx context.Context, c *Context[P]) (map[string]interface{}, error), opts ...StepOption) *WorkflowBuilder[P] {
+	return addStep(b, id, lib.StepTypeCustom, func(ctx context.Context, c *Context[P]) (*map[string]interface{}, error) {
+		outputs, err := fn(ctx, c)
+		return &outputs, err
+	}, opts)
+}
+
+func addStep[P, O any](b *WorkflowBuilder[P], id string, stepType lib.StepType, fn func(ctx context.Context, c *Context[P]) (*O, error), opts []StepOption) *WorkflowBuilder[P] {
+	if b.err != nil {
+		return b
+	}
+	if id == "" || b.workflow.step(id) != nil {
+		b.err = errors.Errorf("framework: workflow %q has a step without id or a duplicate step %q", b.workflow.ID, id)
+		return b
+	}
+
+	var o stepOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	step := &Step{
+		ID:            id,
+		Type:          stepType,
+		ControlSchema: o.controls,
+		Providers:     o.providers,
+		Resolve: func(ctx context.Context, in *StepInput) (map[string]interface{}, error) {
+			c, err := newContext[P](in)
+			if err != nil {
+				return nil, err
+			}
+			output, err := fn(ctx, c)
+			if err != nil || output == nil {
+				return nil, err
+			}
+			var outputs map[string]interface{}
+			return outputs, convert.JSON(output, &outputs)
+		},
+	}
+	if stepType != lib.StepTypeCustom {
+		step.OutputSchema = SchemaOf[O]()
+	}
+	if o.skip != nil {
+		skip, ok := o.skip.(func(ctx context.Context, c *Context[P]) (bool, error))
+		if !ok {
+			b.err = errors.Errorf("framework: skip function of step %q does not take the payload of workflow %q", id, b.workflow.ID)
+			return b
+		}
+		step.Skip = func(ctx context.Context, in *StepInput) (bool, error) {
+			c, err := newContext[P](in)
+			if err != nil {
+				return false, err
+			}
+			return skip(ctx, c)
+		}
+	}
+	b.workflow.Steps = append(b.workflow.Steps, step)
+	return b
+}
+
+func newContext[P any](in *StepInput) (*Context[P], error) {
+	c := &Context[P]{
+		WorkflowID: in.WorkflowID,
+		StepID:     in.StepID,
+		Subscriber: in.Subscriber,
+		Controls:   in.Controls,
+		Results:    in.Results,
+		Preview:    in.Preview,
+	}
+	if err := convert.JSON(in.Payload, &c.Payload); err != nil {
+		return nil, errors.Wrap(err, "invalid payload")
+	}
+	return c, nil
+}
+
+// Build returns the workflow, or the first error met while defining it.
+func (b *WorkflowBuilder[P]) Build() (*TypedWorkflow[P], error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	if b.workflow.ID == "" {
+		return nil, errors.New("framework: workflow without id")
+	}
+	return &TypedWorkflow[P]{Workflow: b.workflow}, nil
+}
+
+// TypedWorkflow is a workflow whose trigger payload is a P. Its Workflow is
+// served by a Handler.
+type TypedWorkflow[P any] struct {
+	*Workflow
+}
+
+type TriggerOptions struct {
+	Overrides     interface{}
+	TransactionId string
+	Actor         interface{}
+	Tenant        *lib.TriggerTenant
+}
+
+// Trigger triggers the workflow for the recipients to with payload.
+func (w *TypedWorkflow[P]) Trigger(ctx context.Context, events lib.IEvent, to interface{}, payload P, opts *TriggerOptions) (lib.EventResponse, error) {
+	data := lib.ITriggerPayloadOptions{To: to, Payload: payload}
+	if opts != nil {
+		data.Overrides = opts.Overrides
+		data.TransactionId = opts.TransactionId
+		data.Actor = opts.Actor
+		data.Tenant = opts.Tenant
+	}
+	return events.Trigger(ctx, w.ID, data)
+}
+
+// WorkflowRequest returns the workflow in the format of the workflow API.
+// The content of the steps is resolved with sample, as Go functions cannot be
+// stored by Novu; custom steps, which only run through the bridge, are left
+// out.
+func (w *TypedWorkflow[P]) WorkflowRequest(ctx context.Context, sample P, subscriber lib.SubscriberPayload) (lib.CreateWorkflowRequest, error) {
+	req := lib.CreateWorkflowRequest{
+		Name:        w.Name,
+		Description: w.Description,
+		Tags:        w.Tags,
+		Steps:       []lib.WorkflowStep{},
+		Active:      true,
+	}
+	if req.Name == "" {
+		req.Name = w.ID
+	}
+
+	var payload map[string]interface{}
+	if err := convert.JSON(sample, &payload); err != nil {
+		return req, errors.Wrap(err, "invalid sample payload")
+	}
+	results := map[string]map[string]interface{}{}
+	for _, s := range w.Steps {
+		in := &StepInput{
+			WorkflowID: w.ID,
+			StepID:     s.ID,
+			Payload:    payload,
+			Subscriber: subscriber,
+			Controls:   map[string]interface{}{},
+			Results:    results,
+			Preview:    true,
+		}
+		outputs, err := s.Resolve(ctx, in)
+		if err != nil {
+			return req, errors.Wrapf(err, "resolve step %q", s.ID)
+		}
+		results[s.ID] = outputs
+		if s.Type == lib.StepTypeCustom {
+			continue
+		}
+		step, err := workflowStep(s, outputs)
+		if err != nil {
+			return req, errors.Wrapf(err, "step %q", s.ID)
+		}
+		req.Steps = append(req.Steps, step)
+	}
+	return req, nil
+}
+
+func workflowStep(s *Step, outputs map[string]interface{}) (lib.WorkflowStep, error) {
+	step := lib.WorkflowStep{
+		Name:     s.ID,
+		Active:   true,
+		Template: &lib.StepTemplate{Type: s.Type, Name: s.ID},
+	}
+	switch s.Type {
+	case lib.StepTypeEmail:
+		var o EmailOutput
+		if err := convert.JSON(outputs, &o); err != nil {
+			return step, err
+		}
+		step.Template.Subject = o.Subject
+		step.Template.Content = &lib.StepContent{Text: o.Body}
+		step.Template.ContentType = "customHtml"
+	case lib.StepTypePush:
+		var o PushOutput
+		if err := convert.JSON(outputs, &o); err != nil {
+			return step, err
+		}
+		step.Template.Title = o.Subject
+		step.Template.Content = &lib.StepContent{Text: o.Body}
+	case lib.StepTypeInApp:
+		var o InAppOutput
+		if err := convert.JSON(outputs, &o); err != nil {
+			return step, err
+		}
+		step.Template.Content = &lib.StepContent{Text: o.Body}
+		if o.Redirect != nil {
+			step.Template.Cta = map[string]interface{}{"type": "redirect", "data": map[string]interface{}{"url": o.Redirect.URL}}
+		}
+	case lib.StepTypeSms, lib.StepTypeChat:
+		var o SMSOutput
+		if err := convert.JSON(outputs, &o); err != nil {
+			return step, err
+		}
+		step.Template.Content = &lib.StepContent{Text: o.Body}
+	case lib.StepTypeDelay, lib.StepTypeDigest:
+		step.Metadata = map[string]interface{}{"type": "regular"}
+		for k, v := range outputs {
+			step.Metadata[k] = v
+		}
+		if cron, ok := outputs["cron"]; ok && cron != "" {
+			step.Metadata["type"] = "timed"
+		}
+	}
+	return step, nil
+}
@@ -0,0 +1,196 @@
+package framework_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/novuhq/go-novu/framework"
+	"github.com/novuhq/go-novu/lib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type orderPayload struct {
+	OrderID string      `json:"orderId" description:"Id of the order"`
+	Items   []orderItem `json:"items"`
+	Total   float64     `json:"total"`
+	Express bool        `json:"express,omitempty"`
+	Coupon  *string     `json:"coupon"`
+}
+
+type orderItem struct {
+	Name     string `json:"name"`
+	Quantity int    `json:"quantity"`
+}
+
+func orderWorkflow(t *testing.T) *framework.TypedWorkflow[orderPayload] {
+	w, err := framework.Define[orderPayload]("order-shipped").
+		Name("Order shipped").
+		Tags("orders").
+		InApp("inbox", func(ctx context.Context, c *framework.Context[orderPayload]) (*framework.InAppOutput, error) {
+			return &framework.InAppOutput{
+				Body:     fmt.Sprintf("Order %s with %d items has shipped", c.Payload.OrderID, len(c.Payload.Items)),
+				Redirect: &framework.Redirect{URL: "/orders/" + c.Payload.OrderID},
+			}, nil
+		}).
+		Digest("batch", func(ctx context.Context, c *framework.Context[orderPayload]) (*framework.DigestOutput, error) {
+			return &framework.DigestOutput{Amount: 1, Unit: framework.Hours}, nil
+		}).
+		Email("email", func(ctx context.Context, c *framework.Context[orderPayload]) (*framework.EmailOutput, error) {
+			events, err := c.DigestEvents("batch")
+			if err != nil {
+				return nil, err
+			}
+			orders := []string{c.Payload.OrderID}
+			for _, e := range events {
+				orders = append(orders, e.Payload.OrderID)
+			}
+			return &framework.EmailOutput{
+				Subject: fmt.Sprintf("Hi %s, %d orders shipped", c.Subscriber.FirstName, len(orders)),
+				Body:    fmt.Sprint(orders),
+			}, nil
+		}, framework.WithControls(framework.Schema{"type": "object"}),
+			framework.SkipIf(func(ctx context.Context, c *framework.Context[orderPayload]) (bool, error) {
+				return c.Payload.Express, nil
+			})).
+		Custom("audit", func(ctx context.Context, c *framework.Context[orderPayload]) (map[string]interface{}, error) {
+			return map[string]interface{}{"total": c.Payload.Total}, nil
+		}).
+		Build()
+	require.NoError(t, err)
+	return w
+}
+
+func TestSchemaOf(t *testing.T) {
+	type event struct {
+		At   time.Time              `json:"at"`
+		Data map[string]interface{} `json:"data,omitempty"`
+	}
+
+	schema := framework.SchemaOf[orderPayload]()
+	assert.Equal(t, []string{"orderId", "items", "total"}, schema["required"])
+
+	properties := schema["properties"].(map[string]interface{})
+	assert.Equal(t, framework.Schema{"type": "string", "description": "Id of the order"}, properties["orderId"])
+	assert.Equal(t, framework.Schema{"type": "number"}, properties["total"])
+	assert.Equal(t, framework.Schema{"type": "string"}, properties["coupon"])
+	items := properties["items"].(framework.Schema)
+	assert.Equal(t, "array", items["type"])
+	assert.Equal(t, []string{"name", "quantity"}, items["items"].(framework.Schema)["required"])
+
+	eventProperties := framework.SchemaOf[event]()["properties"].(map[string]interface{})
+	assert.Equal(t, framework.Schema{"type": "string", "format": "date-time"}, eventProperties["at"])
+	assert.Equal(t, framework.Schema{"type": "object", "additionalProperties": framework.Schema{}}, eventProperties["data"])
+}
+
+func TestDefineServedByHandler(t *testing.T) {
+	w := orderWorkflow(t)
+	h, err := framework.NewHandler(secretKey, &framework.HandlerOptions{Now: func() time.Time { return now }}, w.Workflow)
+	require.NoError(t, err)
+
+	rec := serve(h, http.MethodGet, "/api/novu?action=discover", "", now)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	workflow := decode(t, rec)["workflows"].([]interface{})[0].(map[string]interface{})
+	required := workflow["payload"].(map[string]interface{})["schema"].(map[string]interface{})["required"]
+	assert.Equal(t, []interface{}{"orderId", "items", "total"}, required)
+
+	var types []interface{}
+	for _, s := range workflow["steps"].([]interface{}) {
+		types = append(types, s.(map[string]interface{})["type"])
+	}
+	assert.Equal(t, []interface{}{"in_app", "digest", "email", "custom"}, types)
+
+	body := `{
+		"workflowId": "order-shipped",
+		"stepId": "email",
+		"subscriber": {"subscriberId": "sub-1", "firstName": "John"},
+		"payload": {"orderId": "o-1", "items": [], "total": 12.5},
+		"state": [{"stepId": "batch", "outputs": {}, "state": {"status": "completed"}, "events": []}]
+	}`
+	rec = serve(h, http.MethodPost, "/api/novu?action=execute", body, now)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, map[string]interface{}{"subject": "Hi John, 1 orders shipped", "body": "[o-1]"}, decode(t, rec)["outputs"])
+
+	body = `{"workflowId": "order-shipped", "stepId": "email", "payload": {"orderId": "o-1", "express": true}}`
+	rec = serve(h, http.MethodPost, "/api/novu?action=execute", body, now)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, true, decode(t, rec)["options"].(map[string]interface{})["skip"])
+
+	body = `{"workflowId": "order-shipped", "stepId": "inbox", "payload": {"orderId": 42}}`
+	rec = serve(h, http.MethodPost, "/api/novu?action=execute", body, now)
+	require.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Contains(t, decode(t, rec)["message"], "invalid payload")
+}
+
+func TestDigestEvents(t *testing.T) {
+	c := &framework.Context[orderPayload]{Results: map[string]map[string]interface{}{
+		"batch": {"events": []interface{}{
+			map[string]interface{}{"id": "e1", "time": "2024-07-01T10:00:00Z", "payload": map[string]interface{}{"orderId": "o-2"}},
+		}},
+	}}
+	events, err := c.DigestEvents("batch")
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, "o-2", events[0].Payload.OrderID)
+	assert.Equal(t, time.Date(2024, 7, 1, 10, 0, 0, 0, time.UTC), events[0].Time)
+}
+
+func TestDefineErrors(t *testing.T) {
+	noop := func(ctx context.Context, c *framework.Context[orderPayload]) (*framework.SMSOutput, error) {
+		return &framework.SMSOutput{}, nil
+	}
+	_, err := framework.Define[orderPayload]("w").SMS("sms", noop).SMS("sms", noop).Build()
+	assert.ErrorContains(t, err, "duplicate step")
+
+	skip := framework.SkipIf(func(ctx context.Context, c *framework.Context[string]) (bool, error) { return true, nil })
+	_, err = framework.Define[orderPayload]("w").SMS("sms", noop, skip).Build()
+	assert.ErrorContains(t, err, "does not take the payload")
+}
+
+func TestWorkflowRequest(t *testing.T) {
+	w := orderWorkflow(t)
+	sample := orderPayload{OrderID: "o-1", Items: []orderItem{{Name: "Book", Quantity: 1}}}
+	req, err := w.WorkflowRequest(context.Background(), sample, lib.SubscriberPayload{FirstName: "Jane"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "Order shipped", req.Name)
+	require.Len(t, req.Steps, 3)
+	assert.Equal(t, &lib.StepTemplate{
+		Type:    lib.StepTypeInApp,
+		Name:    "inbox",
+		Content: &lib.StepContent{Text: "Order o-1 with 1 items has shipped"},
+		Cta:     map[string]interface{}{"type": "redirect", "data": map[string]interface{}{"url": "/orders/o-1"}},
+	}, req.Steps[0].Template)
+	assert.Equal(t, map[string]interface{}{"type": "regular", "amount": 1.0, "unit": "hours"}, req.Steps[1].Metadata)
+	assert.Equal(t, "Hi Jane, 1 orders shipped", req.Steps[2].Template.Subject)
+
+	bb, err := json.Marshal(w)
+	require.NoError(t, err)
+	assert.Contains(t, string(bb), `"workflowId":"order-shipped"`)
+}
+
+type recordingEvents struct {
+	lib.IEvent
+	eventId string
+	data    lib.ITriggerPayloadOptions
+}
+
+func (e *recordingEvents) Trigger(ctx context.Context, eventId string, data lib.ITriggerPayloadOptions) (lib.EventResponse, error) {
+	e.eventId, e.data = eventId, data
+	return lib.EventResponse{}, nil
+}
+
+func TestTypedTrigger(t *testing.T) {
+	w := orderWorkflow(t)
+	events := &recordingEvents{}
+	payload := orderPayload{OrderID: "o-1"}
+	_, err := w.Trigger(context.Background(), events, "sub-1", payload, &framework.TriggerOptions{TransactionId: "tx-1"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "order-shipped", events.eventId)
+	assert.Equal(t, lib.ITriggerPayloadOptions{To: "sub-1", Payload: payload, TransactionId: "tx-1"}, events.data)
+}
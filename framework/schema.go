@@ -0,0 +1,96 @@
+package framework
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// SchemaOf returns the JSON schema of T, following the json tags of its
+// fields. Fields that are neither pointers nor tagged omitempty are required.
+func SchemaOf[T any]() Schema {
+	return schemaFor(reflect.TypeOf((*T)(nil)).Elem(), map[reflect.Type]bool{})
+}
+
+func schemaFor(t reflect.Type, visiting map[reflect.Type]bool) Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return Schema{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return Schema{"type": "boolean"}
+	case reflect.String:
+		return Schema{"type": "string"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return Schema{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return Schema{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return Schema{"type": "string", "contentEncoding": "base64"}
+		}
+		return Schema{"type": "array", "items": schemaFor(t.Elem(), visiting)}
+	case reflect.Map:
+		return Schema{"type": "object", "additionalProperties": schemaFor(t.Elem(), visiting)}
+	case reflect.Struct:
+		if visiting[t] {
+			// Recursive types are left open rather than expanded forever.
+			return Schema{}
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+
+		properties := map[string]interface{}{}
+		required := []string{}
+		addFields(t, properties, &required, visiting)
+		s := Schema{"type": "object", "properties": properties, "additionalProperties": false}
+		if len(required) > 0 {
+			s["required"] = required
+		}
+		return s
+	}
+	return Schema{}
+}
+
+func addFields(t reflect.Type, properties map[string]interface{}, required *[]string, visiting map[reflect.Type]bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				addFields(ft, properties, required, visiting)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		s := schemaFor(f.Type, visiting)
+		if description := f.Tag.Get("description"); description != "" {
+			s["description"] = description
+		}
+		properties[name] = s
+		if f.Type.Kind() != reflect.Ptr && !strings.Contains(options, "omitempty") {
+			*required = append(*required, name)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+// Package framework serves workflows defined in Go to Novu through the bridge
+// endpoint of the Novu Framework.
+//
+// Novu calls the bridge to discover the workflows of the application, to
+// preview their steps in the dashboard and to execute each step when a
+// workflow is triggered. The Handler implements this protocol for the
+// workflows it is given.
+package framework
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+
+	"github.com/novuhq/go-novu/lib"
+)
+
+// Schema is a JSON schema.
+type Schema map[string]interface{}
+
+// Workflow is a workflow defined in code.
+type Workflow struct {
+	ID          string
+	Name        string
+	Description string
+	Tags        []string
+	// PayloadSchema describes the payload of the trigger.
+	PayloadSchema Schema
+	Steps         []*Step
+	// Preferences holds the default subscriber preferences of the workflow.
+	Preferences map[string]interface{}
+}
+
+// Step is a step of a workflow.
+type Step struct {
+	ID   string
+	Type lib.StepType
+	// ControlSchema describes the controls editable in the dashboard.
+	ControlSchema Schema
+	// OutputSchema describes the outputs returned by Resolve.
+	OutputSchema Schema
+	// ResultSchema describes the result of action steps, such as the events
+	// of a digest.
+	ResultSchema Schema
+	// Resolve returns the outputs of the step: the content of channel steps
+	// or the settings of action steps.
+	Resolve func(ctx context.Context, in *StepInput) (map[string]interface{}, error)
+	// Skip, when set, tells whether the step must be skipped.
+	Skip func(ctx context.Context, in *StepInput) (bool, error)
+	// Providers holds provider specific overrides, by provider id.
+	Providers map[string]interface{}
+}
+
+// StepInput is what a step is resolved with.
+type StepInput struct {
+	WorkflowID string
+	StepID     string
+	Payload    map[string]interface{}
+	Subscriber lib.SubscriberPayload
+	Controls   map[string]interface{}
+	// Results holds the outputs of the steps executed before, by step id.
+	Results map[string]map[string]interface{}
+	// Preview is set when the dashboard previews the step.
+	Preview bool
+}
+
+// step returns the step with the given id.
+func (w *Workflow) step(id string) *Step {
+	for _, s := range w.Steps {
+		if s.ID == id {
+			return s
+		}
+	}
+	return nil
+}
+
+var emptySchema = Schema{"type": "object", "properties": map[string]interface{}{}, "additionalProperties": true}
+
+func schemaOrEmpty(s Schema) Schema {
+	if s == nil {
+		return emptySchema
+	}
+	return s
+}
+
+type schemaDefinition struct {
+	Schema        Schema `json:"schema"`
+	UnknownSchema Schema `json:"unknownSchema"`
+}
+
+func newSchemaDefinition(s Schema) schemaDefinition {
+	s = schemaOrEmpty(s)
+	return schemaDefinition{Schema: s, UnknownSchema: s}
+}
+
+// discoverWorkflow is the definition of a workflow sent by the discover action.
+type discoverWorkflow struct {
+	WorkflowID  string                 `json:"workflowId"`
+	Name        string                 `json:"name,omitempty"`
+	Description string                 `json:"description,omitempty"`
+	Tags        []string               `json:"tags"`
+	Code        string                 `json:"code"`
+	Payload     schemaDefinition       `json:"payload"`
+	Controls    schemaDefinition       `json:"controls"`
+	Preferences map[string]interface{} `json:"preferences"`
+	Steps       []discoverStep         `json:"steps"`
+}
+
+type discoverStep struct {
+	StepID    string                 `json:"stepId"`
+	Type      lib.StepType           `json:"type"`
+	Code      string                 `json:"code"`
+	Controls  schemaDefinition       `json:"controls"`
+	Outputs   schemaDefinition       `json:"outputs"`
+	Results   schemaDefinition       `json:"results"`
+	Options   map[string]interface{} `json:"options"`
+	Providers []interface{}          `json:"providers"`
+}
+
+func (w *Workflow) discover() discoverWorkflow {
+	d := discoverWorkflow{
+		WorkflowID:  w.ID,
+		Name:        w.Name,
+		Description: w.Description,
+		Tags:        w.Tags,
+		Payload:     newSchemaDefinition(w.PayloadSchema),
+		Controls:    newSchemaDefinition(nil),
+		Preferences: w.Preferences,
+		Steps:       []discoverStep{},
+	}
+	if d.Tags == nil {
+		d.Tags = []string{}
+	}
+	if d.Preferences == nil {
+		d.Preferences = map[string]interface{}{}
+	}
+	for _, s := range w.Steps {
+		step := discoverStep{
+			StepID:    s.ID,
+			Type:      s.Type,
+			Controls:  newSchemaDefinition(s.ControlSchema),
+			Outputs:   newSchemaDefinition(s.OutputSchema),
+			Results:   newSchemaDefinition(s.ResultSchema),
+			Options:   map[string]interface{}{},
+			Providers: []interface{}{},
+		}
+		ids := make([]string, 0, len(s.Providers))
+		for id := range s.Providers {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		for _, id := range ids {
+			step.Providers = append(step.Providers, map[string]interface{}{"type": id, "outputs": s.Providers[id]})
+		}
+		d.Steps = append(d.Steps, step)
+	}
+	return d
+}
+
+// MarshalJSON encodes the workflow in the format of the discover action.
+func (w *Workflow) MarshalJSON() ([]byte, error) {
+	return json.Marshal(w.discover())
+}
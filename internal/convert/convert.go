@@ -0,0 +1,13 @@
+// Package convert converts between API payload types.
+package convert
+
+import "encoding/json"
+
+// JSON copies v into out through its JSON representation.
+func JSON(v, out interface{}) error {
+	bb, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(bb, out)
+}
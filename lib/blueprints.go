@@ -1,8 +1,12 @@
 package lib
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"net/http"
+
+	"github.com/pkg/errors"
 )
 
 type BlueprintService service
@@ -40,3 +44,104 @@ func (b *BlueprintService) GetByTemplateID(ctx context.Context, templateID strin
 
 	return resp, nil
 }
+
+// CreateWorkflow creates a workflow in the current environment from the
+// blueprint with the given template id.
+func (b *BlueprintService) CreateWorkflow(ctx context.Context, templateID string, opts *BlueprintWorkflowOptions) (*Workflow, error) {
+	if opts == nil {
+		opts = &BlueprintWorkflowOptions{}
+	}
+	blueprint, err := b.GetByTemplateID(ctx, templateID)
+	if err != nil {
+		return nil, err
+	}
+
+	request, err := newBlueprintWorkflowRequest(blueprint, opts)
+	if err != nil {
+		return nil, err
+	}
+	if request.NotificationGroupId == "" {
+		groupName := "General"
+		if blueprint.NotificationGroup != nil && blueprint.NotificationGroup.Name != "" {
+			groupName = blueprint.NotificationGroup.Name
+		}
+		if request.NotificationGroupId, err = (*WorkflowService)(b).NotificationGroupId(ctx, groupName); err != nil {
+			return nil, err
+		}
+	}
+
+	var resp WorkflowResponse
+	URL := b.client.config.BackendURL.JoinPath("workflows")
+	jsonBody, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, URL.String(), bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = b.client.sendRequest(req, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return &resp.Data, nil
+}
+
+// newBlueprintWorkflowRequest copies the blueprint without the ids that only
+// exist in the environment of the blueprint.
+func newBlueprintWorkflowRequest(blueprint BlueprintByTemplateIdResponse, opts *BlueprintWorkflowOptions) (*CreateWorkflowRequest, error) {
+	request := &CreateWorkflowRequest{
+		Name:                blueprint.Name,
+		NotificationGroupId: opts.NotificationGroupId,
+		Description:         blueprint.Description,
+		Tags:                blueprint.Tags,
+		Critical:            blueprint.Critical,
+		PreferenceSettings:  blueprint.PreferenceSettings,
+		BlueprintId:         blueprint.Id,
+		Active:              opts.Active,
+		Draft:               !opts.Active,
+	}
+	if opts.Name != "" {
+		request.Name = opts.Name
+	}
+
+	used := map[string]bool{}
+	for _, step := range blueprint.Steps {
+		step.Id = ""
+		step.TemplateId = ""
+		if step.Template != nil {
+			template := *step.Template
+			template.Id = ""
+			template.LayoutId = ""
+			template.FeedId = ""
+			step.Template = &template
+		}
+
+		for _, key := range []string{step.Name, step.UUID} {
+			override, ok := opts.Steps[key]
+			if key == "" || !ok {
+				continue
+			}
+			used[key] = true
+			if step.Template == nil {
+				return nil, errors.Errorf("step %q has no template to override", key)
+			}
+			if override.Subject != "" {
+				step.Template.Subject = override.Subject
+			}
+			if override.Content != nil {
+				step.Template.Content = override.Content
+			}
+		}
+		request.Steps = append(request.Steps, step)
+	}
+
+	for key := range opts.Steps {
+		if !used[key] {
+			return nil, errors.Errorf("blueprint %s has no step %q", blueprint.Id, key)
+		}
+	}
+	return request, nil
+}
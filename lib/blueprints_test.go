@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/http/httptest"
 	"path/filepath"
 	"testing"
 
@@ -68,3 +69,118 @@ func TestBlueprintService_GetByTemplateID_Success(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, resp, expectedResponse)
 }
+
+func blueprintWorkflowServer(t *testing.T, groups []lib.NotificationGroup, created *lib.CreateWorkflowRequest, createdGroup *string) *httptest.Server {
+	var blueprint lib.BlueprintByTemplateIdResponse
+	fileToStruct(filepath.Join("../testdata", "blueprint_response.json"), &blueprint)
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var resp interface{}
+		switch {
+		case req.Method == http.MethodGet && req.URL.Path == "/v1/blueprints/"+templateID:
+			resp = blueprint
+		case req.Method == http.MethodGet && req.URL.Path == "/v1/notification-groups":
+			resp = lib.NotificationGroupsResponse{Data: groups}
+		case req.Method == http.MethodPost && req.URL.Path == "/v1/notification-groups":
+			var body map[string]string
+			require.NoError(t, json.NewDecoder(req.Body).Decode(&body))
+			*createdGroup = body["name"]
+			resp = lib.NotificationGroupResponse{Data: lib.NotificationGroup{Id: "new-group", Name: body["name"]}}
+		case req.Method == http.MethodPost && req.URL.Path == "/v1/workflows":
+			require.NoError(t, json.NewDecoder(req.Body).Decode(created))
+			resp = lib.WorkflowResponse{Data: lib.Workflow{
+				Id:                  "workflow1",
+				Name:                created.Name,
+				NotificationGroupID: created.NotificationGroupId,
+				Steps:               created.Steps,
+			}}
+		default:
+			t.Errorf("unexpected request %s %s", req.Method, req.URL)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		bb, _ := json.Marshal(resp)
+		w.Write(bb)
+	}))
+}
+
+func TestBlueprintService_CreateWorkflow_Success(t *testing.T) {
+	var created lib.CreateWorkflowRequest
+	var createdGroup string
+	groups := []lib.NotificationGroup{{Id: "general", Name: "General"}, {Id: "onboarding", Name: "Onboarding"}}
+	httpServer := blueprintWorkflowServer(t, groups, &created, &createdGroup)
+	defer httpServer.Close()
+
+	ctx := context.Background()
+	c := lib.NewAPIClient(novuApiKey, &lib.Config{BackendURL: lib.MustParseURL(httpServer.URL)})
+	workflow, err := c.BlueprintApi.CreateWorkflow(ctx, templateID, &lib.BlueprintWorkflowOptions{
+		Name: "Welcome",
+		Steps: map[string]lib.StepOverride{
+			"Email": {Subject: "Welcome aboard", Content: &lib.StepContent{Text: "<p>Hi {{firstName}}</p>"}},
+		},
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, "workflow1", workflow.Id)
+	require.Equal(t, "Welcome", created.Name)
+	require.Equal(t, "onboarding", created.NotificationGroupId)
+	require.Equal(t, "string", created.BlueprintId)
+	require.True(t, created.Draft)
+	require.Empty(t, createdGroup)
+
+	require.Len(t, created.Steps, 2)
+	inApp, email := created.Steps[0], created.Steps[1]
+	require.Empty(t, inApp.Id)
+	require.Empty(t, inApp.Template.Id)
+	require.Empty(t, inApp.Template.FeedId)
+	require.Equal(t, &lib.StepContent{Text: "Welcome {{firstName}}!"}, inApp.Template.Content)
+	require.Equal(t, lib.StepTypeEmail, email.Template.Type)
+	require.Equal(t, "Welcome aboard", email.Template.Subject)
+	require.Equal(t, &lib.StepContent{Text: "<p>Hi {{firstName}}</p>"}, email.Template.Content)
+	require.Empty(t, email.Template.LayoutId)
+	require.Equal(t, "payload", email.Filters[0].Children[0].On)
+}
+
+func TestBlueprintService_CreateWorkflow_CreatesMissingGroup(t *testing.T) {
+	var created lib.CreateWorkflowRequest
+	var createdGroup string
+	httpServer := blueprintWorkflowServer(t, nil, &created, &createdGroup)
+	defer httpServer.Close()
+
+	ctx := context.Background()
+	c := lib.NewAPIClient(novuApiKey, &lib.Config{BackendURL: lib.MustParseURL(httpServer.URL)})
+	_, err := c.BlueprintApi.CreateWorkflow(ctx, templateID, &lib.BlueprintWorkflowOptions{Active: true})
+	require.NoError(t, err)
+
+	require.Equal(t, "Onboarding", createdGroup)
+	require.Equal(t, "new-group", created.NotificationGroupId)
+	require.Equal(t, "string", created.Name)
+	require.True(t, created.Active)
+	require.False(t, created.Draft)
+}
+
+func TestBlueprintService_CreateWorkflow_UnknownStep(t *testing.T) {
+	var created lib.CreateWorkflowRequest
+	var createdGroup string
+	httpServer := blueprintWorkflowServer(t, nil, &created, &createdGroup)
+	defer httpServer.Close()
+
+	ctx := context.Background()
+	c := lib.NewAPIClient(novuApiKey, &lib.Config{BackendURL: lib.MustParseURL(httpServer.URL)})
+	_, err := c.BlueprintApi.CreateWorkflow(ctx, templateID, &lib.BlueprintWorkflowOptions{
+		NotificationGroupId: "group",
+		Steps:               map[string]lib.StepOverride{"SMS": {Subject: "Hi"}},
+	})
+	require.ErrorContains(t, err, `no step "SMS"`)
+}
+
+func TestStepContent_JSON(t *testing.T) {
+	var content lib.StepContent
+	require.NoError(t, json.Unmarshal([]byte(`[{"type":"text","content":"Hi"}]`), &content))
+	require.Equal(t, lib.StepContent{Blocks: []lib.EmailBlock{{Type: "text", Content: "Hi"}}}, content)
+
+	bb, err := json.Marshal(lib.StepContent{Text: "Hi"})
+	require.NoError(t, err)
+	require.Equal(t, `"Hi"`, string(bb))
+}
@@ -7,6 +7,7 @@ import (
 	"log"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"testing"
@@ -263,3 +264,113 @@ func TestChangesService_BulkApply_Success(t *testing.T) {
 		assert.Equal(t, expectedResponse, resp)
 	})
 }
+
+// promotionServer lists the pending changes by pages of at most 4 changes,
+// whatever the limit requested.
+func promotionServer(t *testing.T, applied *[][]string) *httptest.Server {
+	var changes []lib.ChangesGetResponseData
+	fileToStruct(filepath.Join("../testdata", "pending_changes_response.json"), &changes)
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/v1/changes":
+			assert.Equal(t, "false", req.URL.Query().Get("promoted"))
+			page, _ := strconv.Atoi(req.URL.Query().Get("page"))
+			limit, _ := strconv.Atoi(req.URL.Query().Get("limit"))
+			if limit > 4 {
+				limit = 4
+			}
+			resp := lib.ChangesGetResponse{TotalCount: len(changes), Page: page, PageSize: limit, Data: []lib.ChangesGetResponseData{}}
+			for i := (page - 1) * limit; i < len(changes) && i < page*limit; i++ {
+				resp.Data = append(resp.Data, changes[i])
+			}
+			bb, _ := json.Marshal(resp)
+			w.Write(bb)
+		case "/v1/changes/bulk/apply":
+			var payload lib.ChangesBulkApplyPayload
+			require.NoError(t, json.NewDecoder(req.Body).Decode(&payload))
+			*applied = append(*applied, payload.ChangeIds)
+			resp := lib.ChangesApplyResponse{}
+			for _, id := range payload.ChangeIds {
+				resp.Data = append(resp.Data, lib.ChangesGetResponseData{Id: id, Enabled: true})
+			}
+			bb, _ := json.Marshal(resp)
+			w.Write(bb)
+		default:
+			t.Errorf("unexpected request %s %s", req.Method, req.URL)
+		}
+	}))
+}
+
+func TestChangesService_PlanPromotion(t *testing.T) {
+	var applied [][]string
+	server := promotionServer(t, &applied)
+	defer server.Close()
+
+	ctx := context.Background()
+	c := lib.NewAPIClient(novuApiKey, &lib.Config{BackendURL: lib.MustParseURL(server.URL)})
+
+	plan, err := c.ChangesApi.PlanPromotion(ctx, &lib.PromotionOptions{PageSize: 4})
+	require.NoError(t, err)
+
+	t.Run("Changes are ordered after their dependencies", func(t *testing.T) {
+		assert.Equal(t, []string{"c-lay", "c-grp", "c-feed", "c-wf", "c-lay2"}, plan.ChangeIds())
+		assert.ElementsMatch(t, []string{"c-grp", "c-lay", "c-feed"}, plan.Changes[3].DependsOn)
+	})
+
+	t.Run("Entities are decoded", func(t *testing.T) {
+		workflow := plan.Changes[3]
+		assert.Equal(t, &lib.WorkflowChange{Name: "Welcome", Active: true, NotificationGroupId: "grp1", Tags: []string{"onboarding"}}, workflow.Entity)
+		require.Len(t, workflow.Children, 1)
+		assert.Equal(t, &lib.MessageTemplateChange{Name: "Welcome email", Type: lib.EMAIL, Subject: "Welcome {{name}}", LayoutId: "lay1", FeedId: "feed1"}, workflow.Children[0].Entity)
+		assert.Equal(t, &lib.LayoutChange{Name: "Default", Content: "<main>{{{body}}}</main>"}, plan.Changes[0].Entity)
+	})
+
+	t.Run("Plan renders as a diff", func(t *testing.T) {
+		assert.Equal(t, `update Layout "Default" (change c-lay)
+  ~ content: "<div>{{{body}}}</div>" -> "<main>{{{body}}}</main>"
+  + name: "Default"
+  - variables[1]: {"name":"footer"}
+create NotificationGroup "General" (change c-grp)
+create Feed "Updates" (change c-feed)
+create NotificationTemplate "Welcome" (change c-wf)
+  create MessageTemplate "Welcome email" (change c-msg)
+update Layout "Marketing" (change c-lay2)
+  ~ name: "Old" -> "Marketing"
+`, plan.String())
+	})
+
+	t.Run("Selecting a change keeps its dependencies", func(t *testing.T) {
+		assert.Equal(t, []string{"c-lay", "c-grp", "c-feed", "c-wf"}, plan.Select("c-msg").ChangeIds())
+		assert.Equal(t, []string{"c-lay2"}, plan.Select("c-lay2").ChangeIds())
+	})
+
+	t.Run("Pages shorter than the limit", func(t *testing.T) {
+		plan, err := c.ChangesApi.PlanPromotion(ctx, &lib.PromotionOptions{PageSize: 10})
+		require.NoError(t, err)
+		assert.Len(t, plan.ChangeIds(), 5)
+	})
+
+	t.Run("Types limit the plan", func(t *testing.T) {
+		plan, err := c.ChangesApi.PlanPromotion(ctx, &lib.PromotionOptions{Types: []lib.ChangeType{lib.ChangeTypeFeed, lib.ChangeTypeNotificationGroup}})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"c-grp", "c-feed"}, plan.ChangeIds())
+	})
+
+	t.Run("Dry run applies nothing", func(t *testing.T) {
+		var out strings.Builder
+		result, err := c.ChangesApi.Promote(ctx, plan, &lib.PromoteOptions{DryRun: true, Output: &out})
+		require.NoError(t, err)
+		assert.Empty(t, result.Applied)
+		assert.Empty(t, applied)
+		assert.Equal(t, plan.String(), out.String())
+	})
+
+	t.Run("Changes are applied in batches", func(t *testing.T) {
+		result, err := c.ChangesApi.Promote(ctx, plan.Select("c-wf"), &lib.PromoteOptions{BatchSize: 3})
+		require.NoError(t, err)
+		assert.Equal(t, 2, result.Batches)
+		assert.Len(t, result.Applied, 4)
+		assert.Equal(t, [][]string{{"c-lay", "c-grp", "c-feed"}, {"c-wf"}}, applied)
+	})
+}
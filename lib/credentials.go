@@ -0,0 +1,374 @@
+package lib
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// CredentialKey is the JSON name of a field of IntegrationCredentials.
+type CredentialKey string
+
+const (
+	CredentialApiKey           CredentialKey = "apiKey"
+	CredentialUser             CredentialKey = "user"
+	CredentialSecretKey        CredentialKey = "secretKey"
+	CredentialDomain           CredentialKey = "domain"
+	CredentialPassword         CredentialKey = "password"
+	CredentialHost             CredentialKey = "host"
+	CredentialPort             CredentialKey = "port"
+	CredentialSecure           CredentialKey = "secure"
+	CredentialRegion           CredentialKey = "region"
+	CredentialAccountSID       CredentialKey = "accountSid"
+	CredentialMessageProfileID CredentialKey = "messageProfileId"
+	CredentialToken            CredentialKey = "token"
+	CredentialFrom             CredentialKey = "from"
+	CredentialSenderName       CredentialKey = "senderName"
+	CredentialProjectName      CredentialKey = "projectName"
+	CredentialApplicationID    CredentialKey = "applicationId"
+	CredentialClientID         CredentialKey = "clientId"
+	CredentialTlsOptions       CredentialKey = "tlsOptions"
+	CredentialBaseUrl          CredentialKey = "baseUrl"
+	CredentialWebhookUrl       CredentialKey = "webhookUrl"
+	CredentialRedirectUrl      CredentialKey = "redirectUrl"
+	CredentialHmac             CredentialKey = "hmac"
+	CredentialServiceAccount   CredentialKey = "serviceAccount"
+)
+
+const maskedCredential = "********"
+
+// secretCredentials are never printed by IntegrationCredentials.String or MarshalLog.
+// Webhook URLs embed their token, as for Slack and Discord, and TLS options may
+// hold private keys and their passphrase.
+var secretCredentials = map[CredentialKey]bool{
+	CredentialApiKey:         true,
+	CredentialSecretKey:      true,
+	CredentialPassword:       true,
+	CredentialToken:          true,
+	CredentialWebhookUrl:     true,
+	CredentialTlsOptions:     true,
+	CredentialServiceAccount: true,
+}
+
+// IsSecret reports whether the credential must be masked when logged.
+func (k CredentialKey) IsSecret() bool {
+	return secretCredentials[k]
+}
+
+// CredentialSchema lists the credentials a provider accepts.
+type CredentialSchema struct {
+	Required []CredentialKey
+	Optional []CredentialKey
+}
+
+// GetCredentialSchema returns the credentials known for a provider.
+func GetCredentialSchema(providerID ProviderIdType) (CredentialSchema, bool) {
+	provider, ok := GetProvider(providerID)
+	return provider.Credentials, ok
+}
+
+// Values returns the credentials that are set, keyed by their JSON name.
+func (c IntegrationCredentials) Values() map[CredentialKey]interface{} {
+	values := map[CredentialKey]interface{}{}
+
+	v := reflect.ValueOf(c)
+	t := v.Type()
+	for i := 0; i < v.NumField(); i++ {
+		if v.Field(i).IsZero() {
+			continue
+		}
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		values[CredentialKey(name)] = v.Field(i).Interface()
+	}
+
+	return values
+}
+
+// MergeCredentials overlays credentials in order: the fields set in one
+// replace those of the credentials before it.
+func MergeCredentials(credentials ...IntegrationCredentials) IntegrationCredentials {
+	var merged IntegrationCredentials
+	m := reflect.ValueOf(&merged).Elem()
+	for _, c := range credentials {
+		v := reflect.ValueOf(c)
+		for i := 0; i < v.NumField(); i++ {
+			if !v.Field(i).IsZero() {
+				m.Field(i).Set(v.Field(i))
+			}
+		}
+	}
+	return merged
+}
+
+// Validate checks that every credential required by the provider is set.
+// Providers missing from the registry are not validated.
+func (c IntegrationCredentials) Validate(providerID ProviderIdType) error {
+	schema, ok := GetCredentialSchema(providerID)
+	if !ok {
+		return nil
+	}
+
+	values := c.Values()
+	var missing []string
+	for _, key := range schema.Required {
+		if _, ok := values[key]; !ok {
+			missing = append(missing, string(key))
+		}
+	}
+
+	if len(missing) > 0 {
+		return errors.Errorf("missing %s credentials: %s", providerID, strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+// MarshalLog returns the credentials with the secrets masked, for structured loggers.
+func (c IntegrationCredentials) MarshalLog() interface{} {
+	masked := map[string]interface{}{}
+	for key, value := range c.Values() {
+		if key.IsSecret() {
+			value = maskedCredential
+		}
+		masked[string(key)] = value
+	}
+
+	return masked
+}
+
+// String prints the credentials with the secrets masked.
+func (c IntegrationCredentials) String() string {
+	masked := c.MarshalLog().(map[string]interface{})
+
+	keys := make([]string, 0, len(masked))
+	for key := range masked {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		value, _ := json.Marshal(masked[key])
+		pairs = append(pairs, fmt.Sprintf("%s:%s", key, value))
+	}
+
+	return "{" + strings.Join(pairs, " ") + "}"
+}
+
+// ProviderCredentials is implemented by the typed credentials of each provider.
+type ProviderCredentials interface {
+	ProviderID() ProviderIdType
+	Channel() ChannelType
+	Credentials() IntegrationCredentials
+}
+
+// NewCreateIntegrationRequest builds a CreateIntegrationRequest after validating the credentials.
+func NewCreateIntegrationRequest(credentials ProviderCredentials, active bool) (CreateIntegrationRequest, error) {
+	creds := credentials.Credentials()
+	if err := creds.Validate(credentials.ProviderID()); err != nil {
+		return CreateIntegrationRequest{}, err
+	}
+
+	return CreateIntegrationRequest{
+		ProviderID:  string(credentials.ProviderID()),
+		Channel:     credentials.Channel(),
+		Credentials: creds,
+		Active:      active,
+		Check:       true,
+	}, nil
+}
+
+// NewUpdateIntegrationRequest builds an UpdateIntegrationRequest after validating the credentials.
+func NewUpdateIntegrationRequest(credentials ProviderCredentials, active bool) (UpdateIntegrationRequest, error) {
+	creds := credentials.Credentials()
+	if err := creds.Validate(credentials.ProviderID()); err != nil {
+		return UpdateIntegrationRequest{}, err
+	}
+
+	return UpdateIntegrationRequest{
+		Credentials: creds,
+		Active:      active,
+		Check:       true,
+	}, nil
+}
+
+type SendGridCredentials struct {
+	ApiKey     string
+	From       string
+	SenderName string
+}
+
+func (SendGridCredentials) ProviderID() ProviderIdType { return ProviderSendGrid }
+func (SendGridCredentials) Channel() ChannelType       { return EMAIL }
+func (c SendGridCredentials) Credentials() IntegrationCredentials {
+	return IntegrationCredentials{ApiKey: c.ApiKey, From: c.From, SenderName: c.SenderName}
+}
+
+type SESCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	Region          string
+	From            string
+	SenderName      string
+}
+
+func (SESCredentials) ProviderID() ProviderIdType { return ProviderSES }
+func (SESCredentials) Channel() ChannelType       { return EMAIL }
+func (c SESCredentials) Credentials() IntegrationCredentials {
+	return IntegrationCredentials{
+		ApiKey:     c.AccessKeyID,
+		SecretKey:  c.SecretAccessKey,
+		Region:     c.Region,
+		From:       c.From,
+		SenderName: c.SenderName,
+	}
+}
+
+type MailgunCredentials struct {
+	ApiKey     string
+	Domain     string
+	Username   string
+	BaseUrl    string
+	From       string
+	SenderName string
+}
+
+func (MailgunCredentials) ProviderID() ProviderIdType { return ProviderMailgun }
+func (MailgunCredentials) Channel() ChannelType       { return EMAIL }
+func (c MailgunCredentials) Credentials() IntegrationCredentials {
+	return IntegrationCredentials{
+		ApiKey:     c.ApiKey,
+		Domain:     c.Domain,
+		User:       c.Username,
+		BaseUrl:    c.BaseUrl,
+		From:       c.From,
+		SenderName: c.SenderName,
+	}
+}
+
+type PostmarkCredentials struct {
+	ApiKey     string
+	From       string
+	SenderName string
+}
+
+func (PostmarkCredentials) ProviderID() ProviderIdType { return ProviderPostmark }
+func (PostmarkCredentials) Channel() ChannelType       { return EMAIL }
+func (c PostmarkCredentials) Credentials() IntegrationCredentials {
+	return IntegrationCredentials{ApiKey: c.ApiKey, From: c.From, SenderName: c.SenderName}
+}
+
+// SMTPCredentials configures the custom SMTP (nodemailer) provider.
+type SMTPCredentials struct {
+	Host       string
+	Port       string
+	User       string
+	Password   string
+	Secure     bool
+	From       string
+	SenderName string
+}
+
+func (SMTPCredentials) ProviderID() ProviderIdType { return ProviderNodemailer }
+func (SMTPCredentials) Channel() ChannelType       { return EMAIL }
+func (c SMTPCredentials) Credentials() IntegrationCredentials {
+	return IntegrationCredentials{
+		Host:       c.Host,
+		Port:       c.Port,
+		User:       c.User,
+		Password:   c.Password,
+		Secure:     c.Secure,
+		From:       c.From,
+		SenderName: c.SenderName,
+	}
+}
+
+type TwilioCredentials struct {
+	AccountSID string
+	AuthToken  string
+	From       string
+}
+
+func (TwilioCredentials) ProviderID() ProviderIdType { return ProviderTwilio }
+func (TwilioCredentials) Channel() ChannelType       { return SMS }
+func (c TwilioCredentials) Credentials() IntegrationCredentials {
+	return IntegrationCredentials{AccountSID: c.AccountSID, Token: c.AuthToken, From: c.From}
+}
+
+type FCMCredentials struct {
+	// ServiceAccount is the JSON key of the Firebase service account.
+	ServiceAccount string
+}
+
+func (FCMCredentials) ProviderID() ProviderIdType { return ProviderFCM }
+func (FCMCredentials) Channel() ChannelType       { return PUSH }
+func (c FCMCredentials) Credentials() IntegrationCredentials {
+	return IntegrationCredentials{ServiceAccount: c.ServiceAccount}
+}
+
+type APNSCredentials struct {
+	// PrivateKey is the content of the .p8 key file.
+	PrivateKey string
+	KeyID      string
+	TeamID     string
+	BundleID   string
+	Production bool
+}
+
+func (APNSCredentials) ProviderID() ProviderIdType { return ProviderAPNS }
+func (APNSCredentials) Channel() ChannelType       { return PUSH }
+func (c APNSCredentials) Credentials() IntegrationCredentials {
+	return IntegrationCredentials{
+		SecretKey:     c.PrivateKey,
+		ApiKey:        c.KeyID,
+		ProjectName:   c.TeamID,
+		ApplicationID: c.BundleID,
+		Secure:        c.Production,
+	}
+}
+
+type ExpoCredentials struct {
+	AccessToken string
+}
+
+func (ExpoCredentials) ProviderID() ProviderIdType { return ProviderExpo }
+func (ExpoCredentials) Channel() ChannelType       { return PUSH }
+func (c ExpoCredentials) Credentials() IntegrationCredentials {
+	return IntegrationCredentials{ApiKey: c.AccessToken}
+}
+
+type SlackCredentials struct {
+	ApplicationID string
+	ClientID      string
+	ClientSecret  string
+	RedirectUrl   string
+	Hmac          bool
+}
+
+func (SlackCredentials) ProviderID() ProviderIdType { return ProviderSlack }
+func (SlackCredentials) Channel() ChannelType       { return CHAT }
+func (c SlackCredentials) Credentials() IntegrationCredentials {
+	return IntegrationCredentials{
+		ApplicationID: c.ApplicationID,
+		ClientID:      c.ClientID,
+		SecretKey:     c.ClientSecret,
+		RedirectUrl:   c.RedirectUrl,
+		Hmac:          c.Hmac,
+	}
+}
+
+type PushWebhookCredentials struct {
+	WebhookUrl string
+	// SecretKey signs the webhook payloads with HMAC.
+	SecretKey string
+}
+
+func (PushWebhookCredentials) ProviderID() ProviderIdType { return ProviderPushWebhook }
+func (PushWebhookCredentials) Channel() ChannelType       { return PUSH }
+func (c PushWebhookCredentials) Credentials() IntegrationCredentials {
+	return IntegrationCredentials{WebhookUrl: c.WebhookUrl, SecretKey: c.SecretKey}
+}
@@ -4,7 +4,11 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
 )
 
 type IEvent interface {
@@ -12,8 +16,14 @@ type IEvent interface {
 	TriggerBulk(ctx context.Context, data []BulkTriggerOptions) ([]EventResponse, error)
 	BroadcastToAll(ctx context.Context, data BroadcastEventToAll) (EventResponse, error)
 	CancelTrigger(ctx context.Context, transactionId string) (bool, error)
+	CancelTriggers(ctx context.Context, transactionIds []string, opts *CancelTriggersOptions) ([]CancelTriggerResult, error)
+	TriggerBulkChunked(ctx context.Context, events []BulkTriggerOptions, opts *TriggerBulkChunkedOptions) ([]BulkTriggerResult, error)
 }
 
+// MaxBulkTriggerEvents is the largest number of events Novu accepts in a bulk
+// trigger request.
+const MaxBulkTriggerEvents = 100
+
 type EventService service
 
 func (e *EventService) Trigger(ctx context.Context, eventId string, data ITriggerPayloadOptions) (EventResponse, error) {
@@ -27,6 +37,7 @@ func (e *EventService) Trigger(ctx context.Context, eventId string, data ITrigge
 		Overrides:     data.Overrides,
 		TransactionId: data.TransactionId,
 		Actor:         data.Actor,
+		Tenant:        data.Tenant,
 	}
 
 	jsonBody, _ := json.Marshal(reqBody)
@@ -78,6 +89,7 @@ func (e *EventService) BroadcastToAll(ctx context.Context, data BroadcastEventTo
 		Overrides:     data.Overrides,
 		TransactionId: data.TransactionId,
 		Actor:         data.Actor,
+		Tenant:        data.Tenant,
 	}
 
 	jsonBody, _ := json.Marshal(reqBody)
@@ -112,4 +124,198 @@ func (e *EventService) CancelTrigger(ctx context.Context, transactionId string)
 	return resp, nil
 }
 
+// CancelTriggers cancels the delayed or digested events of many transactions.
+// The results are aligned with transactionIds; a *BulkError is returned when
+// at least one of the cancellations failed.
+func (e *EventService) CancelTriggers(ctx context.Context, transactionIds []string, opts *CancelTriggersOptions) ([]CancelTriggerResult, error) {
+	if opts == nil {
+		opts = &CancelTriggersOptions{}
+	}
+
+	results := make([]CancelTriggerResult, len(transactionIds))
+	errs := runConcurrently(ctx, len(transactionIds), opts.Concurrency, func(ctx context.Context, i int) error {
+		cancelled, err := e.CancelTrigger(ctx, transactionIds[i])
+		results[i].Cancelled = cancelled
+		return err
+	})
+
+	var failed []error
+	for i, err := range errs {
+		results[i].TransactionId = transactionIds[i]
+		if err != nil {
+			results[i].Err = err
+			failed = append(failed, errors.Wrapf(err, "transaction %s", transactionIds[i]))
+		}
+	}
+
+	if len(failed) > 0 {
+		return results, &BulkError{Total: len(transactionIds), Errors: failed}
+	}
+
+	return results, nil
+}
+
 var _ IEvent = &EventService{}
+
+// TriggerBulkChunked triggers any number of events, split into bulk requests
+// of at most MaxBulkTriggerEvents sent concurrently. The results are aligned
+// with events; a *BulkError is returned when at least one event failed, so a
+// bad event or chunk does not fail the others. A chunk Novu rejects with a
+// client error is split in halves and sent again, so that only the events it
+// objects to fail. With a key set by WithIdempotencyKey, each request is sent
+// with a key derived from it.
+func (e *EventService) TriggerBulkChunked(ctx context.Context, events []BulkTriggerOptions, opts *TriggerBulkChunkedOptions) ([]BulkTriggerResult, error) {
+	if opts == nil {
+		opts = &TriggerBulkChunkedOptions{}
+	}
+	size := opts.ChunkSize
+	if size <= 0 {
+		size = MaxBulkTriggerEvents
+	}
+	if size > MaxBulkTriggerEvents {
+		return nil, errors.Errorf("chunk size %d exceeds the maximum of %d", size, MaxBulkTriggerEvents)
+	}
+
+	results := make([]BulkTriggerResult, len(events))
+	chunks := (len(events) + size - 1) / size
+	errs := runConcurrently(ctx, chunks, opts.Concurrency, func(ctx context.Context, i int) error {
+		start, end := i*size, (i+1)*size
+		if end > len(events) {
+			end = len(events)
+		}
+		// Each chunk is a request of its own for the idempotency key.
+		e.triggerBulkSplit(withIdempotencyKeySuffix(ctx, fmt.Sprintf(".%d", i)), events[start:end], results[start:end])
+		return nil
+	})
+
+	var failed []error
+	for i := range results {
+		if err := errs[i/size]; err != nil {
+			results[i].Err = err
+		}
+		if results[i].TransactionId == "" {
+			results[i].TransactionId = events[i].TransactionId
+		}
+		if results[i].Err != nil {
+			failed = append(failed, errors.Wrapf(results[i].Err, "event %d", i))
+		}
+	}
+
+	if len(failed) > 0 {
+		return results, &BulkError{Total: len(events), Errors: failed}
+	}
+
+	return results, nil
+}
+
+// triggerBulkSplit sends events in one request and stores their outcome in
+// results. When Novu rejects the request as invalid, the halves of events are
+// sent separately until the invalid events are isolated.
+func (e *EventService) triggerBulkSplit(ctx context.Context, events []BulkTriggerOptions, results []BulkTriggerResult) {
+	chunk, err := e.triggerBulk(ctx, events)
+	if err != nil {
+		if len(events) > 1 && rejectsEvents(err) {
+			half := len(events) / 2
+			e.triggerBulkSplit(withIdempotencyKeySuffix(ctx, ".0"), events[:half], results[:half])
+			e.triggerBulkSplit(withIdempotencyKeySuffix(ctx, ".1"), events[half:], results[half:])
+			return
+		}
+		for i := range results {
+			results[i].Err = err
+		}
+		return
+	}
+	for i := range results {
+		if i >= len(chunk) {
+			results[i].Err = errors.New("no result returned for the event")
+			continue
+		}
+		r := chunk[i]
+		results[i] = BulkTriggerResult{TransactionId: r.TransactionId, Acknowledged: r.Acknowledged, Status: r.Status, Err: r.err()}
+	}
+}
+
+// rejectsEvents tells whether Novu refused a request because of its content,
+// rather than because of a conflict or its rate limit.
+func rejectsEvents(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	switch apiErr.StatusCode {
+	case http.StatusRequestTimeout, http.StatusConflict, http.StatusTooManyRequests:
+		return false
+	}
+	return apiErr.StatusCode >= http.StatusBadRequest && apiErr.StatusCode < http.StatusInternalServerError
+}
+
+type bulkTriggerEventResult struct {
+	Acknowledged  bool        `json:"acknowledged"`
+	Status        string      `json:"status"`
+	TransactionId string      `json:"transactionId"`
+	Error         interface{} `json:"error,omitempty"`
+}
+
+// err reports the events Novu did not process, such as those of inactive
+// workflows.
+func (r bulkTriggerEventResult) err() error {
+	if r.Status == "processed" || (r.Status == "" && r.Acknowledged) {
+		return nil
+	}
+	var details []string
+	switch v := r.Error.(type) {
+	case string:
+		details = append(details, v)
+	case []interface{}:
+		for _, d := range v {
+			details = append(details, fmt.Sprint(d))
+		}
+	}
+	if len(details) == 0 {
+		return errors.Errorf("event not processed: %s", r.Status)
+	}
+	return errors.Errorf("event not processed: %s: %s", r.Status, strings.Join(details, "; "))
+}
+
+// triggerBulk sends a bulk trigger request. Novu answers with the results
+// wrapped in data; the bare list of responses is accepted as well.
+func (e *EventService) triggerBulk(ctx context.Context, events []BulkTriggerOptions) ([]bulkTriggerEventResult, error) {
+	URL := e.client.config.BackendURL.JoinPath("events/trigger/bulk")
+
+	jsonBody, err := json.Marshal(BulkTriggerEvent{Events: events})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, URL.String(), bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return nil, err
+	}
+
+	var raw json.RawMessage
+	if _, err := e.client.sendRequest(req, &raw); err != nil {
+		return nil, err
+	}
+
+	var results []bulkTriggerEventResult
+	if trimmed := bytes.TrimSpace(raw); len(trimmed) > 0 && trimmed[0] == '[' {
+		var responses []struct {
+			Data bulkTriggerEventResult `json:"data"`
+		}
+		if err := json.Unmarshal(trimmed, &responses); err != nil {
+			return nil, errors.Wrap(err, "unable to unmarshal response body")
+		}
+		for _, r := range responses {
+			results = append(results, r.Data)
+		}
+		return results, nil
+	}
+
+	var resp struct {
+		Data []bulkTriggerEventResult `json:"data"`
+	}
+	if err := json.Unmarshal(raw, &resp); err != nil {
+		return nil, errors.Wrap(err, "unable to unmarshal response body")
+	}
+	return resp.Data, nil
+}
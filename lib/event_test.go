@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"log"
 	"net/http"
@@ -11,7 +12,10 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/novuhq/go-novu/lib"
 	"github.com/stretchr/testify/require"
@@ -265,3 +269,236 @@ func TestCancelTriggeredEvent_Success(t *testing.T) {
 		assert.Equal(t, expectedResponse, resp)
 	})
 }
+
+func TestCancelTriggers_PartialFailure(t *testing.T) {
+	var inFlight, maxInFlight int32
+
+	eventService := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		current := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			max := atomic.LoadInt32(&maxInFlight)
+			if current <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, current) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+
+		assert.Equal(t, http.MethodDelete, req.Method)
+		if req.URL.Path == "/v1/events/trigger/tx-bad" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"not found"}`))
+			return
+		}
+		w.Write([]byte(`true`))
+	}))
+	defer eventService.Close()
+
+	c := lib.NewAPIClient(novuApiKey, &lib.Config{BackendURL: lib.MustParseURL(eventService.URL)})
+
+	ids := []string{"tx-1", "tx-2", "tx-bad", "tx-3", "tx-4", "tx-5"}
+	results, err := c.EventApi.CancelTriggers(context.Background(), ids, &lib.CancelTriggersOptions{Concurrency: 2})
+
+	var bulkErr *lib.BulkError
+	require.ErrorAs(t, err, &bulkErr)
+	assert.Equal(t, len(ids), bulkErr.Total)
+	assert.Len(t, bulkErr.Errors, 1)
+
+	require.Len(t, results, len(ids))
+	for i, result := range results {
+		assert.Equal(t, ids[i], result.TransactionId)
+		if ids[i] == "tx-bad" {
+			assert.Error(t, result.Err)
+			assert.False(t, result.Cancelled)
+		} else {
+			assert.NoError(t, result.Err)
+			assert.True(t, result.Cancelled)
+		}
+	}
+	assert.LessOrEqual(t, atomic.LoadInt32(&maxInFlight), int32(2))
+}
+
+func TestTriggerBulkChunked_PartialFailure(t *testing.T) {
+	var inFlight, maxInFlight, requests int32
+
+	eventService := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		current := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			max := atomic.LoadInt32(&maxInFlight)
+			if current <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, current) {
+				break
+			}
+		}
+		atomic.AddInt32(&requests, 1)
+		time.Sleep(10 * time.Millisecond)
+
+		assert.Equal(t, "/v1/events/trigger/bulk", req.URL.Path)
+		var body lib.BulkTriggerEvent
+		require.NoError(t, json.NewDecoder(req.Body).Decode(&body))
+		assert.LessOrEqual(t, len(body.Events), 4)
+
+		var results []map[string]interface{}
+		for _, event := range body.Events {
+			switch event.TransactionId {
+			case "tx-5":
+				w.WriteHeader(http.StatusUnprocessableEntity)
+				w.Write([]byte(`{"message": "workflow not found"}`))
+				return
+			case "tx-2":
+				results = append(results, map[string]interface{}{"acknowledged": true, "status": "error", "error": []string{"subscriber is missing"}, "transactionId": event.TransactionId})
+			default:
+				results = append(results, map[string]interface{}{"acknowledged": true, "status": "processed", "transactionId": event.TransactionId})
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": results})
+	}))
+	defer eventService.Close()
+
+	c := lib.NewAPIClient(novuApiKey, &lib.Config{BackendURL: lib.MustParseURL(eventService.URL)})
+
+	var events []lib.BulkTriggerOptions
+	for i := 0; i < 10; i++ {
+		events = append(events, lib.BulkTriggerOptions{Name: "welcome", To: "sub-1", TransactionId: fmt.Sprintf("tx-%d", i)})
+	}
+	results, err := c.EventApi.TriggerBulkChunked(context.Background(), events, &lib.TriggerBulkChunkedOptions{ChunkSize: 4, Concurrency: 2})
+
+	var bulkErr *lib.BulkError
+	require.ErrorAs(t, err, &bulkErr)
+	assert.Equal(t, 10, bulkErr.Total)
+	// tx-2 was not processed and tx-5 was rejected: its chunk (tx-4 to tx-7)
+	// was split into tx-4 and tx-5, then tx-4 alone and tx-5 alone, and tx-6
+	// and tx-7.
+	assert.Len(t, bulkErr.Errors, 2)
+	assert.Equal(t, int32(7), atomic.LoadInt32(&requests))
+	assert.LessOrEqual(t, atomic.LoadInt32(&maxInFlight), int32(2))
+
+	require.Len(t, results, len(events))
+	for i, result := range results {
+		assert.Equal(t, events[i].TransactionId, result.TransactionId)
+		switch {
+		case i == 2:
+			assert.ErrorContains(t, result.Err, "event not processed: error: subscriber is missing")
+			assert.Equal(t, "error", result.Status)
+		case i == 5:
+			var apiErr *lib.APIError
+			require.ErrorAs(t, result.Err, &apiErr)
+			assert.Equal(t, http.StatusUnprocessableEntity, apiErr.StatusCode)
+		default:
+			assert.NoError(t, result.Err)
+			assert.Equal(t, "processed", result.Status)
+		}
+	}
+}
+
+func TestTriggerBulkChunked_IdempotencyKeys(t *testing.T) {
+	var mu sync.Mutex
+	var keys []string
+	eventService := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var body lib.BulkTriggerEvent
+		require.NoError(t, json.NewDecoder(req.Body).Decode(&body))
+		mu.Lock()
+		keys = append(keys, req.Header.Get("Idempotency-Key"))
+		mu.Unlock()
+
+		var results []map[string]interface{}
+		for _, event := range body.Events {
+			if event.TransactionId == "tx-5" {
+				w.WriteHeader(http.StatusUnprocessableEntity)
+				w.Write([]byte(`{"message": "workflow not found"}`))
+				return
+			}
+			results = append(results, map[string]interface{}{"acknowledged": true, "status": "processed", "transactionId": event.TransactionId})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": results})
+	}))
+	defer eventService.Close()
+
+	c := lib.NewAPIClient(novuApiKey, &lib.Config{BackendURL: lib.MustParseURL(eventService.URL)})
+	var events []lib.BulkTriggerOptions
+	for i := 0; i < 8; i++ {
+		events = append(events, lib.BulkTriggerOptions{Name: "welcome", To: "sub-1", TransactionId: fmt.Sprintf("tx-%d", i)})
+	}
+	ctx := lib.WithIdempotencyKey(context.Background(), "job-1")
+	_, err := c.EventApi.TriggerBulkChunked(ctx, events, &lib.TriggerBulkChunkedOptions{ChunkSize: 4})
+	var bulkErr *lib.BulkError
+	require.ErrorAs(t, err, &bulkErr)
+	assert.Len(t, bulkErr.Errors, 1)
+
+	// Every chunk, and every half of the rejected one, has a key of its own.
+	assert.ElementsMatch(t, []string{"job-1.0", "job-1.1", "job-1.1.0", "job-1.1.0.0", "job-1.1.0.1", "job-1.1.1"}, keys)
+}
+
+func TestTriggerBulkChunked_LegacyResponse(t *testing.T) {
+	eventService := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		bb, _ := os.ReadFile(filepath.Join("../testdata", "novu_send_trigger_bulk_response.json"))
+		w.Write(bb)
+	}))
+	defer eventService.Close()
+
+	c := lib.NewAPIClient(novuApiKey, &lib.Config{BackendURL: lib.MustParseURL(eventService.URL)})
+	results, err := c.EventApi.TriggerBulkChunked(context.Background(), make([]lib.BulkTriggerOptions, 2), nil)
+	require.NoError(t, err)
+	assert.Equal(t, "d2239acb-e879-4bdb-ab6f-365b43278d8f", results[0].TransactionId)
+	assert.True(t, results[1].Acknowledged)
+
+	_, err = c.EventApi.TriggerBulkChunked(context.Background(), nil, &lib.TriggerBulkChunkedOptions{ChunkSize: 101})
+	assert.ErrorContains(t, err, "exceeds the maximum of 100")
+}
+
+func TestEventServiceTrigger_WithTenant(t *testing.T) {
+	tests := map[string]struct {
+		tenant   *lib.TriggerTenant
+		expected string
+	}{
+		"identifier": {
+			tenant:   &lib.TriggerTenant{Identifier: "acme"},
+			expected: `"acme"`,
+		},
+		"inline": {
+			tenant:   &lib.TriggerTenant{Identifier: "acme", Name: "Acme", Data: map[string]interface{}{"plan": "pro"}},
+			expected: `{"identifier":"acme","name":"Acme","data":{"plan":"pro"}}`,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			eventService := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				var body map[string]json.RawMessage
+				require.NoError(t, json.NewDecoder(req.Body).Decode(&body))
+				assert.JSONEq(t, tc.expected, string(body["tenant"]))
+
+				var decoded lib.TriggerTenant
+				require.NoError(t, json.Unmarshal(body["tenant"], &decoded))
+				assert.Equal(t, *tc.tenant, decoded)
+
+				w.Write([]byte(`{"data":{"acknowledged":true,"status":"processed"}}`))
+			}))
+			defer eventService.Close()
+
+			c := lib.NewAPIClient(novuApiKey, &lib.Config{BackendURL: lib.MustParseURL(eventService.URL)})
+			_, err := c.EventApi.Trigger(context.Background(), novuEventId, lib.ITriggerPayloadOptions{
+				To:     "subscriber",
+				Tenant: tc.tenant,
+			})
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestEventServiceBroadcastToAll_WithTenant(t *testing.T) {
+	eventService := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(req.Body).Decode(&body))
+		assert.Equal(t, "acme", body["tenant"])
+		w.Write([]byte(`{"data":{"acknowledged":true,"status":"processed"}}`))
+	}))
+	defer eventService.Close()
+
+	c := lib.NewAPIClient(novuApiKey, &lib.Config{BackendURL: lib.MustParseURL(eventService.URL)})
+	_, err := c.EventApi.BroadcastToAll(context.Background(), lib.BroadcastEventToAll{
+		Name:   novuEventId,
+		Tenant: &lib.TriggerTenant{Identifier: "acme"},
+	})
+	require.NoError(t, err)
+}
@@ -0,0 +1,34 @@
+package lib
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+type idempotencyKeyContextKey struct{}
+
+// WithIdempotencyKey returns a copy of ctx whose requests are sent with key as
+// their Idempotency-Key header instead of a random one. Novu does not process
+// a request twice for the same key, which makes replaying it safe.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyContextKey{}, key)
+}
+
+// withIdempotencyKeySuffix returns a copy of ctx whose key set by
+// WithIdempotencyKey ends with suffix, for the requests a request is split
+// into. ctx is returned as is when it has no key.
+func withIdempotencyKeySuffix(ctx context.Context, suffix string) context.Context {
+	if key, ok := ctx.Value(idempotencyKeyContextKey{}).(string); ok && key != "" {
+		return WithIdempotencyKey(ctx, key+suffix)
+	}
+	return ctx
+}
+
+// idempotencyKey returns the key set by WithIdempotencyKey, or a new one.
+func idempotencyKey(ctx context.Context) string {
+	if key, ok := ctx.Value(idempotencyKeyContextKey{}).(string); ok && key != "" {
+		return key
+	}
+	return uuid.New().String()
+}
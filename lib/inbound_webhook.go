@@ -0,0 +1,209 @@
+package lib
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const defaultInboundMaxBodyBytes = 25 << 20
+
+// InboundWebhookHandlerFunc handles an email received by the inbound parser.
+// Returning an error makes the handler answer with a server error, without
+// the error itself: fn logs it if needed.
+type InboundWebhookHandlerFunc func(ctx context.Context, payload *InboundWebhookPayload) error
+
+type InboundWebhookOptions struct {
+	// MaxBodyBytes limits the size of the posted payload, attachments
+	// included. It defaults to 25MB.
+	MaxBodyBytes int64
+	// ReplyAddresses verifies the reply address the email was sent to and
+	// decodes it into InboundWebhookPayload.Reply. Emails without a valid
+	// address are rejected.
+	ReplyAddresses *ReplyAddressSigner
+}
+
+// InboundWebhookHandler is an http.Handler receiving the posts Novu sends to
+// the reply callback url of a step when a subscriber replies to an email.
+type InboundWebhookHandler struct {
+	handle InboundWebhookHandlerFunc
+	opts   InboundWebhookOptions
+}
+
+// NewInboundWebhookHandler returns a handler decoding inbound parse webhook
+// posts and passing them to fn.
+func NewInboundWebhookHandler(fn InboundWebhookHandlerFunc, opts *InboundWebhookOptions) *InboundWebhookHandler {
+	h := &InboundWebhookHandler{handle: fn}
+	if opts != nil {
+		h.opts = *opts
+	}
+	if h.opts.MaxBodyBytes <= 0 {
+		h.opts.MaxBodyBytes = defaultInboundMaxBodyBytes
+	}
+	return h
+}
+
+func (h *InboundWebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var payload InboundWebhookPayload
+	body := http.MaxBytesReader(w, r.Body, h.opts.MaxBodyBytes)
+	if err := json.NewDecoder(body).Decode(&payload); err != nil {
+		http.Error(w, fmt.Sprintf("invalid inbound payload: %v", err), http.StatusBadRequest)
+		return
+	}
+	if h.opts.ReplyAddresses != nil {
+		reply, err := h.replyContext(r.Context(), &payload.Mail)
+		if err != nil && !errors.Is(err, ErrInvalidReplyAddress) {
+			// The store of the reply contexts failed.
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		payload.Reply = reply
+	}
+
+	if err := h.handle(r.Context(), &payload); err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// replyContext decodes the first recipient on the domain of the reply
+// addresses.
+func (h *InboundWebhookHandler) replyContext(ctx context.Context, m *InboundEmail) (*ReplyContext, error) {
+	signer := h.opts.ReplyAddresses
+	for _, recipient := range append(append([]InboundEmailAddress{}, m.To...), m.Cc...) {
+		if strings.HasSuffix(strings.ToLower(recipient.Address), "@"+signer.domain) {
+			return signer.Parse(ctx, recipient.Address)
+		}
+	}
+	return nil, errors.Wrapf(ErrInvalidReplyAddress, "no recipient on %s", signer.domain)
+}
+
+// InboundWebhookPayload is the body of an inbound parse webhook post: the
+// parsed email and the notification it replies to.
+type InboundWebhookPayload struct {
+	TransactionId      string                 `json:"transactionId"`
+	TemplateIdentifier string                 `json:"templateIdentifier"`
+	Template           *Workflow              `json:"template,omitempty"`
+	Notification       map[string]interface{} `json:"notification,omitempty"`
+	Subscriber         *InboundSubscriber     `json:"subscriber,omitempty"`
+	Environment        *InboundEnvironment    `json:"environment,omitempty"`
+	Mail               InboundEmail           `json:"mail"`
+	// Reply is decoded from the reply address when the handler verifies them.
+	Reply *ReplyContext `json:"-"`
+}
+
+type InboundSubscriber struct {
+	Id string `json:"_id"`
+	SubscriberPayload
+}
+
+type InboundEnvironment struct {
+	Id   string `json:"_id"`
+	Name string `json:"name"`
+}
+
+type InboundEmail struct {
+	MessageId   string                   `json:"messageId"`
+	Subject     string                   `json:"subject"`
+	HTML        string                   `json:"html"`
+	Text        string                   `json:"text"`
+	From        []InboundEmailAddress    `json:"from"`
+	To          []InboundEmailAddress    `json:"to"`
+	Cc          []InboundEmailAddress    `json:"cc,omitempty"`
+	Headers     map[string]interface{}   `json:"headers,omitempty"`
+	Attachments []InboundEmailAttachment `json:"attachments,omitempty"`
+	Date        string                   `json:"date,omitempty"`
+	Priority    string                   `json:"priority,omitempty"`
+	Dkim        string                   `json:"dkim,omitempty"`
+	Spf         string                   `json:"spf,omitempty"`
+	SpamScore   float64                  `json:"spamScore,omitempty"`
+	Language    string                   `json:"language,omitempty"`
+}
+
+type InboundEmailAddress struct {
+	Address string `json:"address"`
+	Name    string `json:"name,omitempty"`
+}
+
+type InboundEmailAttachment struct {
+	FileName           string            `json:"fileName"`
+	GeneratedFileName  string            `json:"generatedFileName,omitempty"`
+	ContentType        string            `json:"contentType"`
+	ContentDisposition string            `json:"contentDisposition,omitempty"`
+	ContentId          string            `json:"contentId,omitempty"`
+	Checksum           string            `json:"checksum,omitempty"`
+	Length             int               `json:"length"`
+	Content            AttachmentContent `json:"content,omitempty"`
+}
+
+// AttachmentContent holds the bytes of an attachment. It decodes both base64
+// strings and serialized Node.js buffers.
+type AttachmentContent []byte
+
+func (c *AttachmentContent) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		bb, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return err
+		}
+		*c = bb
+		return nil
+	}
+
+	var buffer struct {
+		Type string `json:"type"`
+		Data []int  `json:"data"`
+	}
+	if err := json.Unmarshal(data, &buffer); err != nil {
+		return err
+	}
+	bb := make([]byte, len(buffer.Data))
+	for i, b := range buffer.Data {
+		bb[i] = byte(b)
+	}
+	*c = bb
+	return nil
+}
+
+func (c AttachmentContent) MarshalJSON() ([]byte, error) {
+	return json.Marshal(base64.StdEncoding.EncodeToString(c))
+}
+
+// Header returns the first value of the header with the given name, ignoring
+// its case.
+func (m *InboundEmail) Header(name string) string {
+	for k, v := range m.Headers {
+		if !strings.EqualFold(k, name) {
+			continue
+		}
+		switch v := v.(type) {
+		case string:
+			return v
+		case []interface{}:
+			if len(v) > 0 {
+				return fmt.Sprint(v[0])
+			}
+		}
+	}
+	return ""
+}
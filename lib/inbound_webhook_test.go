@@ -0,0 +1,86 @@
+package lib_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/novuhq/go-novu/lib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func postInbound(t *testing.T, h http.Handler, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/novu/inbound", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestInboundWebhookHandler_Success(t *testing.T) {
+	body, err := os.ReadFile(filepath.Join("../testdata", "inbound_webhook_payload.json"))
+	require.NoError(t, err)
+
+	var received *lib.InboundWebhookPayload
+	h := lib.NewInboundWebhookHandler(func(ctx context.Context, payload *lib.InboundWebhookPayload) error {
+		received = payload
+		return nil
+	}, nil)
+
+	rec := postInbound(t, h, string(body))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NotNil(t, received)
+
+	assert.Equal(t, "tx-123", received.TransactionId)
+	assert.Equal(t, "comment-reply", received.TemplateIdentifier)
+	assert.Equal(t, "workflow1", received.Template.Id)
+	assert.Equal(t, "sub-1", received.Subscriber.SubscriberId)
+	assert.Equal(t, "64f0c1e2a1b2c3d4e5f60718", received.Subscriber.Id)
+	assert.Equal(t, "Production", received.Environment.Name)
+
+	mail := received.Mail
+	assert.Equal(t, "Re: New comment on Docs", mail.Subject)
+	assert.Equal(t, "Sounds good!", mail.Text)
+	assert.Equal(t, []lib.InboundEmailAddress{{Address: "jane@example.com", Name: "Jane"}}, mail.From)
+	assert.Equal(t, "parse+tx-123@inbound.example.com", mail.To[0].Address)
+	assert.Equal(t, "<notification1@novu.co>", mail.Header("In-Reply-To"))
+	assert.Equal(t, "<notification1@novu.co>", mail.Header("references"))
+	assert.Equal(t, "", mail.Header("x-missing"))
+
+	require.Len(t, mail.Attachments, 2)
+	assert.Equal(t, "hello", string(mail.Attachments[0].Content))
+	assert.Equal(t, []byte{1, 2, 3}, []byte(mail.Attachments[1].Content))
+}
+
+func TestInboundWebhookHandler_Errors(t *testing.T) {
+	h := lib.NewInboundWebhookHandler(func(ctx context.Context, payload *lib.InboundWebhookPayload) error {
+		return errors.New("unknown thread")
+	}, &lib.InboundWebhookOptions{MaxBodyBytes: 64})
+
+	t.Run("Only POST is allowed", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/novu/inbound", http.NoBody)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	})
+
+	t.Run("Invalid JSON is rejected", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, postInbound(t, h, "{").Code)
+	})
+
+	t.Run("Large bodies are rejected", func(t *testing.T) {
+		body := `{"mail": {"text": "` + strings.Repeat("a", 100) + `"}}`
+		assert.Equal(t, http.StatusBadRequest, postInbound(t, h, body).Code)
+	})
+
+	t.Run("Callback errors are server errors", func(t *testing.T) {
+		rec := postInbound(t, h, `{"transactionId": "tx"}`)
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		assert.Equal(t, "Internal Server Error\n", rec.Body.String())
+	})
+}
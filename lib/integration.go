@@ -5,8 +5,13 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
 )
 
+const tenantConditionOn = "tenant"
+
 type IIntegration interface {
 	Create(ctx context.Context, request CreateIntegrationRequest) (*IntegrationResponse, error)
 	GetAll(ctx context.Context) (*GetIntegrationsResponse, error)
@@ -16,6 +21,8 @@ type IIntegration interface {
 	Delete(ctx context.Context, integrationId string) (*IntegrationResponse, error)
 	SetIntegrationAsPrimary(ctx context.Context, integrationId string) (*SetIntegrationAsPrimaryResponse, error)
 	GetChannelLimit(ctx context.Context, channelType string) (*IntegrationChannelLimitResponse, error)
+	ListForTenant(ctx context.Context, tenantIdentifier string) ([]Integration, error)
+	ResolveForChannel(ctx context.Context, channel ChannelType, tenantIdentifier string) (*Integration, error)
 }
 
 type IntegrationService service
@@ -24,12 +31,25 @@ func (i IntegrationService) Create(ctx context.Context, request CreateIntegratio
 	var response IntegrationResponse
 	URL := i.client.config.BackendURL.JoinPath("integrations")
 
+	if request.Channel == "" {
+		if provider, ok := GetProvider(ProviderIdType(request.ProviderID)); ok {
+			request.Channel = provider.Channel
+		}
+	}
+	if err := validateProviderChannel(ProviderIdType(request.ProviderID), request.Channel); err != nil {
+		return nil, err
+	}
+
 	requestBody := CreateIntegrationRequest{
-		ProviderID:  request.ProviderID,
-		Channel:     request.Channel,
-		Credentials: request.Credentials,
-		Active:      request.Active,
-		Check:       request.Check,
+		Name:          request.Name,
+		Identifier:    request.Identifier,
+		EnvironmentID: request.EnvironmentID,
+		ProviderID:    request.ProviderID,
+		Channel:       request.Channel,
+		Credentials:   request.Credentials,
+		Active:        request.Active,
+		Check:         request.Check,
+		Conditions:    request.Conditions,
 	}
 
 	jsonBody, _ := json.Marshal(requestBody)
@@ -110,9 +130,13 @@ func (i IntegrationService) Update(ctx context.Context, integrationId string, re
 	URL := i.client.config.BackendURL.JoinPath("integrations", integrationId)
 
 	requestBody := UpdateIntegrationRequest{
-		Credentials: request.Credentials,
-		Active:      request.Active,
-		Check:       request.Check,
+		Name:          request.Name,
+		Identifier:    request.Identifier,
+		EnvironmentID: request.EnvironmentID,
+		Credentials:   request.Credentials,
+		Active:        request.Active,
+		Check:         request.Check,
+		Conditions:    request.Conditions,
 	}
 
 	jsonBody, _ := json.Marshal(requestBody)
@@ -186,3 +210,158 @@ func (i IntegrationService) GetChannelLimit(ctx context.Context, channelType str
 
 	return &response, nil
 }
+
+// TenantCondition returns a condition matching any of the given tenants.
+func TenantCondition(tenantIdentifiers ...string) IntegrationCondition {
+	condition := IntegrationCondition{Type: "BOOLEAN", Value: "OR"}
+	for _, identifier := range tenantIdentifiers {
+		condition.Children = append(condition.Children, IntegrationConditionChild{
+			Field:    "identifier",
+			Value:    identifier,
+			Operator: IntegrationConditionEqual,
+			On:       tenantConditionOn,
+		})
+	}
+	return condition
+}
+
+// IntegrationConditions returns the conditions to set with
+// UpdateIntegrationRequest. Without arguments, it removes all conditions.
+func IntegrationConditions(conditions ...IntegrationCondition) *[]IntegrationCondition {
+	list := append([]IntegrationCondition{}, conditions...)
+	return &list
+}
+
+// ListForTenant returns the integrations whose conditions select the tenant.
+func (i IntegrationService) ListForTenant(ctx context.Context, tenantIdentifier string) ([]Integration, error) {
+	all, err := i.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	integrations := []Integration{}
+	for _, integration := range all.Data {
+		if integration.MatchesTenant(tenantIdentifier) {
+			integrations = append(integrations, integration)
+		}
+	}
+
+	return integrations, nil
+}
+
+// ResolveForChannel returns the integration Novu would use to deliver on channel.
+// Active integrations whose conditions select the tenant come first, then the
+// primary integration of the channel and finally any active one without conditions.
+// Among several candidates of a kind, the one with the highest priority wins.
+func (i IntegrationService) ResolveForChannel(ctx context.Context, channel ChannelType, tenantIdentifier string) (*Integration, error) {
+	active, err := i.GetActive(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var conditional, primary, fallback *Integration
+	for idx := range active.Data {
+		integration := &active.Data[idx]
+		if integration.Channel != channel || !integration.Active {
+			continue
+		}
+		if len(integration.Conditions) > 0 {
+			if tenantIdentifier != "" && integration.MatchesTenant(tenantIdentifier) &&
+				(conditional == nil || integration.Priority > conditional.Priority) {
+				conditional = integration
+			}
+			continue
+		}
+		if integration.Primary && primary == nil {
+			primary = integration
+		}
+		if fallback == nil || integration.Priority > fallback.Priority {
+			fallback = integration
+		}
+	}
+
+	if conditional != nil {
+		return conditional, nil
+	}
+	if primary != nil {
+		return primary, nil
+	}
+	if fallback != nil {
+		return fallback, nil
+	}
+
+	return nil, errors.Errorf("no active %s integration found", channel)
+}
+
+// MatchesTenant reports whether the conditions of the integration select the
+// tenant. Integrations without tenant conditions never match, nor do those
+// whose conditions also depend on other fields, such as the payload, unless
+// the tenant alone decides them.
+func (i Integration) MatchesTenant(tenantIdentifier string) bool {
+	if len(i.Conditions) == 0 {
+		return false
+	}
+
+	for _, condition := range i.Conditions {
+		if !condition.matchesTenant(tenantIdentifier) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (c IntegrationCondition) matchesTenant(tenantIdentifier string) bool {
+	isOr := strings.EqualFold(c.Value, "OR")
+	matched := !isOr
+
+	tenantChildren, otherChildren := 0, 0
+	for _, child := range c.Children {
+		if child.On != tenantConditionOn || child.Field != "identifier" {
+			otherChildren++
+			continue
+		}
+		tenantChildren++
+
+		ok := child.matches(tenantIdentifier)
+		if isOr && ok {
+			matched = true
+		}
+		if !isOr && !ok {
+			matched = false
+		}
+	}
+
+	if tenantChildren == 0 {
+		return false
+	}
+	// The other children decide the groups the tenant children do not:
+	// an OR group none of them satisfies, or an AND group all of them do.
+	if otherChildren > 0 && matched != isOr {
+		return false
+	}
+	if c.IsNegated {
+		return !matched
+	}
+	return matched
+}
+
+func (c IntegrationConditionChild) matches(value string) bool {
+	switch c.Operator {
+	case IntegrationConditionEqual:
+		return c.Value == value
+	case IntegrationConditionNotEqual:
+		return c.Value != value
+	case IntegrationConditionIn, IntegrationConditionNotIn:
+		found := false
+		for _, v := range strings.Split(c.Value, ",") {
+			if strings.TrimSpace(v) == value {
+				found = true
+				break
+			}
+		}
+		return found == (c.Operator == IntegrationConditionIn)
+	default:
+		return false
+	}
+}
@@ -306,3 +306,169 @@ func TestGetChannelLimit_Success(t *testing.T) {
 	assert.Equal(t, response, res)
 	require.NoError(t, err)
 }
+
+func TestCreateIntegration_WithTenantConditions(t *testing.T) {
+	createIntegrationRequest := lib.CreateIntegrationRequest{
+		Name:       "Acme SMTP",
+		Identifier: "acme-smtp",
+		ProviderID: "nodemailer",
+		Channel:    lib.EMAIL,
+		Credentials: lib.IntegrationCredentials{
+			Host: "smtp.acme.test",
+			Port: "587",
+		},
+		Active:     true,
+		Conditions: []lib.IntegrationCondition{lib.TenantCondition("acme")},
+	}
+
+	httpServer := IntegrationTestServer(t, IntegrationServerOptions[lib.CreateIntegrationRequest]{
+		ExpectedRequest: IntegrationRequestDetails[lib.CreateIntegrationRequest]{
+			Url:    "/v1/integrations",
+			Method: http.MethodPost,
+			Body:   createIntegrationRequest,
+		},
+		ExpectedResponse: IntegrationResponseDetails{
+			StatusCode: http.StatusCreated,
+			Body:       &lib.IntegrationResponse{},
+		},
+	})
+
+	novuClient := lib.NewAPIClient(novuApiKey, &lib.Config{BackendURL: lib.MustParseURL(httpServer.URL)})
+	_, err := novuClient.IntegrationsApi.Create(context.Background(), createIntegrationRequest)
+	require.NoError(t, err)
+}
+
+func TestListIntegrationsForTenant_Success(t *testing.T) {
+	var response *lib.GetIntegrationsResponse
+	fileToStruct(filepath.Join("../testdata", "get_tenant_integrations_response.json"), &response)
+
+	httpServer := IntegrationTestServer(t, IntegrationServerOptions[interface{}]{
+		ExpectedRequest: IntegrationRequestDetails[interface{}]{
+			Url:    "/v1/integrations",
+			Method: http.MethodGet,
+		},
+		ExpectedResponse: IntegrationResponseDetails{
+			StatusCode: http.StatusOK,
+			Body:       response,
+		},
+	})
+
+	novuClient := lib.NewAPIClient(novuApiKey, &lib.Config{BackendURL: lib.MustParseURL(httpServer.URL)})
+
+	integrations, err := novuClient.IntegrationsApi.ListForTenant(context.Background(), "acme-eu")
+	require.NoError(t, err)
+	require.Len(t, integrations, 1)
+	assert.Equal(t, "acme-smtp", integrations[0].Identifier)
+
+	integrations, err = novuClient.IntegrationsApi.ListForTenant(context.Background(), "globex")
+	require.NoError(t, err)
+	assert.Empty(t, integrations)
+}
+
+func TestIntegration_MatchesTenant(t *testing.T) {
+	tenant := lib.IntegrationConditionChild{Field: "identifier", Value: "acme", Operator: lib.IntegrationConditionEqual, On: "tenant"}
+	plan := lib.IntegrationConditionChild{Field: "plan", Value: "pro", Operator: lib.IntegrationConditionEqual, On: "payload"}
+	tests := []struct {
+		name      string
+		condition lib.IntegrationCondition
+		matches   bool
+	}{
+		{"Tenant", lib.TenantCondition("globex", "acme"), true},
+		{"Other tenant", lib.TenantCondition("globex"), false},
+		{"AND with a payload field", lib.IntegrationCondition{Value: "AND", Children: []lib.IntegrationConditionChild{tenant, plan}}, false},
+		{"OR with a payload field", lib.IntegrationCondition{Value: "OR", Children: []lib.IntegrationConditionChild{tenant, plan}}, true},
+		{"OR of another tenant with a payload field", lib.IntegrationCondition{Value: "OR", Children: []lib.IntegrationConditionChild{plan, {Field: "identifier", Value: "globex", Operator: lib.IntegrationConditionEqual, On: "tenant"}}}, false},
+		{"Negated AND of another tenant with a payload field", lib.IntegrationCondition{Value: "AND", IsNegated: true, Children: []lib.IntegrationConditionChild{plan, {Field: "identifier", Value: "globex", Operator: lib.IntegrationConditionEqual, On: "tenant"}}}, true},
+		{"Payload field only", lib.IntegrationCondition{Value: "AND", Children: []lib.IntegrationConditionChild{plan}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			integration := lib.Integration{Conditions: []lib.IntegrationCondition{tt.condition}}
+			assert.Equal(t, tt.matches, integration.MatchesTenant("acme"))
+		})
+	}
+}
+
+func TestResolveIntegrationForChannel_Success(t *testing.T) {
+	var response *lib.GetIntegrationsResponse
+	fileToStruct(filepath.Join("../testdata", "get_tenant_integrations_response.json"), &response)
+
+	httpServer := IntegrationTestServer(t, IntegrationServerOptions[interface{}]{
+		ExpectedRequest: IntegrationRequestDetails[interface{}]{
+			Url:    "/v1/integrations/active",
+			Method: http.MethodGet,
+		},
+		ExpectedResponse: IntegrationResponseDetails{
+			StatusCode: http.StatusOK,
+			Body:       response,
+		},
+	})
+
+	ctx := context.Background()
+	novuClient := lib.NewAPIClient(novuApiKey, &lib.Config{BackendURL: lib.MustParseURL(httpServer.URL)})
+
+	tests := map[string]struct {
+		channel  lib.ChannelType
+		tenant   string
+		expected string
+	}{
+		"tenant specific":       {channel: lib.EMAIL, tenant: "acme", expected: "acme-smtp"},
+		"other tenant":          {channel: lib.EMAIL, tenant: "globex", expected: "sendgrid-default"},
+		"no tenant":             {channel: lib.EMAIL, expected: "sendgrid-default"},
+		"channel without rules": {channel: lib.SMS, tenant: "acme", expected: "twilio"},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			integration, err := novuClient.IntegrationsApi.ResolveForChannel(ctx, tc.channel, tc.tenant)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, integration.Identifier)
+		})
+	}
+
+	_, err := novuClient.IntegrationsApi.ResolveForChannel(ctx, lib.PUSH, "acme")
+	assert.Error(t, err)
+}
+
+func TestResolveIntegrationForChannel_Priority(t *testing.T) {
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(`{"data": [
+			{"identifier": "acme-low", "channel": "email", "active": true, "priority": 1,
+			 "conditions": [{"value": "OR", "children": [{"field": "identifier", "value": "acme", "operator": "EQUAL", "on": "tenant"}]}]},
+			{"identifier": "acme-high", "channel": "email", "active": true, "priority": 2,
+			 "conditions": [{"value": "OR", "children": [{"field": "identifier", "value": "acme", "operator": "EQUAL", "on": "tenant"}]}]},
+			{"identifier": "default", "channel": "email", "active": true, "primary": true}
+		]}`))
+	}))
+	defer httpServer.Close()
+
+	novuClient := lib.NewAPIClient(novuApiKey, &lib.Config{BackendURL: lib.MustParseURL(httpServer.URL)})
+	integration, err := novuClient.IntegrationsApi.ResolveForChannel(context.Background(), lib.EMAIL, "acme")
+	require.NoError(t, err)
+	assert.Equal(t, "acme-high", integration.Identifier)
+}
+
+func TestUpdateIntegrationRequest_Conditions(t *testing.T) {
+	bb, err := json.Marshal(lib.UpdateIntegrationRequest{})
+	require.NoError(t, err)
+	assert.NotContains(t, string(bb), "conditions")
+
+	bb, err = json.Marshal(lib.UpdateIntegrationRequest{Conditions: lib.IntegrationConditions()})
+	require.NoError(t, err)
+	assert.Contains(t, string(bb), `"conditions":[]`)
+}
+
+func TestIntegrationConditions_MatchesTenant(t *testing.T) {
+	negated := lib.TenantCondition("acme")
+	negated.IsNegated = true
+
+	assert.False(t, lib.Integration{}.MatchesTenant("acme"))
+	assert.True(t, lib.Integration{Conditions: []lib.IntegrationCondition{lib.TenantCondition("acme", "globex")}}.MatchesTenant("globex"))
+	assert.False(t, lib.Integration{Conditions: []lib.IntegrationCondition{negated}}.MatchesTenant("acme"))
+	assert.True(t, lib.Integration{Conditions: []lib.IntegrationCondition{negated}}.MatchesTenant("globex"))
+	assert.True(t, lib.Integration{Conditions: []lib.IntegrationCondition{{
+		Value: "AND",
+		Children: []lib.IntegrationConditionChild{
+			{Field: "identifier", Value: "acme, globex", Operator: lib.IntegrationConditionIn, On: "tenant"},
+		},
+	}}}.MatchesTenant("globex"))
+}
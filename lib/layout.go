@@ -5,6 +5,8 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
+
+	"github.com/pkg/errors"
 )
 
 type LayoutService service
@@ -56,6 +58,27 @@ func (l *LayoutService) List(ctx context.Context, options *LayoutRequestOptions)
 	return &resp, nil
 }
 
+// IterateLayouts calls fn for every layout of the environment, fetching limit
+// layouts per page. Iteration stops at the first error returned by fn.
+func (l *LayoutService) IterateLayouts(ctx context.Context, limit int, fn func(layout LayoutResponse) error) error {
+	seen := 0
+	for page := 0; ; page++ {
+		resp, err := l.List(ctx, &LayoutRequestOptions{Page: &page, PageSize: &limit})
+		if err != nil {
+			return errors.Wrapf(err, "unable to list layouts page %d", page)
+		}
+		for _, layout := range resp.Data {
+			if err := fn(layout); err != nil {
+				return err
+			}
+		}
+		seen += len(resp.Data)
+		if lastPage(len(resp.Data), seen, resp.TotalCount, limit) {
+			return nil
+		}
+	}
+}
+
 func (l *LayoutService) Get(ctx context.Context, key string) (*LayoutResponse, error) {
 	var resp LayoutResponse
 	URL := l.client.config.BackendURL.JoinPath("layouts", key)
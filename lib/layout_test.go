@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/novuhq/go-novu/lib"
@@ -205,3 +206,28 @@ func TestLayoutService_Layout_SetDefault(t *testing.T) {
 
 	require.NoError(t, err)
 }
+
+func TestLayoutService_IterateLayouts(t *testing.T) {
+	// The server caps pages at 2 layouts, below the requested limit.
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var options lib.LayoutRequestOptions
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&options))
+		resp := lib.LayoutsResponse{TotalCount: 5, PageSize: 2, Page: *options.Page}
+		for i := *options.Page * 2; i < 5 && i < (*options.Page+1)*2; i++ {
+			resp.Data = append(resp.Data, lib.LayoutResponse{Identifier: fmt.Sprintf("layout-%d", i)})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer httpServer.Close()
+
+	c := lib.NewAPIClient(novuApiKey, &lib.Config{BackendURL: lib.MustParseURL(httpServer.URL)})
+	var identifiers []string
+	err := c.LayoutApi.IterateLayouts(context.Background(), 10, func(layout lib.LayoutResponse) error {
+		identifiers = append(identifiers, layout.Identifier)
+		return nil
+	})
+
+	require.NoError(t, err)
+	require.Equal(t, []string{"layout-0", "layout-1", "layout-2", "layout-3", "layout-4"}, identifiers)
+}
@@ -2,11 +2,17 @@ package lib
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"net/url"
 	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
 )
 
+const defaultRetentionPageSize = 100
+
 type MessagesService service
 
 func (e *MessagesService) GetMessages(ctx context.Context, q QueryBuilder) (JsonResponse, error) {
@@ -38,6 +44,96 @@ func (e *MessagesService) DeleteMessage(ctx context.Context, messageId string) (
 	return resp, nil
 }
 
+func (e *MessagesService) ListMessages(ctx context.Context, q MessagesQueryParams) (*MessagesResponse, error) {
+	var resp MessagesResponse
+	URL := e.client.config.BackendURL.JoinPath("messages")
+	URL.RawQuery = q.BuildQuery()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, URL.String(), http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+	_, err = e.client.sendRequest(req, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (e *MessagesService) DeleteMessagesByTransactionId(ctx context.Context, transactionId string, channel ChannelType) error {
+	var resp JsonResponse
+	URL := e.client.config.BackendURL.JoinPath("messages", "transaction", transactionId)
+	if channel != "" {
+		URL.RawQuery = url.Values{"channel": []string{string(channel)}}.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, URL.String(), http.NoBody)
+	if err != nil {
+		return err
+	}
+	_, err = e.client.sendRequest(req, &resp)
+	return err
+}
+
+// ApplyRetention deletes the messages of a subscriber or channel created before
+// opts.OlderThan. All pages are scanned before anything is deleted so that
+// deletions do not shift the pages still to be read.
+func (e *MessagesService) ApplyRetention(ctx context.Context, opts MessageRetentionOptions) (*MessageRetentionResult, error) {
+	if opts.SubscriberId == "" && opts.Channel == "" {
+		return nil, errors.New("retention requires a subscriber id or a channel")
+	}
+	if opts.OlderThan.IsZero() {
+		return nil, errors.New("retention requires a cut-off time")
+	}
+	if opts.PageSize <= 0 {
+		opts.PageSize = defaultRetentionPageSize
+	}
+
+	result := &MessageRetentionResult{}
+	q := MessagesQueryParams{
+		SubscriberId: opts.SubscriberId,
+		Channel:      string(opts.Channel),
+		Limit:        opts.PageSize,
+	}
+	for {
+		page, err := e.ListMessages(ctx, q)
+		if err != nil {
+			return result, errors.Wrapf(err, "unable to list messages page %d", q.Page)
+		}
+		result.Scanned += len(page.Data)
+		for _, message := range page.Data {
+			if message.CreatedAt.Before(opts.OlderThan) {
+				result.Expired = append(result.Expired, message)
+			}
+		}
+		// Novu may return fewer messages than the limit, which it caps.
+		if !page.HasMore || len(page.Data) == 0 {
+			break
+		}
+		q.Page++
+	}
+
+	for _, message := range result.Expired {
+		if opts.DryRun {
+			e.writeRetentionLine(opts, "would delete", message)
+			continue
+		}
+		if _, err := e.DeleteMessage(ctx, message.ID); err != nil {
+			return result, errors.Wrapf(err, "unable to delete message %s", message.ID)
+		}
+		result.Deleted = append(result.Deleted, message.ID)
+		e.writeRetentionLine(opts, "deleted", message)
+	}
+
+	return result, nil
+}
+
+func (e *MessagesService) writeRetentionLine(opts MessageRetentionOptions, action string, message Message) {
+	if opts.Output == nil {
+		return
+	}
+	fmt.Fprintf(opts.Output, "%s %s channel=%s subscriber=%s created=%s\n",
+		action, message.ID, message.Channel, message.SubscriberID, message.CreatedAt.Format(time.RFC3339))
+}
+
 func (q MessagesQueryParams) BuildQuery() string {
 	params := url.Values{}
 	if q.Channel != "" {
@@ -1,10 +1,16 @@
 package lib_test
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/novuhq/go-novu/lib"
 )
@@ -183,3 +189,142 @@ func TestDeleteMessage(t *testing.T) {
 		t.Error("Expected response, got none")
 	}
 }
+
+func TestDeleteMessagesByTransactionId(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			t.Errorf("Want DELETE, got %s", r.Method)
+		}
+		expected := "/v1/messages/transaction/TransactionId?channel=email"
+		if r.URL.String() != expected {
+			t.Errorf("Want %s, got %s", expected, r.URL.String())
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+	c := lib.NewAPIClient(novuApiKey, &lib.Config{BackendURL: lib.MustParseURL(server.URL)})
+	err := c.MessagesApi.DeleteMessagesByTransactionId(context.Background(), "TransactionId", lib.EMAIL)
+	if err != nil {
+		t.Errorf("Error should be nil, got %v", err)
+	}
+}
+
+// retentionServer lists messages by pages of at most 2 messages, whatever the
+// limit requested.
+func retentionServer(t *testing.T, messages []lib.Message, deleted *[]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			if got := r.URL.Query().Get("subscriberId"); got != "subId" {
+				t.Errorf("Want subscriberId subId, got %s", got)
+			}
+			page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+			limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+			if limit > 2 {
+				limit = 2
+			}
+			resp := lib.MessagesResponse{Page: page, PageSize: limit, Data: []lib.Message{}, HasMore: (page+1)*limit < len(messages)}
+			for i := page * limit; i < len(messages) && i < (page+1)*limit; i++ {
+				resp.Data = append(resp.Data, messages[i])
+			}
+			bb, _ := json.Marshal(resp)
+			w.Write(bb)
+		case http.MethodDelete:
+			*deleted = append(*deleted, strings.TrimPrefix(r.URL.Path, "/v1/messages/"))
+			w.Write([]byte(messagesDeleteResponse))
+		default:
+			t.Errorf("Unexpected method %s", r.Method)
+		}
+	}))
+}
+
+func TestApplyRetention(t *testing.T) {
+	cutOff := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+	var messages []lib.Message
+	for i := 0; i < 5; i++ {
+		messages = append(messages, lib.Message{
+			ID:        fmt.Sprintf("m%d", i),
+			Channel:   lib.IN_APP,
+			CreatedAt: cutOff.AddDate(0, 0, 2*i-5),
+		})
+	}
+
+	t.Run("dry run", func(t *testing.T) {
+		var deleted []string
+		server := retentionServer(t, messages, &deleted)
+		defer server.Close()
+		c := lib.NewAPIClient(novuApiKey, &lib.Config{BackendURL: lib.MustParseURL(server.URL)})
+
+		var out bytes.Buffer
+		result, err := c.MessagesApi.ApplyRetention(context.Background(), lib.MessageRetentionOptions{
+			SubscriberId: "subId",
+			OlderThan:    cutOff,
+			DryRun:       true,
+			PageSize:     2,
+			Output:       &out,
+		})
+		if err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+		if result.Scanned != 5 {
+			t.Errorf("Want 5 scanned, got %d", result.Scanned)
+		}
+		if len(result.Expired) != 3 {
+			t.Errorf("Want 3 expired, got %d", len(result.Expired))
+		}
+		if len(deleted) != 0 || len(result.Deleted) != 0 {
+			t.Errorf("Dry run must not delete, deleted %v", deleted)
+		}
+		if !strings.HasPrefix(out.String(), "would delete m0 channel=in_app") {
+			t.Errorf("Unexpected dry run output %q", out.String())
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		var deleted []string
+		server := retentionServer(t, messages, &deleted)
+		defer server.Close()
+		c := lib.NewAPIClient(novuApiKey, &lib.Config{BackendURL: lib.MustParseURL(server.URL)})
+
+		result, err := c.MessagesApi.ApplyRetention(context.Background(), lib.MessageRetentionOptions{
+			SubscriberId: "subId",
+			OlderThan:    cutOff,
+			PageSize:     2,
+		})
+		if err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+		want := []string{"m0", "m1", "m2"}
+		if fmt.Sprint(deleted) != fmt.Sprint(want) || fmt.Sprint(result.Deleted) != fmt.Sprint(want) {
+			t.Errorf("Want %v deleted, got %v and %v", want, deleted, result.Deleted)
+		}
+	})
+
+	t.Run("pages shorter than the limit", func(t *testing.T) {
+		var deleted []string
+		server := retentionServer(t, messages, &deleted)
+		defer server.Close()
+		c := lib.NewAPIClient(novuApiKey, &lib.Config{BackendURL: lib.MustParseURL(server.URL)})
+
+		result, err := c.MessagesApi.ApplyRetention(context.Background(), lib.MessageRetentionOptions{
+			SubscriberId: "subId",
+			OlderThan:    cutOff,
+			DryRun:       true,
+			PageSize:     10,
+		})
+		if err != nil {
+			t.Fatalf("Error should be nil, got %v", err)
+		}
+		if result.Scanned != 5 {
+			t.Errorf("Want 5 scanned, got %d", result.Scanned)
+		}
+	})
+
+	t.Run("requires a scope", func(t *testing.T) {
+		c := lib.NewAPIClient(novuApiKey, &lib.Config{})
+		_, err := c.MessagesApi.ApplyRetention(context.Background(), lib.MessageRetentionOptions{OlderThan: cutOff})
+		if err == nil {
+			t.Error("Expected an error without subscriber or channel")
+		}
+	})
+}
@@ -1,6 +1,7 @@
 package lib
 
 import (
+	"encoding/json"
 	"io"
 	"time"
 )
@@ -8,6 +9,7 @@ import (
 type ChannelType string
 type GeneralError error
 type ProviderIdType string
+type PreferenceLevel string
 
 const Version = "v1"
 
@@ -19,21 +21,19 @@ const (
 )
 
 const (
-	EMAIL  ChannelType = "EMAIL"
-	SMS    ChannelType = "SMS"
+	EMAIL  ChannelType = "email"
+	SMS    ChannelType = "sms"
+	IN_APP ChannelType = "in_app"
+	CHAT   ChannelType = "chat"
+	PUSH   ChannelType = "push"
+
+	// Deprecated: DIRECT is not a Novu channel and is rejected by the API.
 	DIRECT ChannelType = "DIRECT"
 )
 
 const (
-	slack       ProviderIdType = "slack"
-	discord     ProviderIdType = "discord"
-	msteams     ProviderIdType = "msteams"
-	mattermost  ProviderIdType = "mattermost"
-	fcm         ProviderIdType = "fcm"
-	apns        ProviderIdType = "apns"
-	expo        ProviderIdType = "expo"
-	oneSignal   ProviderIdType = "one-signal"
-	pushWebhook ProviderIdType = "push-webhook"
+	PreferenceLevelGlobal   PreferenceLevel = "global"
+	PreferenceLevelTemplate PreferenceLevel = "template"
 )
 
 type Data struct {
@@ -46,11 +46,12 @@ type Response struct {
 }
 
 type ITriggerPayloadOptions struct {
-	To            interface{} `json:"to,omitempty"`
-	Payload       interface{} `json:"payload,omitempty"`
-	Overrides     interface{} `json:"overrides,omitempty"`
-	TransactionId string      `json:"transactionId,omitempty"`
-	Actor         interface{} `json:"actor,omitempty"`
+	To            interface{}    `json:"to,omitempty"`
+	Payload       interface{}    `json:"payload,omitempty"`
+	Overrides     interface{}    `json:"overrides,omitempty"`
+	TransactionId string         `json:"transactionId,omitempty"`
+	Actor         interface{}    `json:"actor,omitempty"`
+	Tenant        *TriggerTenant `json:"tenant,omitempty"`
 }
 
 type TriggerRecipientsTypeArray interface {
@@ -76,6 +77,23 @@ type SubscriberPayload struct {
 	SubscriberId string                 `json:"subscriberId"`
 }
 
+// Subscriber is a subscriber as listed by the API.
+type Subscriber struct {
+	Id string `json:"_id"`
+	SubscriberPayload
+	Channels  []SubscriberCredentialPayload `json:"channels,omitempty"`
+	CreatedAt string                        `json:"createdAt,omitempty"`
+	UpdatedAt string                        `json:"updatedAt,omitempty"`
+}
+
+type SubscribersResponse struct {
+	Data       []Subscriber `json:"data"`
+	Page       int          `json:"page"`
+	PageSize   int          `json:"pageSize"`
+	TotalCount int          `json:"totalCount"`
+	HasMore    bool         `json:"hasMore"`
+}
+
 type SubscriberBulkPayload struct {
 	Subscribers []SubscriberPayload `json:"subscribers"`
 }
@@ -107,12 +125,13 @@ type EventResponse struct {
 }
 
 type EventRequest struct {
-	Name          string      `json:"name"`
-	To            interface{} `json:"to"`
-	Payload       interface{} `json:"payload"`
-	Overrides     interface{} `json:"overrides,omitempty"`
-	TransactionId string      `json:"transactionId,omitempty"`
-	Actor         interface{} `json:"actor,omitempty"`
+	Name          string         `json:"name"`
+	To            interface{}    `json:"to"`
+	Payload       interface{}    `json:"payload"`
+	Overrides     interface{}    `json:"overrides,omitempty"`
+	TransactionId string         `json:"transactionId,omitempty"`
+	Actor         interface{}    `json:"actor,omitempty"`
+	Tenant        *TriggerTenant `json:"tenant,omitempty"`
 }
 
 type MessagesQueryParams struct {
@@ -123,6 +142,54 @@ type MessagesQueryParams struct {
 	Limit         int
 }
 
+type Message struct {
+	ID             string                 `json:"_id"`
+	EnvironmentID  string                 `json:"_environmentId"`
+	OrganizationID string                 `json:"_organizationId"`
+	SubscriberID   string                 `json:"_subscriberId"`
+	TemplateID     string                 `json:"_templateId"`
+	NotificationID string                 `json:"_notificationId"`
+	FeedID         string                 `json:"_feedId"`
+	TransactionID  string                 `json:"transactionId"`
+	Channel        ChannelType            `json:"channel"`
+	Subject        string                 `json:"subject"`
+	Content        interface{}            `json:"content"`
+	Status         string                 `json:"status"`
+	Seen           bool                   `json:"seen"`
+	Read           bool                   `json:"read"`
+	Payload        map[string]interface{} `json:"payload"`
+	CreatedAt      time.Time              `json:"createdAt"`
+	UpdatedAt      time.Time              `json:"updatedAt"`
+}
+
+type MessagesResponse struct {
+	HasMore    bool      `json:"hasMore"`
+	TotalCount int       `json:"totalCount"`
+	Page       int       `json:"page"`
+	PageSize   int       `json:"pageSize"`
+	Data       []Message `json:"data"`
+}
+
+// MessageRetentionOptions selects the messages removed by MessagesService.ApplyRetention.
+// At least one of SubscriberId or Channel must be set.
+type MessageRetentionOptions struct {
+	SubscriberId string
+	Channel      ChannelType
+	// Messages created before OlderThan are deleted.
+	OlderThan time.Time
+	// DryRun only reports the messages that would be deleted.
+	DryRun   bool
+	PageSize int
+	// Output receives one line per expired message, it is optional.
+	Output io.Writer
+}
+
+type MessageRetentionResult struct {
+	Scanned int
+	Expired []Message
+	Deleted []string
+}
+
 // QueryBuilder gives us an interface to pass as arg to our API methods.
 // See messages.go for an example of implementing this interface
 type QueryBuilder interface {
@@ -156,19 +223,28 @@ type Preference struct {
 	Channels Channel `json:"channels"`
 }
 
+// Channel holds the per channel preference of a subscriber. A nil value means
+// the channel has no preference set, which is different from it being disabled.
 type Channel struct {
-	Email bool `json:"email"`
-	Sms   bool `json:"sms"`
-	Chat  bool `json:"chat"`
-	InApp bool `json:"in_app"`
-	Push  bool `json:"push"`
+	Email *bool `json:"email,omitempty"`
+	Sms   *bool `json:"sms,omitempty"`
+	Chat  *bool `json:"chat,omitempty"`
+	InApp *bool `json:"in_app,omitempty"`
+	Push  *bool `json:"push,omitempty"`
+}
+
+type SubscriberPreference struct {
+	Level      PreferenceLevel `json:"level,omitempty"`
+	Template   Template        `json:"template"`
+	Preference Preference      `json:"preference"`
 }
 
 type SubscriberPreferencesResponse struct {
-	Data []struct {
-		Template   Template   `json:"template"`
-		Preference Preference `json:"preference"`
-	} `json:"data"`
+	Data []SubscriberPreference `json:"data"`
+}
+
+type SubscriberGlobalPreferencesResponse struct {
+	Data SubscriberPreference `json:"data"`
 }
 
 type UpdateSubscriberPreferencesChannel struct {
@@ -178,7 +254,12 @@ type UpdateSubscriberPreferencesChannel struct {
 
 type UpdateSubscriberPreferencesOptions struct {
 	Channel []UpdateSubscriberPreferencesChannel `json:"channel,omitempty"`
-	Enabled bool                                 `json:"enabled,omitempty"`
+	Enabled *bool                                `json:"enabled,omitempty"`
+}
+
+type UpdateSubscriberGlobalPreferencesOptions struct {
+	Enabled     *bool                                `json:"enabled,omitempty"`
+	Preferences []UpdateSubscriberPreferencesChannel `json:"preferences,omitempty"`
 }
 
 type ListTopicsResponse struct {
@@ -245,6 +326,67 @@ type SubscriberMarkMessageSeenOptions struct {
 	Read      bool   `json:"read"`
 }
 
+type MessageMarkAs string
+
+const (
+	MessageMarkAsRead   MessageMarkAs = "read"
+	MessageMarkAsSeen   MessageMarkAs = "seen"
+	MessageMarkAsUnread MessageMarkAs = "unread"
+	MessageMarkAsUnseen MessageMarkAs = "unseen"
+)
+
+type SubscriberMarkAllMessagesOptions struct {
+	FeedIdentifier []string      `json:"feedIdentifier,omitempty"`
+	MarkAs         MessageMarkAs `json:"markAs"`
+}
+
+type SubscriberMarkAllMessagesResponse struct {
+	Data int `json:"data"`
+}
+
+type SubscriberMarkMessagesOptions struct {
+	MessageID []string      `json:"messageId"`
+	MarkAs    MessageMarkAs `json:"markAs"`
+}
+
+type SubscriberMarkMessagesResponse struct {
+	Data []NotificationFeedData `json:"data"`
+}
+
+type MessageActionType string
+type MessageActionStatus string
+
+const (
+	MessageActionPrimary   MessageActionType = "primary"
+	MessageActionSecondary MessageActionType = "secondary"
+)
+
+const (
+	MessageActionStatusPending MessageActionStatus = "pending"
+	MessageActionStatusDone    MessageActionStatus = "done"
+)
+
+type SubscriberMessageActionOptions struct {
+	Status  MessageActionStatus    `json:"status"`
+	Payload map[string]interface{} `json:"payload,omitempty"`
+}
+
+type SubscriberMessageResponse struct {
+	Data NotificationFeedData `json:"data"`
+}
+
+// SubscriberDeleteMessagesOptions selects the messages to delete. When
+// MessageIDs is empty every message in the subscriber feed is deleted,
+// optionally limited to a single feed.
+type SubscriberDeleteMessagesOptions struct {
+	MessageIDs     []string
+	FeedIdentifier string
+}
+
+type SubscriberDeleteMessagesResponse struct {
+	Deleted []string `json:"deleted"`
+}
+
 type NotificationFeedData struct {
 	CTA              CTA       `json:"cta"`
 	Channel          string    `json:"channel"`
@@ -344,30 +486,77 @@ type IntegrationCredentials struct {
 	RequireTls       bool                   `json:"requireTls,omitempty"`
 	IgnoreTls        bool                   `json:"ignoreTls,omitempty"`
 	TlsOptions       map[string]interface{} `json:"tlsOptions,omitempty"`
+	BaseUrl          string                 `json:"baseUrl,omitempty"`
+	WebhookUrl       string                 `json:"webhookUrl,omitempty"`
+	RedirectUrl      string                 `json:"redirectUrl,omitempty"`
+	Hmac             bool                   `json:"hmac,omitempty"`
+	ServiceAccount   string                 `json:"serviceAccount,omitempty"`
+}
+
+type IntegrationConditionOperator string
+
+const (
+	IntegrationConditionEqual    IntegrationConditionOperator = "EQUAL"
+	IntegrationConditionNotEqual IntegrationConditionOperator = "NOT_EQUAL"
+	IntegrationConditionIn       IntegrationConditionOperator = "IN"
+	IntegrationConditionNotIn    IntegrationConditionOperator = "NOT_IN"
+)
+
+// IntegrationConditionChild compares a field of the object named by On, for
+// example the identifier of the tenant a notification is triggered for.
+type IntegrationConditionChild struct {
+	Field    string                       `json:"field"`
+	Value    string                       `json:"value"`
+	Operator IntegrationConditionOperator `json:"operator"`
+	On       string                       `json:"on"`
+}
+
+// IntegrationCondition selects when an integration is used. Children are
+// combined with Value, which is either "AND" or "OR".
+type IntegrationCondition struct {
+	IsNegated bool                        `json:"isNegated"`
+	Type      string                      `json:"type"`
+	Value     string                      `json:"value"`
+	Children  []IntegrationConditionChild `json:"children"`
 }
 
 type CreateIntegrationRequest struct {
-	ProviderID  string                 `json:"providerId"`
-	Channel     ChannelType            `json:"channel"`
-	Credentials IntegrationCredentials `json:"credentials,omitempty"`
-	Active      bool                   `json:"active"`
-	Check       bool                   `json:"check"`
+	Name          string                 `json:"name,omitempty"`
+	Identifier    string                 `json:"identifier,omitempty"`
+	EnvironmentID string                 `json:"_environmentId,omitempty"`
+	ProviderID    string                 `json:"providerId"`
+	Channel       ChannelType            `json:"channel"`
+	Credentials   IntegrationCredentials `json:"credentials,omitempty"`
+	Active        bool                   `json:"active"`
+	Check         bool                   `json:"check"`
+	Conditions    []IntegrationCondition `json:"conditions,omitempty"`
 }
 
 type UpdateIntegrationRequest struct {
-	Credentials IntegrationCredentials `json:"credentials"`
-	Active      bool                   `json:"active"`
-	Check       bool                   `json:"check"`
+	Name          string                 `json:"name,omitempty"`
+	Identifier    string                 `json:"identifier,omitempty"`
+	EnvironmentID string                 `json:"_environmentId,omitempty"`
+	Credentials   IntegrationCredentials `json:"credentials"`
+	Active        bool                   `json:"active"`
+	Check         bool                   `json:"check"`
+	// Conditions replaces the conditions of the integration when set; an
+	// empty list removes them. See IntegrationConditions.
+	Conditions *[]IntegrationCondition `json:"conditions,omitempty"`
 }
 
 type Integration struct {
 	Id             string                 `json:"_id"`
 	EnvironmentID  string                 `json:"_environmentId"`
 	OrganizationID string                 `json:"_organizationId"`
+	Name           string                 `json:"name,omitempty"`
+	Identifier     string                 `json:"identifier,omitempty"`
 	ProviderID     string                 `json:"providerId"`
 	Channel        ChannelType            `json:"channel"`
 	Credentials    IntegrationCredentials `json:"credentials"`
 	Active         bool                   `json:"active"`
+	Primary        bool                   `json:"primary,omitempty"`
+	Priority       int                    `json:"priority,omitempty"`
+	Conditions     []IntegrationCondition `json:"conditions,omitempty"`
 	Deleted        bool                   `json:"deleted"`
 	UpdatedAt      string                 `json:"updatedAt"`
 	DeletedAt      string                 `json:"deletedAt"`
@@ -407,24 +596,54 @@ type SetIntegrationAsPrimaryResponse struct {
 	} `json:"data"`
 }
 type BulkTriggerOptions struct {
-	Name          interface{} `json:"name,omitempty"`
-	To            interface{} `json:"to,omitempty"`
-	Payload       interface{} `json:"payload,omitempty"`
-	Overrides     interface{} `json:"overrides,omitempty"`
-	TransactionId string      `json:"transactionId,omitempty"`
-	Actor         interface{} `json:"actor,omitempty"`
+	Name          interface{}    `json:"name,omitempty"`
+	To            interface{}    `json:"to,omitempty"`
+	Payload       interface{}    `json:"payload,omitempty"`
+	Overrides     interface{}    `json:"overrides,omitempty"`
+	TransactionId string         `json:"transactionId,omitempty"`
+	Actor         interface{}    `json:"actor,omitempty"`
+	Tenant        *TriggerTenant `json:"tenant,omitempty"`
+}
+
+type CancelTriggerResult struct {
+	TransactionId string
+	Cancelled     bool
+	Err           error
+}
+
+type CancelTriggersOptions struct {
+	// Concurrency limits the number of cancel requests in flight, it defaults to DefaultBulkConcurrency.
+	Concurrency int
 }
 
 type BulkTriggerEvent struct {
 	Events []BulkTriggerOptions `json:"events"`
 }
 
+type TriggerBulkChunkedOptions struct {
+	// ChunkSize is the number of events per request. It defaults to and
+	// cannot exceed MaxBulkTriggerEvents.
+	ChunkSize int
+	// Concurrency limits the number of requests in flight, it defaults to DefaultBulkConcurrency.
+	Concurrency int
+}
+
+// BulkTriggerResult is the outcome of an event of a bulk trigger. Err is set
+// when the request of its chunk failed or when Novu did not process it.
+type BulkTriggerResult struct {
+	TransactionId string
+	Acknowledged  bool
+	Status        string
+	Err           error
+}
+
 type BroadcastEventToAll struct {
-	Name          interface{} `json:"name,omitempty"`
-	Payload       interface{} `json:"payload,omitempty"`
-	Overrides     interface{} `json:"overrides,omitempty"`
-	TransactionId string      `json:"transactionId,omitempty"`
-	Actor         interface{} `json:"actor,omitempty"`
+	Name          interface{}    `json:"name,omitempty"`
+	Payload       interface{}    `json:"payload,omitempty"`
+	Overrides     interface{}    `json:"overrides,omitempty"`
+	TransactionId string         `json:"transactionId,omitempty"`
+	Actor         interface{}    `json:"actor,omitempty"`
+	Tenant        *TriggerTenant `json:"tenant,omitempty"`
 }
 type MxRecordConfiguredStatus struct {
 	MxRecordConfigured bool `json:"mxRecordConfigured"`
@@ -478,29 +697,207 @@ type LayoutsResponse struct {
 	Page       int              `json:"page"`
 }
 type BlueprintByTemplateIdResponse struct {
-	Id                  string        `json:"_id,omitempty"`
-	Name                string        `json:"name,omitempty"`
-	Description         string        `json:"description,omitempty"`
-	Active              bool          `json:"active,omitempty"`
-	Draft               bool          `json:"draft,omitempty"`
-	PreferenceSettings  interface{}   `json:"preferenceSettings,omitempty"`
-	Critical            bool          `json:"critical,omitempty"`
-	Tags                []string      `json:"tags,omitempty"`
-	Steps               []interface{} `json:"steps,omitempty"`
-	OrganizationID      string        `json:"_organizationId,omitempty"`
-	CreatorID           string        `json:"_creatorId,omitempty"`
-	EnvironmentID       string        `json:"_environmentId,omitempty"`
-	Triggers            []interface{} `json:"triggers,omitempty"`
-	NotificationGroupID string        `json:"_notificationGroupId,omitempty"`
-	ParentId            string        `json:"_parentId,omitempty"`
-	Deleted             bool          `json:"deleted,omitempty"`
-	DeletedAt           string        `json:"deletedAt,omitempty"`
-	DeletedBy           string        `json:"deletedBy,omitempty"`
-	CreatedAt           string        `json:"createdAt,omitempty"`
-	UpdatedAt           string        `json:"updatedAt,omitempty"`
-	NotificationGroup   interface{}   `json:"notificationGroup,omitempty"`
-	IsBlueprint         bool          `json:"isBlueprint,omitempty"`
-	BlueprintID         string        `json:"blueprintId,omitempty"`
+	Id                  string             `json:"_id,omitempty"`
+	Name                string             `json:"name,omitempty"`
+	Description         string             `json:"description,omitempty"`
+	Active              bool               `json:"active,omitempty"`
+	Draft               bool               `json:"draft,omitempty"`
+	PreferenceSettings  interface{}        `json:"preferenceSettings,omitempty"`
+	Critical            bool               `json:"critical,omitempty"`
+	Tags                []string           `json:"tags,omitempty"`
+	Steps               []WorkflowStep     `json:"steps,omitempty"`
+	OrganizationID      string             `json:"_organizationId,omitempty"`
+	CreatorID           string             `json:"_creatorId,omitempty"`
+	EnvironmentID       string             `json:"_environmentId,omitempty"`
+	Triggers            []WorkflowTrigger  `json:"triggers,omitempty"`
+	NotificationGroupID string             `json:"_notificationGroupId,omitempty"`
+	ParentId            string             `json:"_parentId,omitempty"`
+	Deleted             bool               `json:"deleted,omitempty"`
+	DeletedAt           string             `json:"deletedAt,omitempty"`
+	DeletedBy           string             `json:"deletedBy,omitempty"`
+	CreatedAt           string             `json:"createdAt,omitempty"`
+	UpdatedAt           string             `json:"updatedAt,omitempty"`
+	NotificationGroup   *NotificationGroup `json:"notificationGroup,omitempty"`
+	IsBlueprint         bool               `json:"isBlueprint,omitempty"`
+	BlueprintID         string             `json:"blueprintId,omitempty"`
+}
+
+// StepType is the type of a workflow step: a channel or an action such as a
+// digest or a delay.
+type StepType string
+
+const (
+	StepTypeInApp   StepType = "in_app"
+	StepTypeEmail   StepType = "email"
+	StepTypeSms     StepType = "sms"
+	StepTypeChat    StepType = "chat"
+	StepTypePush    StepType = "push"
+	StepTypeDigest  StepType = "digest"
+	StepTypeDelay   StepType = "delay"
+	StepTypeTrigger StepType = "trigger"
+	StepTypeCustom  StepType = "custom"
+)
+
+type WorkflowTrigger struct {
+	Type                string            `json:"type,omitempty"`
+	Identifier          string            `json:"identifier,omitempty"`
+	Variables           []TriggerVariable `json:"variables,omitempty"`
+	SubscriberVariables []TriggerVariable `json:"subscriberVariables,omitempty"`
+}
+
+type TriggerVariable struct {
+	Name string `json:"name"`
+}
+
+type WorkflowStep struct {
+	Id               string                 `json:"_id,omitempty"`
+	TemplateId       string                 `json:"_templateId,omitempty"`
+	UUID             string                 `json:"uuid,omitempty"`
+	Name             string                 `json:"name,omitempty"`
+	Active           bool                   `json:"active"`
+	ShouldStopOnFail bool                   `json:"shouldStopOnFail,omitempty"`
+	Filters          []StepFilter           `json:"filters,omitempty"`
+	Template         *StepTemplate          `json:"template,omitempty"`
+	Metadata         map[string]interface{} `json:"metadata,omitempty"`
+}
+
+type StepFilter struct {
+	IsNegated bool              `json:"isNegated,omitempty"`
+	Type      string            `json:"type,omitempty"`
+	Value     string            `json:"value,omitempty"`
+	Children  []StepFilterChild `json:"children,omitempty"`
+}
+
+type StepFilterChild struct {
+	Field    string      `json:"field,omitempty"`
+	Value    interface{} `json:"value,omitempty"`
+	Operator string      `json:"operator,omitempty"`
+	On       string      `json:"on,omitempty"`
+}
+
+// StepTemplate is the message template of a step.
+type StepTemplate struct {
+	Id          string                 `json:"_id,omitempty"`
+	Type        StepType               `json:"type,omitempty"`
+	Name        string                 `json:"name,omitempty"`
+	Subject     string                 `json:"subject,omitempty"`
+	Title       string                 `json:"title,omitempty"`
+	Preheader   string                 `json:"preheader,omitempty"`
+	SenderName  string                 `json:"senderName,omitempty"`
+	Content     *StepContent           `json:"content,omitempty"`
+	ContentType string                 `json:"contentType,omitempty"`
+	Cta         map[string]interface{} `json:"cta,omitempty"`
+	Variables   []TemplateVariable     `json:"variables,omitempty"`
+	LayoutId    string                 `json:"_layoutId,omitempty"`
+	FeedId      string                 `json:"_feedId,omitempty"`
+}
+
+type TemplateVariable struct {
+	Name         string      `json:"name"`
+	Type         string      `json:"type,omitempty"`
+	Required     bool        `json:"required,omitempty"`
+	DefaultValue interface{} `json:"defaultValue,omitempty"`
+}
+
+// StepContent is the content of a step template: a text for most channels,
+// or the blocks of the email editor.
+type StepContent struct {
+	Text   string
+	Blocks []EmailBlock
+}
+
+type EmailBlock struct {
+	Type    string                 `json:"type"`
+	Content string                 `json:"content,omitempty"`
+	URL     string                 `json:"url,omitempty"`
+	Styles  map[string]interface{} `json:"styles,omitempty"`
+}
+
+func (c StepContent) MarshalJSON() ([]byte, error) {
+	if c.Blocks != nil {
+		return json.Marshal(c.Blocks)
+	}
+	return json.Marshal(c.Text)
+}
+
+func (c *StepContent) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '[' {
+		c.Text = ""
+		return json.Unmarshal(data, &c.Blocks)
+	}
+	c.Blocks = nil
+	return json.Unmarshal(data, &c.Text)
+}
+
+type NotificationGroup struct {
+	Id             string `json:"_id,omitempty"`
+	Name           string `json:"name,omitempty"`
+	EnvironmentId  string `json:"_environmentId,omitempty"`
+	OrganizationId string `json:"_organizationId,omitempty"`
+	ParentId       string `json:"_parentId,omitempty"`
+}
+
+type NotificationGroupsResponse struct {
+	Data []NotificationGroup `json:"data"`
+}
+
+type NotificationGroupResponse struct {
+	Data NotificationGroup `json:"data"`
+}
+
+type Workflow struct {
+	Id                  string             `json:"_id,omitempty"`
+	Name                string             `json:"name,omitempty"`
+	Description         string             `json:"description,omitempty"`
+	Active              bool               `json:"active,omitempty"`
+	Draft               bool               `json:"draft,omitempty"`
+	Critical            bool               `json:"critical,omitempty"`
+	Tags                []string           `json:"tags,omitempty"`
+	Steps               []WorkflowStep     `json:"steps,omitempty"`
+	Triggers            []WorkflowTrigger  `json:"triggers,omitempty"`
+	NotificationGroupID string             `json:"_notificationGroupId,omitempty"`
+	NotificationGroup   *NotificationGroup `json:"notificationGroup,omitempty"`
+	EnvironmentID       string             `json:"_environmentId,omitempty"`
+	OrganizationID      string             `json:"_organizationId,omitempty"`
+	BlueprintID         string             `json:"blueprintId,omitempty"`
+	CreatedAt           string             `json:"createdAt,omitempty"`
+	UpdatedAt           string             `json:"updatedAt,omitempty"`
+}
+
+type WorkflowResponse struct {
+	Data Workflow `json:"data"`
+}
+
+type CreateWorkflowRequest struct {
+	Name                string         `json:"name"`
+	NotificationGroupId string         `json:"notificationGroupId"`
+	Description         string         `json:"description,omitempty"`
+	Tags                []string       `json:"tags,omitempty"`
+	Steps               []WorkflowStep `json:"steps"`
+	Active              bool           `json:"active,omitempty"`
+	Draft               bool           `json:"draft,omitempty"`
+	Critical            bool           `json:"critical,omitempty"`
+	PreferenceSettings  interface{}    `json:"preferenceSettings,omitempty"`
+	BlueprintId         string         `json:"blueprintId,omitempty"`
+}
+
+// BlueprintWorkflowOptions overrides parts of a blueprint when creating a
+// workflow from it.
+type BlueprintWorkflowOptions struct {
+	Name string
+	// NotificationGroupId defaults to the group of the environment named like
+	// the blueprint group, which is created when missing.
+	NotificationGroupId string
+	// Steps overrides the template of the steps, by step name or uuid.
+	Steps map[string]StepOverride
+	// Active makes the workflow active instead of a draft.
+	Active bool
+}
+
+// StepOverride replaces the parts of a step template that are set.
+type StepOverride struct {
+	Subject string
+	Content *StepContent
 }
 
 type BlueprintGroupByCategoryResponse struct {
@@ -546,9 +943,159 @@ type ChangesApplyResponse struct {
 	Data []ChangesGetResponseData `json:"data,omitempty"`
 }
 
+// ChangeType is the kind of entity a change promotes.
+type ChangeType string
+
+const (
+	ChangeTypeWorkflow          ChangeType = "NotificationTemplate"
+	ChangeTypeMessageTemplate   ChangeType = "MessageTemplate"
+	ChangeTypeLayout            ChangeType = "Layout"
+	ChangeTypeDefaultLayout     ChangeType = "DefaultLayout"
+	ChangeTypeFeed              ChangeType = "Feed"
+	ChangeTypeNotificationGroup ChangeType = "NotificationGroup"
+	ChangeTypeTranslationGroup  ChangeType = "TranslationGroup"
+	ChangeTypeTranslation       ChangeType = "Translation"
+)
+
+// ChangeDiff is an entry of the diff held by a change. Kind is N for a new
+// value, E for an edit, D for a deletion and A for a change in an array, in
+// which case Item describes the change of the element at Index.
+type ChangeDiff struct {
+	Kind  string        `json:"kind"`
+	Path  []interface{} `json:"path,omitempty"`
+	Lhs   interface{}   `json:"lhs,omitempty"`
+	Rhs   interface{}   `json:"rhs,omitempty"`
+	Index int           `json:"index,omitempty"`
+	Item  *ChangeDiff   `json:"item,omitempty"`
+}
+
+type WorkflowChange struct {
+	Name                string            `json:"name,omitempty"`
+	Description         string            `json:"description,omitempty"`
+	Active              bool              `json:"active,omitempty"`
+	Draft               bool              `json:"draft,omitempty"`
+	Critical            bool              `json:"critical,omitempty"`
+	Tags                []string          `json:"tags,omitempty"`
+	NotificationGroupId string            `json:"_notificationGroupId,omitempty"`
+	Steps               []WorkflowStep    `json:"steps,omitempty"`
+	Triggers            []WorkflowTrigger `json:"triggers,omitempty"`
+}
+
+type MessageTemplateChange struct {
+	Name     string      `json:"name,omitempty"`
+	Type     ChannelType `json:"type,omitempty"`
+	Subject  string      `json:"subject,omitempty"`
+	Content  interface{} `json:"content,omitempty"`
+	LayoutId string      `json:"_layoutId,omitempty"`
+	FeedId   string      `json:"_feedId,omitempty"`
+}
+
+type LayoutChange struct {
+	Name       string `json:"name,omitempty"`
+	Identifier string `json:"identifier,omitempty"`
+	Content    string `json:"content,omitempty"`
+	IsDefault  bool   `json:"isDefault,omitempty"`
+}
+
+type FeedChange struct {
+	Name       string `json:"name,omitempty"`
+	Identifier string `json:"identifier,omitempty"`
+}
+
+type NotificationGroupChange struct {
+	Name string `json:"name,omitempty"`
+}
+
+type TranslationChange struct {
+	Name         string      `json:"name,omitempty"`
+	Identifier   string      `json:"identifier,omitempty"`
+	Locale       string      `json:"isoLanguage,omitempty"`
+	GroupId      string      `json:"_groupId,omitempty"`
+	Translations interface{} `json:"translations,omitempty"`
+}
+
+// PromotionOptions selects the pending changes to plan. Types limits the plan
+// to some entity types, all are planned when empty.
+type PromotionOptions struct {
+	Types    []ChangeType
+	PageSize int
+}
+
+type PromoteOptions struct {
+	// DryRun writes the plan to Output without applying it.
+	DryRun    bool
+	BatchSize int
+	Output    io.Writer
+}
+
+type PromotionResult struct {
+	Applied []ChangesGetResponseData
+	// Batches is the number of bulk apply requests sent.
+	Batches int
+}
+
+type Tenant struct {
+	Id            string                 `json:"_id"`
+	EnvironmentId string                 `json:"_environmentId"`
+	Identifier    string                 `json:"identifier"`
+	Name          string                 `json:"name"`
+	Data          map[string]interface{} `json:"data,omitempty"`
+	CreatedAt     string                 `json:"createdAt"`
+	UpdatedAt     string                 `json:"updatedAt"`
+}
+
+type TenantResponse struct {
+	Data Tenant `json:"data"`
+}
+
+type TenantsResponse struct {
+	Data     []Tenant `json:"data"`
+	Page     int      `json:"page"`
+	PageSize int      `json:"pageSize"`
+	HasMore  bool     `json:"hasMore"`
+}
+
+type CreateTenantRequest struct {
+	Identifier string                 `json:"identifier"`
+	Name       string                 `json:"name"`
+	Data       map[string]interface{} `json:"data,omitempty"`
+}
 
 type UpdateTenantRequest struct {
-	Name 	 string `json:"name"`
-	Data 	 map[string]interface{} `json:"data"`
-	Identifier string `json:"identifier"`
+	Name       string                 `json:"name,omitempty"`
+	Data       map[string]interface{} `json:"data,omitempty"`
+	Identifier string                 `json:"identifier,omitempty"`
+}
+
+// TriggerTenant is the tenant a trigger is sent for. It is sent as the bare
+// identifier, or as an inline tenant when Name or Data is set.
+type TriggerTenant struct {
+	Identifier string                 `json:"identifier"`
+	Name       string                 `json:"name,omitempty"`
+	Data       map[string]interface{} `json:"data,omitempty"`
+}
+
+func (t TriggerTenant) MarshalJSON() ([]byte, error) {
+	if t.Name == "" && len(t.Data) == 0 {
+		return json.Marshal(t.Identifier)
+	}
+
+	type inlineTenant TriggerTenant
+	return json.Marshal(inlineTenant(t))
+}
+
+func (t *TriggerTenant) UnmarshalJSON(b []byte) error {
+	var identifier string
+	if err := json.Unmarshal(b, &identifier); err == nil {
+		*t = TriggerTenant{Identifier: identifier}
+		return nil
+	}
+
+	type inlineTenant TriggerTenant
+	var tenant inlineTenant
+	if err := json.Unmarshal(b, &tenant); err != nil {
+		return err
+	}
+	*t = TriggerTenant(tenant)
+	return nil
 }
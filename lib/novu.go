@@ -11,7 +11,6 @@ import (
 	"strings"
 	"time"
 
-	"github.com/google/uuid"
 	"github.com/hashicorp/go-retryablehttp"
 	"github.com/pkg/errors"
 )
@@ -52,6 +51,7 @@ type APIClient struct {
 	InboundParserApi *InboundParserService
 	LayoutApi        *LayoutService
 	TenantApi	       *TenantService
+	WorkflowApi      *WorkflowService
 }
 
 type service struct {
@@ -112,13 +112,14 @@ func NewAPIClient(apiKey string, cfg *Config) *APIClient {
 	c.LayoutApi = (*LayoutService)(&c.common)
 	c.BlueprintApi = (*BlueprintService)(&c.common)
 	c.TenantApi = (*TenantService)(&c.common)
+	c.WorkflowApi = (*WorkflowService)(&c.common)
 	return c
 }
 
 func (c APIClient) sendRequest(req *http.Request, resp interface{}) (*http.Response, error) {
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", fmt.Sprintf("ApiKey %s", c.apiKey))
-	req.Header.Set("Idempotency-Key", uuid.New().String())
+	req.Header.Set("Idempotency-Key", idempotencyKey(req.Context()))
 
 	res, err := c.config.HttpClient.Do(req)
 	if err != nil {
@@ -129,10 +130,7 @@ func (c APIClient) sendRequest(req *http.Request, resp interface{}) (*http.Respo
 	defer res.Body.Close()
 
 	if res.StatusCode >= http.StatusMultipleChoices {
-		return res, errors.Errorf(
-			`request was not successful, status code %d, %s`, res.StatusCode,
-			string(body),
-		)
+		return res, newAPIError(res.StatusCode, body)
 	}
 
 	if string(body) == "" {
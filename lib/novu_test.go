@@ -3,6 +3,7 @@ package lib_test
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 	"net/http/httptest"
@@ -166,3 +167,44 @@ func TestError_Retry_With_Default_Config(t *testing.T) {
 	assert.True(t, allElementsSame(idempotencyHeader))
 	assert.Equal(t, len(idempotencyHeader), 1)
 }
+
+func TestAPIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		w.Write([]byte(`{"statusCode": 422, "message": ["name should not be empty", "key must be a string"], "error": "Unprocessable Entity"}`))
+	}))
+	defer server.Close()
+
+	c := lib.NewAPIClient(novuApiKey, &lib.Config{BackendURL: lib.MustParseURL(server.URL)})
+	_, err := c.TopicsApi.Get(context.Background(), "topic")
+
+	var apiErr *lib.APIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusUnprocessableEntity, apiErr.StatusCode)
+	assert.Equal(t, "Unprocessable Entity", apiErr.Reason)
+	assert.Equal(t, "name should not be empty; key must be a string", apiErr.Message)
+	assert.True(t, strings.HasPrefix(err.Error(), "request was not successful, status code 422, {"))
+}
+
+func TestWithIdempotencyKey(t *testing.T) {
+	var keys []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		keys = append(keys, req.Header.Get("Idempotency-Key"))
+		w.Write([]byte(`{"data": {"acknowledged": true}}`))
+	}))
+	defer server.Close()
+
+	c := lib.NewAPIClient(novuApiKey, &lib.Config{BackendURL: lib.MustParseURL(server.URL)})
+	ctx := lib.WithIdempotencyKey(context.Background(), "trigger-1")
+	for i := 0; i < 2; i++ {
+		_, err := c.EventApi.Trigger(ctx, "welcome", lib.ITriggerPayloadOptions{To: "sub-1"})
+		require.NoError(t, err)
+	}
+	_, err := c.EventApi.Trigger(context.Background(), "welcome", lib.ITriggerPayloadOptions{To: "sub-1"})
+	require.NoError(t, err)
+
+	require.Len(t, keys, 3)
+	assert.Equal(t, []string{"trigger-1", "trigger-1"}, keys[:2])
+	assert.NotEmpty(t, keys[2])
+	assert.NotEqual(t, "trigger-1", keys[2])
+}
@@ -0,0 +1,483 @@
+package lib
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultPromotionPageSize  = 100
+	defaultPromotionBatchSize = 20
+)
+
+// PlannedChange is a pending change decoded for promotion.
+type PlannedChange struct {
+	Change ChangesGetResponseData
+	Diff   []ChangeDiff
+	// Entity is one of *WorkflowChange, *MessageTemplateChange, *LayoutChange,
+	// *FeedChange, *NotificationGroupChange or *TranslationChange, or nil for
+	// other types. Updates only set the changed fields.
+	Entity interface{}
+	// Children are the changes promoted together with this one, such as the
+	// steps of a workflow.
+	Children []*PlannedChange
+	// DependsOn holds the ids of the changes that must be promoted first.
+	DependsOn []string
+}
+
+// PromotionPlan holds pending changes in the order they must be promoted.
+type PromotionPlan struct {
+	Changes []*PlannedChange
+}
+
+// PlanPromotion fetches all pending changes and orders them so the entities a
+// change references, such as the layout of a step or the group of a
+// workflow, are promoted before it.
+func (c *ChangesService) PlanPromotion(ctx context.Context, opts *PromotionOptions) (*PromotionPlan, error) {
+	if opts == nil {
+		opts = &PromotionOptions{}
+	}
+	pageSize := opts.PageSize
+	if pageSize <= 0 {
+		pageSize = defaultPromotionPageSize
+	}
+
+	var changes []ChangesGetResponseData
+	for page := 1; ; page++ {
+		resp, err := c.GetChanges(ctx, ChangesGetQuery{Page: page, Limit: pageSize, Promoted: "false"})
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, resp.Data...)
+		if lastPage(len(resp.Data), len(changes), resp.TotalCount, pageSize) {
+			break
+		}
+	}
+
+	return NewPromotionPlan(changes, opts.Types...)
+}
+
+// NewPromotionPlan decodes and orders changes. When types are given, only
+// changes of these types and their dependencies are planned.
+func NewPromotionPlan(changes []ChangesGetResponseData, types ...ChangeType) (*PromotionPlan, error) {
+	planned := make([]*PlannedChange, 0, len(changes))
+	byId := map[string]*PlannedChange{}
+	for _, change := range changes {
+		p, err := decodeChange(change)
+		if err != nil {
+			return nil, err
+		}
+		planned = append(planned, p)
+		byId[change.Id] = p
+	}
+
+	var roots []*PlannedChange
+	for _, p := range planned {
+		if parent, ok := byId[p.Change.ParentId]; ok && p.Change.ParentId != p.Change.Id {
+			parent.Children = append(parent.Children, p)
+			continue
+		}
+		roots = append(roots, p)
+	}
+
+	// Dependencies are found by matching the ids an entity references with the
+	// entity ids of the other changes.
+	byEntity := map[string]*PlannedChange{}
+	for _, p := range roots {
+		byEntity[p.Change.EntityId] = p
+	}
+	for _, p := range roots {
+		seen := map[string]bool{}
+		for _, ref := range p.references() {
+			if dep, ok := byEntity[ref]; ok && dep != p && !seen[dep.Change.Id] {
+				seen[dep.Change.Id] = true
+				p.DependsOn = append(p.DependsOn, dep.Change.Id)
+			}
+		}
+	}
+
+	plan, err := sortChanges(roots)
+	if err != nil {
+		return nil, err
+	}
+	if len(types) == 0 {
+		return plan, nil
+	}
+	return plan.SelectFunc(func(p *PlannedChange) bool {
+		for _, t := range types {
+			if ChangeType(p.Change.Type) == t {
+				return true
+			}
+		}
+		return false
+	}), nil
+}
+
+func decodeChange(change ChangesGetResponseData) (*PlannedChange, error) {
+	p := &PlannedChange{Change: change}
+	if change.Change != nil {
+		bb, err := json.Marshal(change.Change)
+		if err != nil {
+			return nil, err
+		}
+		// A change without a diff is sent as an empty object.
+		if len(bb) > 0 && bb[0] == '[' {
+			if err := json.Unmarshal(bb, &p.Diff); err != nil {
+				return nil, errors.Wrapf(err, "change %s", change.Id)
+			}
+		}
+	}
+
+	switch ChangeType(change.Type) {
+	case ChangeTypeWorkflow:
+		p.Entity = &WorkflowChange{}
+	case ChangeTypeMessageTemplate:
+		p.Entity = &MessageTemplateChange{}
+	case ChangeTypeLayout, ChangeTypeDefaultLayout:
+		p.Entity = &LayoutChange{}
+	case ChangeTypeFeed:
+		p.Entity = &FeedChange{}
+	case ChangeTypeNotificationGroup:
+		p.Entity = &NotificationGroupChange{}
+	case ChangeTypeTranslation, ChangeTypeTranslationGroup:
+		p.Entity = &TranslationChange{}
+	default:
+		return p, nil
+	}
+
+	bb, err := json.Marshal(applyDiff(p.Diff))
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(bb, p.Entity); err != nil {
+		return nil, errors.Wrapf(err, "change %s", change.Id)
+	}
+	return p, nil
+}
+
+// applyDiff builds the new values of an entity from its diff.
+func applyDiff(diff []ChangeDiff) interface{} {
+	var root interface{} = map[string]interface{}{}
+	for _, d := range diff {
+		switch d.Kind {
+		case "N", "E":
+			if len(d.Path) == 0 {
+				root = d.Rhs
+				continue
+			}
+			root = setPath(root, d.Path, d.Rhs)
+		case "A":
+			if d.Item != nil && d.Item.Kind != "D" {
+				root = setPath(root, append(append([]interface{}{}, d.Path...), float64(d.Index)), d.Item.Rhs)
+			}
+		}
+	}
+	return root
+}
+
+func setPath(container interface{}, path []interface{}, value interface{}) interface{} {
+	if len(path) == 0 {
+		return value
+	}
+	switch key := path[0].(type) {
+	case float64:
+		list, _ := container.([]interface{})
+		for len(list) <= int(key) {
+			list = append(list, nil)
+		}
+		list[int(key)] = setPath(list[int(key)], path[1:], value)
+		return list
+	default:
+		m, ok := container.(map[string]interface{})
+		if !ok {
+			m = map[string]interface{}{}
+		}
+		name := fmt.Sprint(key)
+		m[name] = setPath(m[name], path[1:], value)
+		return m
+	}
+}
+
+// references returns the entity ids the change and its children point to.
+func (p *PlannedChange) references() []string {
+	var refs []string
+	switch e := p.Entity.(type) {
+	case *WorkflowChange:
+		refs = append(refs, e.NotificationGroupId)
+	case *MessageTemplateChange:
+		refs = append(refs, e.LayoutId, e.FeedId)
+	case *TranslationChange:
+		refs = append(refs, e.GroupId)
+	}
+	for _, child := range p.Children {
+		refs = append(refs, child.references()...)
+	}
+
+	out := refs[:0]
+	for _, ref := range refs {
+		if ref != "" {
+			out = append(out, ref)
+		}
+	}
+	return out
+}
+
+// sortChanges orders changes after their dependencies, keeping the order of
+// creation otherwise.
+func sortChanges(changes []*PlannedChange) (*PromotionPlan, error) {
+	sorted := append([]*PlannedChange{}, changes...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Change.CreatedAt < sorted[j].Change.CreatedAt
+	})
+	byId := map[string]*PlannedChange{}
+	for _, p := range sorted {
+		byId[p.Change.Id] = p
+	}
+
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := map[string]int{}
+	plan := &PromotionPlan{}
+	var visit func(p *PlannedChange) error
+	visit = func(p *PlannedChange) error {
+		switch state[p.Change.Id] {
+		case visiting:
+			return errors.Errorf("changes have a circular dependency on %s", p.Change.Id)
+		case done:
+			return nil
+		}
+		state[p.Change.Id] = visiting
+		for _, id := range p.DependsOn {
+			if dep, ok := byId[id]; ok {
+				if err := visit(dep); err != nil {
+					return err
+				}
+			}
+		}
+		state[p.Change.Id] = done
+		plan.Changes = append(plan.Changes, p)
+		return nil
+	}
+
+	for _, p := range sorted {
+		if err := visit(p); err != nil {
+			return nil, err
+		}
+	}
+	return plan, nil
+}
+
+// Select returns a plan with the changes of the given ids and the changes
+// they depend on. The ids of child changes select their parent.
+func (p *PromotionPlan) Select(ids ...string) *PromotionPlan {
+	selected := map[string]bool{}
+	for _, id := range ids {
+		selected[id] = true
+	}
+	return p.SelectFunc(func(c *PlannedChange) bool {
+		if selected[c.Change.Id] {
+			return true
+		}
+		for _, child := range c.Children {
+			if selected[child.Change.Id] {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// SelectFunc returns a plan with the changes for which fn returns true and
+// the changes they depend on.
+func (p *PromotionPlan) SelectFunc(fn func(*PlannedChange) bool) *PromotionPlan {
+	byId := map[string]*PlannedChange{}
+	for _, c := range p.Changes {
+		byId[c.Change.Id] = c
+	}
+
+	keep := map[string]bool{}
+	var mark func(c *PlannedChange)
+	mark = func(c *PlannedChange) {
+		if keep[c.Change.Id] {
+			return
+		}
+		keep[c.Change.Id] = true
+		for _, id := range c.DependsOn {
+			if dep, ok := byId[id]; ok {
+				mark(dep)
+			}
+		}
+	}
+	for _, c := range p.Changes {
+		if fn(c) {
+			mark(c)
+		}
+	}
+
+	selected := &PromotionPlan{}
+	for _, c := range p.Changes {
+		if keep[c.Change.Id] {
+			selected.Changes = append(selected.Changes, c)
+		}
+	}
+	return selected
+}
+
+// ChangeIds returns the ids of the changes in promotion order.
+func (p *PromotionPlan) ChangeIds() []string {
+	ids := make([]string, len(p.Changes))
+	for i, c := range p.Changes {
+		ids[i] = c.Change.Id
+	}
+	return ids
+}
+
+// String renders the plan as a human readable diff.
+func (p *PromotionPlan) String() string {
+	var b strings.Builder
+	for _, c := range p.Changes {
+		c.writeDiff(&b, "")
+	}
+	return b.String()
+}
+
+func (p *PlannedChange) String() string {
+	var b strings.Builder
+	p.writeDiff(&b, "")
+	return b.String()
+}
+
+// Name returns the name of the entity, or its id when the change doesn't
+// hold a name.
+func (p *PlannedChange) Name() string {
+	var name string
+	switch e := p.Entity.(type) {
+	case *WorkflowChange:
+		name = e.Name
+	case *MessageTemplateChange:
+		name = e.Name
+	case *LayoutChange:
+		name = e.Name
+	case *FeedChange:
+		name = e.Name
+	case *NotificationGroupChange:
+		name = e.Name
+	case *TranslationChange:
+		name = e.Name
+		if name == "" {
+			name = e.Locale
+		}
+	}
+	if name == "" {
+		return p.Change.EntityId
+	}
+	return name
+}
+
+func (p *PlannedChange) writeDiff(b *strings.Builder, indent string) {
+	action := "update"
+	if len(p.Diff) == 1 && p.Diff[0].Kind == "N" && len(p.Diff[0].Path) == 0 {
+		action = "create"
+	}
+	fmt.Fprintf(b, "%s%s %s %q (change %s)\n", indent, action, p.Change.Type, p.Name(), p.Change.Id)
+	if action == "update" {
+		for _, d := range p.Diff {
+			writeDiffLine(b, indent+"  ", d.Path, d)
+		}
+	}
+	for _, child := range p.Children {
+		child.writeDiff(b, indent+"  ")
+	}
+}
+
+func writeDiffLine(b *strings.Builder, indent string, path []interface{}, d ChangeDiff) {
+	switch d.Kind {
+	case "N":
+		fmt.Fprintf(b, "%s+ %s: %s\n", indent, formatPath(path), formatValue(d.Rhs))
+	case "D":
+		fmt.Fprintf(b, "%s- %s: %s\n", indent, formatPath(path), formatValue(d.Lhs))
+	case "E":
+		fmt.Fprintf(b, "%s~ %s: %s -> %s\n", indent, formatPath(path), formatValue(d.Lhs), formatValue(d.Rhs))
+	case "A":
+		if d.Item != nil {
+			writeDiffLine(b, indent, append(append([]interface{}{}, path...), float64(d.Index)), *d.Item)
+		}
+	}
+}
+
+func formatPath(path []interface{}) string {
+	var b strings.Builder
+	for _, key := range path {
+		if n, ok := key.(float64); ok {
+			b.WriteString("[" + strconv.Itoa(int(n)) + "]")
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteByte('.')
+		}
+		b.WriteString(fmt.Sprint(key))
+	}
+	if b.Len() == 0 {
+		return "."
+	}
+	return b.String()
+}
+
+func formatValue(v interface{}) string {
+	var b strings.Builder
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return fmt.Sprint(v)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// Promote applies the changes of plan in batches, in plan order. It stops at
+// the first failed batch and returns the changes applied so far.
+func (c *ChangesService) Promote(ctx context.Context, plan *PromotionPlan, opts *PromoteOptions) (*PromotionResult, error) {
+	if opts == nil {
+		opts = &PromoteOptions{}
+	}
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultPromotionBatchSize
+	}
+
+	result := &PromotionResult{}
+	if opts.DryRun {
+		if opts.Output != nil {
+			io.WriteString(opts.Output, plan.String())
+		}
+		return result, nil
+	}
+
+	ids := plan.ChangeIds()
+	for start := 0; start < len(ids); start += batchSize {
+		end := start + batchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+		resp, err := c.ApplyBulkChanges(ctx, ChangesBulkApplyPayload{ChangeIds: ids[start:end]})
+		result.Batches++
+		if err != nil {
+			return result, errors.Wrapf(err, "apply changes %d to %d of %d", start+1, end, len(ids))
+		}
+		result.Applied = append(result.Applied, resp.Data...)
+		if opts.Output != nil {
+			for _, p := range plan.Changes[start:end] {
+				fmt.Fprintf(opts.Output, "applied %s %q (change %s)\n", p.Change.Type, p.Name(), p.Change.Id)
+			}
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,377 @@
+package lib
+
+import (
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// ChannelInfo describes a channel Novu can deliver notifications on.
+type ChannelInfo struct {
+	Type        ChannelType
+	DisplayName string
+	// SubscriberCredentials is set for channels where the subscriber holds the
+	// delivery credentials, such as chat webhooks or push device tokens.
+	SubscriberCredentials bool
+}
+
+var channels = []ChannelInfo{
+	{Type: IN_APP, DisplayName: "In-App"},
+	{Type: EMAIL, DisplayName: "Email"},
+	{Type: SMS, DisplayName: "SMS"},
+	{Type: CHAT, DisplayName: "Chat", SubscriberCredentials: true},
+	{Type: PUSH, DisplayName: "Push", SubscriberCredentials: true},
+}
+
+// Channels returns every channel supported by Novu.
+func Channels() []ChannelInfo {
+	return append([]ChannelInfo(nil), channels...)
+}
+
+// GetChannel returns the channel registered for channelType.
+func GetChannel(channelType ChannelType) (ChannelInfo, bool) {
+	for _, channel := range channels {
+		if channel.Type == channelType {
+			return channel, true
+		}
+	}
+	return ChannelInfo{}, false
+}
+
+// IsValid reports whether c is a channel known by Novu.
+func (c ChannelType) IsValid() bool {
+	_, ok := GetChannel(c)
+	return ok
+}
+
+const (
+	// In-App
+	ProviderNovuInApp ProviderIdType = "novu"
+
+	// Email
+	ProviderNovuEmail    ProviderIdType = "novu-email"
+	ProviderSendGrid     ProviderIdType = "sendgrid"
+	ProviderSES          ProviderIdType = "ses"
+	ProviderMailgun      ProviderIdType = "mailgun"
+	ProviderMailjet      ProviderIdType = "mailjet"
+	ProviderMandrill     ProviderIdType = "mandrill"
+	ProviderPostmark     ProviderIdType = "postmark"
+	ProviderSendinblue   ProviderIdType = "sendinblue"
+	ProviderNodemailer   ProviderIdType = "nodemailer"
+	ProviderEmailJS      ProviderIdType = "emailjs"
+	ProviderNetCore      ProviderIdType = "netcore"
+	ProviderInfobipEmail ProviderIdType = "infobip-email"
+	ProviderResend       ProviderIdType = "resend"
+	ProviderPlunk        ProviderIdType = "plunk"
+	ProviderMailerSend   ProviderIdType = "mailersend"
+	ProviderMailtrap     ProviderIdType = "mailtrap"
+	ProviderSparkPost    ProviderIdType = "sparkpost"
+	ProviderOutlook365   ProviderIdType = "outlook365"
+	ProviderBraze        ProviderIdType = "braze"
+	ProviderEmailWebhook ProviderIdType = "email-webhook"
+
+	// SMS
+	ProviderNovuSms        ProviderIdType = "novu-sms"
+	ProviderTwilio         ProviderIdType = "twilio"
+	ProviderNexmo          ProviderIdType = "nexmo"
+	ProviderPlivo          ProviderIdType = "plivo"
+	ProviderSNS            ProviderIdType = "sns"
+	ProviderTelnyx         ProviderIdType = "telnyx"
+	ProviderSms77          ProviderIdType = "sms77"
+	ProviderSmsCentral     ProviderIdType = "sms-central"
+	ProviderGupshup        ProviderIdType = "gupshup"
+	ProviderFiretext       ProviderIdType = "firetext"
+	ProviderInfobipSms     ProviderIdType = "infobip-sms"
+	ProviderBurstSms       ProviderIdType = "burst-sms"
+	ProviderBulkSms        ProviderIdType = "bulk-sms"
+	ProviderISendSms       ProviderIdType = "isend-sms"
+	ProviderFortySixElks   ProviderIdType = "forty-six-elks"
+	ProviderKannel         ProviderIdType = "kannel"
+	ProviderMaqsam         ProviderIdType = "maqsam"
+	ProviderTermii         ProviderIdType = "termii"
+	ProviderAfricasTalking ProviderIdType = "africas-talking"
+	ProviderSendchamp      ProviderIdType = "sendchamp"
+	ProviderClickSend      ProviderIdType = "clicksend"
+	ProviderSimpleTexting  ProviderIdType = "simpletexting"
+	ProviderBandwidth      ProviderIdType = "bandwidth"
+	ProviderMessageBird    ProviderIdType = "messagebird"
+	ProviderAzureSms       ProviderIdType = "azure-sms"
+	ProviderGenericSms     ProviderIdType = "generic-sms"
+
+	// Chat
+	ProviderSlack            ProviderIdType = "slack"
+	ProviderDiscord          ProviderIdType = "discord"
+	ProviderMsTeams          ProviderIdType = "msteams"
+	ProviderMattermost       ProviderIdType = "mattermost"
+	ProviderRyver            ProviderIdType = "ryver"
+	ProviderZulip            ProviderIdType = "zulip"
+	ProviderGrafanaOnCall    ProviderIdType = "grafana-on-call"
+	ProviderGetStream        ProviderIdType = "getstream"
+	ProviderRocketChat       ProviderIdType = "rocket-chat"
+	ProviderWhatsAppBusiness ProviderIdType = "whatsapp-business"
+	ProviderChatWebhook      ProviderIdType = "chat-webhook"
+
+	// Push
+	ProviderFCM         ProviderIdType = "fcm"
+	ProviderAPNS        ProviderIdType = "apns"
+	ProviderExpo        ProviderIdType = "expo"
+	ProviderOneSignal   ProviderIdType = "one-signal"
+	ProviderPushpad     ProviderIdType = "pushpad"
+	ProviderPusherBeams ProviderIdType = "pusher-beams"
+	ProviderPushWebhook ProviderIdType = "push-webhook"
+)
+
+// Provider describes a delivery provider that can back an integration.
+type Provider struct {
+	ID          ProviderIdType
+	Channel     ChannelType
+	DisplayName string
+	Credentials CredentialSchema
+	// SupportsWebhooks is set for providers whose delivery webhooks Novu can
+	// consume. IntegrationService.GetWebhookSupportStatus checks the live value.
+	SupportsWebhooks bool
+}
+
+var (
+	emailSender = []CredentialKey{CredentialFrom, CredentialSenderName}
+
+	providers = []Provider{
+		{ID: ProviderNovuInApp, Channel: IN_APP, DisplayName: "Novu In-App",
+			Credentials: CredentialSchema{Optional: []CredentialKey{CredentialHmac}}},
+
+		{ID: ProviderNovuEmail, Channel: EMAIL, DisplayName: "Novu Email",
+			Credentials: CredentialSchema{Optional: emailSender}},
+		{ID: ProviderSendGrid, Channel: EMAIL, DisplayName: "SendGrid", SupportsWebhooks: true,
+			Credentials: CredentialSchema{Required: withSender(CredentialApiKey)}},
+		{ID: ProviderSES, Channel: EMAIL, DisplayName: "Amazon SES", SupportsWebhooks: true,
+			Credentials: CredentialSchema{Required: withSender(CredentialApiKey, CredentialSecretKey, CredentialRegion)}},
+		{ID: ProviderMailgun, Channel: EMAIL, DisplayName: "Mailgun", SupportsWebhooks: true,
+			Credentials: CredentialSchema{
+				Required: withSender(CredentialApiKey, CredentialDomain, CredentialUser),
+				Optional: []CredentialKey{CredentialBaseUrl},
+			}},
+		{ID: ProviderMailjet, Channel: EMAIL, DisplayName: "Mailjet", SupportsWebhooks: true,
+			Credentials: CredentialSchema{Required: withSender(CredentialApiKey, CredentialSecretKey)}},
+		{ID: ProviderMandrill, Channel: EMAIL, DisplayName: "Mandrill", SupportsWebhooks: true,
+			Credentials: CredentialSchema{Required: withSender(CredentialApiKey)}},
+		{ID: ProviderPostmark, Channel: EMAIL, DisplayName: "Postmark", SupportsWebhooks: true,
+			Credentials: CredentialSchema{Required: withSender(CredentialApiKey)}},
+		{ID: ProviderSendinblue, Channel: EMAIL, DisplayName: "Brevo (Sendinblue)", SupportsWebhooks: true,
+			Credentials: CredentialSchema{Required: withSender(CredentialApiKey)}},
+		{ID: ProviderNodemailer, Channel: EMAIL, DisplayName: "Custom SMTP",
+			Credentials: CredentialSchema{
+				Required: withSender(CredentialHost, CredentialPort),
+				Optional: []CredentialKey{CredentialUser, CredentialPassword, CredentialSecure, CredentialDomain},
+			}},
+		{ID: ProviderEmailJS, Channel: EMAIL, DisplayName: "EmailJS",
+			Credentials: CredentialSchema{
+				Required: withSender(CredentialHost, CredentialPort, CredentialUser, CredentialPassword),
+				Optional: []CredentialKey{CredentialSecure},
+			}},
+		{ID: ProviderNetCore, Channel: EMAIL, DisplayName: "Netcore", SupportsWebhooks: true,
+			Credentials: CredentialSchema{Required: withSender(CredentialApiKey)}},
+		{ID: ProviderInfobipEmail, Channel: EMAIL, DisplayName: "Infobip Email", SupportsWebhooks: true,
+			Credentials: CredentialSchema{Required: withSender(CredentialApiKey, CredentialBaseUrl)}},
+		{ID: ProviderResend, Channel: EMAIL, DisplayName: "Resend", SupportsWebhooks: true,
+			Credentials: CredentialSchema{Required: withSender(CredentialApiKey)}},
+		{ID: ProviderPlunk, Channel: EMAIL, DisplayName: "Plunk",
+			Credentials: CredentialSchema{Required: withSender(CredentialApiKey)}},
+		{ID: ProviderMailerSend, Channel: EMAIL, DisplayName: "MailerSend",
+			Credentials: CredentialSchema{Required: withSender(CredentialApiKey)}},
+		{ID: ProviderMailtrap, Channel: EMAIL, DisplayName: "Mailtrap",
+			Credentials: CredentialSchema{Required: withSender(CredentialApiKey)}},
+		{ID: ProviderSparkPost, Channel: EMAIL, DisplayName: "SparkPost", SupportsWebhooks: true,
+			Credentials: CredentialSchema{
+				Required: withSender(CredentialApiKey),
+				Optional: []CredentialKey{CredentialRegion},
+			}},
+		{ID: ProviderOutlook365, Channel: EMAIL, DisplayName: "Microsoft Outlook365",
+			Credentials: CredentialSchema{Required: withSender(CredentialPassword)}},
+		{ID: ProviderBraze, Channel: EMAIL, DisplayName: "Braze",
+			Credentials: CredentialSchema{Required: withSender(CredentialApiKey, CredentialBaseUrl, CredentialApplicationID)}},
+		{ID: ProviderEmailWebhook, Channel: EMAIL, DisplayName: "Email Webhook",
+			Credentials: CredentialSchema{Required: []CredentialKey{CredentialWebhookUrl, CredentialSecretKey}}},
+
+		{ID: ProviderNovuSms, Channel: SMS, DisplayName: "Novu SMS"},
+		{ID: ProviderTwilio, Channel: SMS, DisplayName: "Twilio", SupportsWebhooks: true,
+			Credentials: CredentialSchema{Required: []CredentialKey{CredentialAccountSID, CredentialToken, CredentialFrom}}},
+		{ID: ProviderNexmo, Channel: SMS, DisplayName: "Nexmo", SupportsWebhooks: true,
+			Credentials: CredentialSchema{Required: []CredentialKey{CredentialApiKey, CredentialSecretKey, CredentialFrom}}},
+		{ID: ProviderPlivo, Channel: SMS, DisplayName: "Plivo", SupportsWebhooks: true,
+			Credentials: CredentialSchema{Required: []CredentialKey{CredentialAccountSID, CredentialToken, CredentialFrom}}},
+		{ID: ProviderSNS, Channel: SMS, DisplayName: "AWS SNS",
+			Credentials: CredentialSchema{Required: []CredentialKey{CredentialApiKey, CredentialSecretKey, CredentialRegion}}},
+		{ID: ProviderTelnyx, Channel: SMS, DisplayName: "Telnyx", SupportsWebhooks: true,
+			Credentials: CredentialSchema{
+				Required: []CredentialKey{CredentialApiKey, CredentialFrom},
+				Optional: []CredentialKey{CredentialMessageProfileID},
+			}},
+		{ID: ProviderSms77, Channel: SMS, DisplayName: "sms77",
+			Credentials: CredentialSchema{Required: []CredentialKey{CredentialApiKey, CredentialFrom}}},
+		{ID: ProviderSmsCentral, Channel: SMS, DisplayName: "SMS Central",
+			Credentials: CredentialSchema{Required: []CredentialKey{CredentialUser, CredentialPassword, CredentialFrom}}},
+		{ID: ProviderGupshup, Channel: SMS, DisplayName: "Gupshup",
+			Credentials: CredentialSchema{Required: []CredentialKey{CredentialUser, CredentialPassword}}},
+		{ID: ProviderFiretext, Channel: SMS, DisplayName: "Firetext",
+			Credentials: CredentialSchema{Required: []CredentialKey{CredentialApiKey, CredentialFrom}}},
+		{ID: ProviderInfobipSms, Channel: SMS, DisplayName: "Infobip SMS", SupportsWebhooks: true,
+			Credentials: CredentialSchema{Required: []CredentialKey{CredentialApiKey, CredentialBaseUrl, CredentialFrom}}},
+		{ID: ProviderBurstSms, Channel: SMS, DisplayName: "BurstSMS",
+			Credentials: CredentialSchema{Required: []CredentialKey{CredentialApiKey, CredentialSecretKey}}},
+		{ID: ProviderBulkSms, Channel: SMS, DisplayName: "BulkSMS",
+			Credentials: CredentialSchema{Required: []CredentialKey{CredentialApiKey}}},
+		{ID: ProviderISendSms, Channel: SMS, DisplayName: "iSend SMS",
+			Credentials: CredentialSchema{Required: []CredentialKey{CredentialApiKey}}},
+		{ID: ProviderFortySixElks, Channel: SMS, DisplayName: "46elks",
+			Credentials: CredentialSchema{Required: []CredentialKey{CredentialUser, CredentialPassword, CredentialFrom}}},
+		{ID: ProviderKannel, Channel: SMS, DisplayName: "Kannel SMS",
+			Credentials: CredentialSchema{
+				Required: []CredentialKey{CredentialHost, CredentialPort, CredentialFrom},
+				Optional: []CredentialKey{CredentialUser, CredentialPassword},
+			}},
+		{ID: ProviderMaqsam, Channel: SMS, DisplayName: "Maqsam",
+			Credentials: CredentialSchema{Required: []CredentialKey{CredentialApiKey, CredentialSecretKey, CredentialFrom}}},
+		{ID: ProviderTermii, Channel: SMS, DisplayName: "Termii",
+			Credentials: CredentialSchema{Required: []CredentialKey{CredentialApiKey, CredentialFrom}}},
+		{ID: ProviderAfricasTalking, Channel: SMS, DisplayName: "Africa's Talking",
+			Credentials: CredentialSchema{Required: []CredentialKey{CredentialApiKey, CredentialUser}}},
+		{ID: ProviderSendchamp, Channel: SMS, DisplayName: "Sendchamp",
+			Credentials: CredentialSchema{Required: []CredentialKey{CredentialApiKey, CredentialFrom}}},
+		{ID: ProviderClickSend, Channel: SMS, DisplayName: "ClickSend",
+			Credentials: CredentialSchema{Required: []CredentialKey{CredentialUser, CredentialApiKey}}},
+		{ID: ProviderSimpleTexting, Channel: SMS, DisplayName: "SimpleTexting",
+			Credentials: CredentialSchema{Required: []CredentialKey{CredentialApiKey, CredentialFrom}}},
+		{ID: ProviderBandwidth, Channel: SMS, DisplayName: "Bandwidth",
+			Credentials: CredentialSchema{Required: []CredentialKey{CredentialUser, CredentialPassword, CredentialAccountSID, CredentialFrom}}},
+		{ID: ProviderMessageBird, Channel: SMS, DisplayName: "MessageBird",
+			Credentials: CredentialSchema{Required: []CredentialKey{CredentialApiKey, CredentialFrom}}},
+		{ID: ProviderAzureSms, Channel: SMS, DisplayName: "Azure SMS",
+			Credentials: CredentialSchema{Required: []CredentialKey{CredentialApiKey, CredentialFrom}}},
+		{ID: ProviderGenericSms, Channel: SMS, DisplayName: "Generic SMS",
+			Credentials: CredentialSchema{
+				Required: []CredentialKey{CredentialBaseUrl, CredentialFrom},
+				Optional: []CredentialKey{CredentialApiKey, CredentialSecretKey},
+			}},
+
+		{ID: ProviderSlack, Channel: CHAT, DisplayName: "Slack",
+			Credentials: CredentialSchema{
+				Optional: []CredentialKey{CredentialApplicationID, CredentialClientID, CredentialSecretKey, CredentialRedirectUrl, CredentialHmac},
+			}},
+		{ID: ProviderDiscord, Channel: CHAT, DisplayName: "Discord"},
+		{ID: ProviderMsTeams, Channel: CHAT, DisplayName: "MS Teams"},
+		{ID: ProviderMattermost, Channel: CHAT, DisplayName: "Mattermost"},
+		{ID: ProviderRyver, Channel: CHAT, DisplayName: "Ryver"},
+		{ID: ProviderZulip, Channel: CHAT, DisplayName: "Zulip"},
+		{ID: ProviderGrafanaOnCall, Channel: CHAT, DisplayName: "Grafana On Call Webhook",
+			Credentials: CredentialSchema{Optional: []CredentialKey{CredentialSecretKey}}},
+		{ID: ProviderGetStream, Channel: CHAT, DisplayName: "GetStream",
+			Credentials: CredentialSchema{Required: []CredentialKey{CredentialApiKey}}},
+		{ID: ProviderRocketChat, Channel: CHAT, DisplayName: "Rocket.Chat",
+			Credentials: CredentialSchema{Required: []CredentialKey{CredentialToken, CredentialUser}}},
+		{ID: ProviderWhatsAppBusiness, Channel: CHAT, DisplayName: "WhatsApp Business",
+			Credentials: CredentialSchema{Required: []CredentialKey{CredentialApiKey, CredentialApplicationID}}},
+		{ID: ProviderChatWebhook, Channel: CHAT, DisplayName: "Chat Webhook",
+			Credentials: CredentialSchema{Required: []CredentialKey{CredentialWebhookUrl, CredentialSecretKey}}},
+
+		{ID: ProviderFCM, Channel: PUSH, DisplayName: "Firebase Cloud Messaging",
+			Credentials: CredentialSchema{Required: []CredentialKey{CredentialServiceAccount}}},
+		{ID: ProviderAPNS, Channel: PUSH, DisplayName: "APNS",
+			Credentials: CredentialSchema{
+				Required: []CredentialKey{CredentialSecretKey, CredentialApiKey, CredentialProjectName, CredentialApplicationID},
+				Optional: []CredentialKey{CredentialSecure},
+			}},
+		{ID: ProviderExpo, Channel: PUSH, DisplayName: "Expo Push",
+			Credentials: CredentialSchema{Required: []CredentialKey{CredentialApiKey}}},
+		{ID: ProviderOneSignal, Channel: PUSH, DisplayName: "OneSignal",
+			Credentials: CredentialSchema{Required: []CredentialKey{CredentialApplicationID, CredentialApiKey}}},
+		{ID: ProviderPushpad, Channel: PUSH, DisplayName: "Pushpad",
+			Credentials: CredentialSchema{Required: []CredentialKey{CredentialApiKey, CredentialApplicationID}}},
+		{ID: ProviderPusherBeams, Channel: PUSH, DisplayName: "Pusher Beams",
+			Credentials: CredentialSchema{Required: []CredentialKey{CredentialApplicationID, CredentialSecretKey}}},
+		{ID: ProviderPushWebhook, Channel: PUSH, DisplayName: "Push Webhook",
+			Credentials: CredentialSchema{Required: []CredentialKey{CredentialWebhookUrl, CredentialSecretKey}}},
+	}
+
+	providersByID = indexProviders(providers)
+)
+
+func withSender(keys ...CredentialKey) []CredentialKey {
+	return append(keys, emailSender...)
+}
+
+func indexProviders(list []Provider) map[ProviderIdType]Provider {
+	index := make(map[ProviderIdType]Provider, len(list))
+	for _, provider := range list {
+		index[provider.ID] = provider
+	}
+	return index
+}
+
+// Providers returns every registered provider, sorted by channel then ID.
+func Providers() []Provider {
+	list := append([]Provider(nil), providers...)
+	sort.SliceStable(list, func(i, j int) bool {
+		if list[i].Channel != list[j].Channel {
+			return list[i].Channel < list[j].Channel
+		}
+		return list[i].ID < list[j].ID
+	})
+	return list
+}
+
+// ProvidersByChannel returns the registered providers of a channel.
+func ProvidersByChannel(channel ChannelType) []Provider {
+	var list []Provider
+	for _, provider := range Providers() {
+		if provider.Channel == channel {
+			list = append(list, provider)
+		}
+	}
+	return list
+}
+
+// GetProvider returns the provider registered for id.
+func GetProvider(id ProviderIdType) (Provider, bool) {
+	provider, ok := providersByID[id]
+	return provider, ok
+}
+
+// IsValid reports whether p is a provider known by the registry.
+func (p ProviderIdType) IsValid() bool {
+	_, ok := GetProvider(p)
+	return ok
+}
+
+// validateProviderChannel checks that a known provider is used on its own channel.
+func validateProviderChannel(providerID ProviderIdType, channel ChannelType) error {
+	provider, ok := GetProvider(providerID)
+	if !ok || channel == "" {
+		return nil
+	}
+	if provider.Channel != channel {
+		return errors.Errorf("provider %s delivers on the %s channel, not %s", providerID, provider.Channel, channel)
+	}
+	return nil
+}
+
+// Validate checks that the credentials target a chat or push provider, the only
+// channels where subscribers hold their own delivery credentials.
+func (p SubscriberCredentialPayload) Validate() error {
+	provider, ok := GetProvider(p.ProviderId)
+	if !ok {
+		return nil
+	}
+	if channel, _ := GetChannel(provider.Channel); !channel.SubscriberCredentials {
+		return errors.Errorf("provider %s is a %s provider, subscriber credentials are only supported on chat and push", p.ProviderId, provider.Channel)
+	}
+	return nil
+}
+
+func validatePreferenceChannels(preferences []UpdateSubscriberPreferencesChannel) error {
+	for _, preference := range preferences {
+		if !preference.Type.IsValid() {
+			return errors.Errorf("unknown preference channel %q", preference.Type)
+		}
+	}
+	return nil
+}
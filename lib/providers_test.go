@@ -0,0 +1,100 @@
+package lib_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/novuhq/go-novu/lib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChannels(t *testing.T) {
+	var types []lib.ChannelType
+	for _, channel := range lib.Channels() {
+		types = append(types, channel.Type)
+	}
+
+	assert.ElementsMatch(t, []lib.ChannelType{lib.IN_APP, lib.EMAIL, lib.SMS, lib.CHAT, lib.PUSH}, types)
+	assert.True(t, lib.PUSH.IsValid())
+	assert.False(t, lib.DIRECT.IsValid())
+}
+
+func TestProviders(t *testing.T) {
+	seen := map[lib.ProviderIdType]bool{}
+	for _, provider := range lib.Providers() {
+		assert.False(t, seen[provider.ID], "provider %s registered twice", provider.ID)
+		seen[provider.ID] = true
+
+		assert.True(t, provider.Channel.IsValid(), "provider %s has an unknown channel", provider.ID)
+		assert.NotEmpty(t, provider.DisplayName)
+	}
+
+	provider, ok := lib.GetProvider(lib.ProviderSendGrid)
+	require.True(t, ok)
+	assert.Equal(t, lib.EMAIL, provider.Channel)
+	assert.True(t, provider.SupportsWebhooks)
+	assert.Contains(t, provider.Credentials.Required, lib.CredentialApiKey)
+
+	for _, provider := range lib.ProvidersByChannel(lib.PUSH) {
+		assert.Equal(t, lib.PUSH, provider.Channel)
+	}
+	assert.True(t, lib.ProviderOneSignal.IsValid())
+	assert.False(t, lib.ProviderIdType("carrier-pigeon").IsValid())
+}
+
+func TestSubscriberCredentialPayload_Validate(t *testing.T) {
+	assert.NoError(t, lib.SubscriberCredentialPayload{ProviderId: lib.ProviderDiscord}.Validate())
+	assert.NoError(t, lib.SubscriberCredentialPayload{ProviderId: lib.ProviderFCM}.Validate())
+	assert.Error(t, lib.SubscriberCredentialPayload{ProviderId: lib.ProviderSendGrid}.Validate())
+}
+
+func TestRegistryIsUsedByServices(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	c := lib.NewAPIClient(novuApiKey, &lib.Config{BackendURL: lib.MustParseURL(server.URL)})
+
+	_, err := c.IntegrationsApi.Create(ctx, lib.CreateIntegrationRequest{ProviderID: "twilio", Channel: lib.EMAIL})
+	assert.Error(t, err)
+
+	_, err = c.SubscriberApi.UpdateCredentials(ctx, subscriberID, lib.SubscriberCredentialPayload{ProviderId: lib.ProviderTwilio})
+	assert.Error(t, err)
+
+	_, err = c.SubscriberApi.UpdateGlobalPreferences(ctx, subscriberID, &lib.UpdateSubscriberGlobalPreferencesOptions{
+		Preferences: []lib.UpdateSubscriberPreferencesChannel{{Type: lib.DIRECT, Enabled: true}},
+	})
+	assert.Error(t, err)
+}
+
+func TestIntegrationService_Create_DefaultsChannel(t *testing.T) {
+	httpServer := IntegrationTestServer(t, IntegrationServerOptions[lib.CreateIntegrationRequest]{
+		ExpectedRequest: IntegrationRequestDetails[lib.CreateIntegrationRequest]{
+			Url:    "/v1/integrations",
+			Method: http.MethodPost,
+			Body: lib.CreateIntegrationRequest{
+				ProviderID:  "fcm",
+				Channel:     lib.PUSH,
+				Credentials: lib.IntegrationCredentials{ServiceAccount: "{}"},
+				Active:      true,
+			},
+		},
+		ExpectedResponse: IntegrationResponseDetails{
+			StatusCode: http.StatusCreated,
+			Body:       &lib.IntegrationResponse{},
+		},
+	})
+
+	c := lib.NewAPIClient(novuApiKey, &lib.Config{BackendURL: lib.MustParseURL(httpServer.URL)})
+	_, err := c.IntegrationsApi.Create(context.Background(), lib.CreateIntegrationRequest{
+		ProviderID:  "fcm",
+		Credentials: lib.IntegrationCredentials{ServiceAccount: "{}"},
+		Active:      true,
+	})
+	require.NoError(t, err)
+}
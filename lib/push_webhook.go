@@ -0,0 +1,220 @@
+package lib
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	PushWebhookSignatureHeader = "X-Novu-Signature"
+	PushWebhookTimestampHeader = "X-Novu-Timestamp"
+
+	defaultPushWebhookTolerance    = 5 * time.Minute
+	defaultPushWebhookReplayWindow = 10 * time.Minute
+	defaultPushWebhookMaxReplays   = 10000
+	defaultPushWebhookMaxBodyBytes = 1 << 20
+)
+
+// PushWebhookHandlerFunc delivers a push received from the push-webhook
+// provider. Returning an error makes the handler answer with a server error,
+// so Novu records the delivery as failed.
+type PushWebhookHandlerFunc func(ctx context.Context, push *PushWebhookPayload) error
+
+type PushWebhookOptions struct {
+	// ReplayWindow is how long the signature of a delivered request is
+	// remembered, to reject the same request sent again. It defaults to 10
+	// minutes; a negative value disables the check. Pushes with identical
+	// bodies within the window are rejected as well, since the provider signs
+	// the body only. Signatures are remembered in memory: a replay sent to
+	// another process behind the same url is not detected.
+	ReplayWindow time.Duration
+	// MaxReplays bounds the number of signatures remembered, the oldest being
+	// forgotten first. It defaults to 10000.
+	MaxReplays int
+	// RequireTimestamp rejects the requests without an X-Novu-Timestamp
+	// header. Requests that carry one are signed over the timestamp and the
+	// body, and are rejected when it is out of Tolerance.
+	RequireTimestamp bool
+	// Tolerance is how far the timestamp of a request may be from the current
+	// time. It defaults to 5 minutes.
+	Tolerance    time.Duration
+	MaxBodyBytes int64
+	// Now replaces time.Now, for tests.
+	Now func() time.Time
+}
+
+// PushWebhookPayload is the push Novu posts to the webhook url of a
+// push-webhook integration.
+type PushWebhookPayload struct {
+	Target     []string               `json:"target"`
+	Title      string                 `json:"title"`
+	Content    string                 `json:"content"`
+	Payload    map[string]interface{} `json:"payload,omitempty"`
+	Overrides  map[string]interface{} `json:"overrides,omitempty"`
+	Subscriber *SubscriberPayload     `json:"subscriber,omitempty"`
+}
+
+// PushWebhookHandler is an http.Handler receiving the pushes of a push-webhook
+// integration. It verifies the X-Novu-Signature HMAC of the body made with the
+// secret key of the integration, and rejects requests replayed with the same
+// signature or with a timestamp out of tolerance.
+type PushWebhookHandler struct {
+	secret []byte
+	handle PushWebhookHandlerFunc
+	opts   PushWebhookOptions
+
+	mu sync.Mutex
+	// seen holds the signatures of the delivered requests, and order the same
+	// signatures oldest first, to forget them.
+	seen  map[string]time.Time
+	order []pushWebhookSignature
+}
+
+type pushWebhookSignature struct {
+	signature string
+	at        time.Time
+}
+
+// NewPushWebhookHandler returns a handler verifying requests with secret, the
+// SecretKey credential of the integration, and passing the pushes to fn.
+func NewPushWebhookHandler(secret string, fn PushWebhookHandlerFunc, opts *PushWebhookOptions) *PushWebhookHandler {
+	h := &PushWebhookHandler{secret: []byte(secret), handle: fn, seen: map[string]time.Time{}}
+	if opts != nil {
+		h.opts = *opts
+	}
+	if h.opts.Tolerance <= 0 {
+		h.opts.Tolerance = defaultPushWebhookTolerance
+	}
+	if h.opts.ReplayWindow == 0 {
+		h.opts.ReplayWindow = defaultPushWebhookReplayWindow
+	}
+	if h.opts.MaxReplays <= 0 {
+		h.opts.MaxReplays = defaultPushWebhookMaxReplays
+	}
+	if h.opts.MaxBodyBytes <= 0 {
+		h.opts.MaxBodyBytes = defaultPushWebhookMaxBodyBytes
+	}
+	if h.opts.Now == nil {
+		h.opts.Now = time.Now
+	}
+	return h
+}
+
+// PushWebhookSignature returns the hex encoded HMAC-SHA256 of body signed at
+// timestamp, in unix seconds. A zero timestamp signs the body only.
+func PushWebhookSignature(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	if timestamp != 0 {
+		mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	}
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (h *PushWebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, h.opts.MaxBodyBytes))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid push payload: %v", err), http.StatusBadRequest)
+		return
+	}
+	signature := r.Header.Get(PushWebhookSignatureHeader)
+	if err := h.verify(signature, r.Header.Get(PushWebhookTimestampHeader), body); err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	var push PushWebhookPayload
+	if err := json.Unmarshal(body, &push); err != nil {
+		h.forget(signature)
+		http.Error(w, fmt.Sprintf("invalid push payload: %v", err), http.StatusBadRequest)
+		return
+	}
+	if err := h.handle(r.Context(), &push); err != nil {
+		// Novu may retry the delivery with the same signature.
+		h.forget(signature)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *PushWebhookHandler) verify(signature, timestamp string, body []byte) error {
+	if signature == "" {
+		return fmt.Errorf("missing %s header", PushWebhookSignatureHeader)
+	}
+
+	var ts int64
+	if timestamp != "" || h.opts.RequireTimestamp {
+		var err error
+		if ts, err = strconv.ParseInt(timestamp, 10, 64); err != nil || ts == 0 {
+			return fmt.Errorf("invalid %s header", PushWebhookTimestampHeader)
+		}
+	}
+
+	expected := PushWebhookSignature(string(h.secret), ts, body)
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return fmt.Errorf("signature mismatch")
+	}
+
+	now := h.opts.Now()
+	window := h.opts.ReplayWindow
+	if ts != 0 {
+		if math.Abs(float64(now.Unix()-ts)) > h.opts.Tolerance.Seconds() {
+			return fmt.Errorf("timestamp out of tolerance")
+		}
+		// Older requests are rejected by their timestamp.
+		if window < 2*h.opts.Tolerance {
+			window = 2 * h.opts.Tolerance
+		}
+	} else if window < 0 {
+		return nil
+	}
+	return h.remember(signature, now, window)
+}
+
+// remember rejects the signatures already received within window.
+func (h *PushWebhookHandler) remember(signature string, now time.Time, window time.Duration) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for len(h.order) > 0 {
+		oldest := h.order[0]
+		// Forgotten signatures may have been seen again since.
+		if at, ok := h.seen[oldest.signature]; ok && at.Equal(oldest.at) {
+			if now.Sub(at) <= window && len(h.seen) < h.opts.MaxReplays {
+				break
+			}
+			delete(h.seen, oldest.signature)
+		}
+		h.order = h.order[1:]
+	}
+	if _, ok := h.seen[signature]; ok {
+		return fmt.Errorf("replayed request")
+	}
+	h.seen[signature] = now
+	h.order = append(h.order, pushWebhookSignature{signature, now})
+	return nil
+}
+
+// forget accepts the signature again, for requests that were not delivered.
+func (h *PushWebhookHandler) forget(signature string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.seen, signature)
+}
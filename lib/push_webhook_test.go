@@ -0,0 +1,170 @@
+package lib_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/novuhq/go-novu/lib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const pushWebhookSecret = "push-secret"
+
+var pushWebhookBody = `{
+	"target": ["device-token"],
+	"title": "New comment",
+	"content": "Jane replied to your comment",
+	"payload": {"commentId": "c1"},
+	"overrides": {"badge": 1},
+	"subscriber": {"subscriberId": "sub-1", "firstName": "John"}
+}`
+
+func pushRequest(body string, timestamp int64, signature string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/novu/push", strings.NewReader(body))
+	if signature != "" {
+		req.Header.Set(lib.PushWebhookSignatureHeader, signature)
+	}
+	if timestamp != 0 {
+		req.Header.Set(lib.PushWebhookTimestampHeader, strconv.FormatInt(timestamp, 10))
+	}
+	return req
+}
+
+func TestPushWebhookHandler(t *testing.T) {
+	now := time.Date(2023, 9, 1, 12, 0, 0, 0, time.UTC)
+	var received []*lib.PushWebhookPayload
+	h := lib.NewPushWebhookHandler(pushWebhookSecret, func(ctx context.Context, push *lib.PushWebhookPayload) error {
+		received = append(received, push)
+		if push.Title == "fail" {
+			return errors.New("delivery failed: token=secret")
+		}
+		return nil
+	}, &lib.PushWebhookOptions{Now: func() time.Time { return now }})
+
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// The push-webhook provider signs the body only.
+	signature := lib.PushWebhookSignature(pushWebhookSecret, 0, []byte(pushWebhookBody))
+
+	t.Run("Signed push is delivered", func(t *testing.T) {
+		rec := serve(pushRequest(pushWebhookBody, 0, signature))
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		require.Len(t, received, 1)
+		assert.Equal(t, &lib.PushWebhookPayload{
+			Target:     []string{"device-token"},
+			Title:      "New comment",
+			Content:    "Jane replied to your comment",
+			Payload:    map[string]interface{}{"commentId": "c1"},
+			Overrides:  map[string]interface{}{"badge": float64(1)},
+			Subscriber: &lib.SubscriberPayload{SubscriberId: "sub-1", FirstName: "John"},
+		}, received[0])
+	})
+
+	t.Run("Replayed push is rejected", func(t *testing.T) {
+		rec := serve(pushRequest(pushWebhookBody, 0, signature))
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.Contains(t, rec.Body.String(), "replayed")
+		assert.Len(t, received, 1)
+	})
+
+	tests := map[string]*http.Request{
+		"missing signature": pushRequest(pushWebhookBody, 0, ""),
+		"wrong secret":      pushRequest(pushWebhookBody, 0, lib.PushWebhookSignature("other", 0, []byte(pushWebhookBody))),
+		"tampered body":     pushRequest(strings.Replace(pushWebhookBody, "Jane", "Eve", 1), 0, signature),
+	}
+	for name, req := range tests {
+		t.Run("Rejects "+name, func(t *testing.T) {
+			assert.Equal(t, http.StatusUnauthorized, serve(req).Code)
+		})
+	}
+	assert.Len(t, received, 1)
+
+	t.Run("Replays are accepted after the window", func(t *testing.T) {
+		now = now.Add(11 * time.Minute)
+		rec := serve(pushRequest(pushWebhookBody, 0, signature))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Len(t, received, 2)
+	})
+
+	t.Run("Delivery errors are server errors", func(t *testing.T) {
+		body := `{"title": "fail"}`
+		req := func() *http.Request {
+			return pushRequest(body, 0, lib.PushWebhookSignature(pushWebhookSecret, 0, []byte(body)))
+		}
+		rec := serve(req())
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		assert.NotContains(t, rec.Body.String(), "token=secret")
+
+		// Novu retries failed deliveries with the same signature.
+		assert.Equal(t, http.StatusInternalServerError, serve(req()).Code)
+		assert.Len(t, received, 4)
+	})
+
+	t.Run("Invalid JSON is rejected", func(t *testing.T) {
+		body := `{"title":`
+		rec := serve(pushRequest(body, 0, lib.PushWebhookSignature(pushWebhookSecret, 0, []byte(body))))
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
+
+func TestPushWebhookHandler_Timestamp(t *testing.T) {
+	now := time.Date(2023, 9, 1, 12, 0, 0, 0, time.UTC)
+	delivered := 0
+	h := lib.NewPushWebhookHandler(pushWebhookSecret, func(ctx context.Context, push *lib.PushWebhookPayload) error {
+		delivered++
+		return nil
+	}, &lib.PushWebhookOptions{RequireTimestamp: true, ReplayWindow: -1, Now: func() time.Time { return now }})
+	serve := func(req *http.Request) int {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	ts := now.Add(-time.Minute).Unix()
+	signature := lib.PushWebhookSignature(pushWebhookSecret, ts, []byte(pushWebhookBody))
+	assert.Equal(t, http.StatusOK, serve(pushRequest(pushWebhookBody, ts, signature)))
+	// Replays within the tolerance are rejected even without a replay window.
+	assert.Equal(t, http.StatusUnauthorized, serve(pushRequest(pushWebhookBody, ts, signature)))
+
+	stale := now.Add(-10 * time.Minute).Unix()
+	tests := map[string]*http.Request{
+		"missing timestamp":  pushRequest(pushWebhookBody, 0, lib.PushWebhookSignature(pushWebhookSecret, 0, []byte(pushWebhookBody))),
+		"tampered timestamp": pushRequest(pushWebhookBody, ts+1, signature),
+		"stale timestamp":    pushRequest(pushWebhookBody, stale, lib.PushWebhookSignature(pushWebhookSecret, stale, []byte(pushWebhookBody))),
+	}
+	for name, req := range tests {
+		t.Run("Rejects "+name, func(t *testing.T) {
+			assert.Equal(t, http.StatusUnauthorized, serve(req))
+		})
+	}
+	assert.Equal(t, 1, delivered)
+}
+
+func TestPushWebhookHandler_MaxReplays(t *testing.T) {
+	h := lib.NewPushWebhookHandler(pushWebhookSecret, func(ctx context.Context, push *lib.PushWebhookPayload) error {
+		return nil
+	}, &lib.PushWebhookOptions{MaxReplays: 2})
+	serve := func(body string) int {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, pushRequest(body, 0, lib.PushWebhookSignature(pushWebhookSecret, 0, []byte(body))))
+		return rec.Code
+	}
+
+	for _, title := range []string{"a", "b", "c"} {
+		assert.Equal(t, http.StatusOK, serve(`{"title": "`+title+`"}`))
+	}
+	assert.Equal(t, http.StatusUnauthorized, serve(`{"title": "c"}`))
+	// The oldest signature was forgotten to stay within two.
+	assert.Equal(t, http.StatusOK, serve(`{"title": "a"}`))
+}
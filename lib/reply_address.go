@@ -0,0 +1,287 @@
+package lib
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"net/mail"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+// MaxReplyLocalPartLength is the longest local part RFC 5321 allows.
+const MaxReplyLocalPartLength = 64
+
+const (
+	defaultReplyPrefix = "reply"
+	replyMACSize       = 10
+	replyKeySize       = 10
+
+	// The first byte of a payload tells how the context is encoded.
+	replyInline byte = 1
+	replyStored byte = 2
+
+	// Inline fields start with their type.
+	replyFieldString   byte = 0
+	replyFieldObjectId byte = 1
+	replyFieldUUID     byte = 2
+)
+
+var (
+	// ErrInvalidReplyAddress is returned for reply addresses that were not
+	// signed with the secret of the signer or were altered.
+	ErrInvalidReplyAddress = errors.New("invalid reply address")
+	// ErrReplyAddressTooLong is returned when a context is too long to be
+	// encoded in a local part, which happens without a ReplyContextStore.
+	ErrReplyAddressTooLong = errors.New("reply address exceeds 64 characters")
+)
+
+// Base32 without padding survives mail systems that change the case of the
+// local part once lowercased.
+var replyEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// ReplyContext is what a reply address points back to.
+type ReplyContext struct {
+	ThreadId      string
+	SubscriberId  string
+	TransactionId string
+}
+
+// ReplyContextStore keeps the contexts of reply addresses on the server, so
+// that the addresses only carry a short random key.
+type ReplyContextStore interface {
+	SaveReplyContext(ctx context.Context, key string, reply ReplyContext) error
+	// LoadReplyContext returns nil when the key is unknown.
+	LoadReplyContext(ctx context.Context, key string) (*ReplyContext, error)
+}
+
+// ReplyAddressSigner generates reply-to addresses pointing to a ReplyContext
+// and signed with an HMAC, and decodes them back when the reply is received.
+//
+// Addresses look like reply+<token>.<signature>@<domain>, where the local part
+// never exceeds the 64 characters allowed by RFC 5321. With a Store, the token
+// is a random key under which the context is saved. Without one, the context
+// is encoded in the token, Mongo ids and UUIDs in binary, and addresses of
+// contexts too long to fit are refused with ErrReplyAddressTooLong.
+type ReplyAddressSigner struct {
+	// Prefix starts the local part, it defaults to "reply".
+	Prefix string
+	// Store keeps the contexts of the addresses when set.
+	Store  ReplyContextStore
+	secret []byte
+	domain string
+}
+
+// NewReplyAddressSigner returns a signer for addresses of the inbound domain.
+func NewReplyAddressSigner(secret, domain string) *ReplyAddressSigner {
+	return &ReplyAddressSigner{secret: []byte(secret), domain: strings.ToLower(domain)}
+}
+
+func (s *ReplyAddressSigner) prefix() string {
+	if s.Prefix == "" {
+		return defaultReplyPrefix
+	}
+	return strings.ToLower(s.Prefix)
+}
+
+// LocalPart returns the signed local part pointing to reply, saving it in the
+// Store when there is one.
+func (s *ReplyAddressSigner) LocalPart(ctx context.Context, reply ReplyContext) (string, error) {
+	var payload []byte
+	if s.Store != nil {
+		key := make([]byte, replyKeySize)
+		if _, err := rand.Read(key); err != nil {
+			return "", errors.Wrap(err, "unable to generate reply key")
+		}
+		if err := s.Store.SaveReplyContext(ctx, encodeReply(key), reply); err != nil {
+			return "", errors.Wrap(err, "unable to save reply context")
+		}
+		payload = append([]byte{replyStored}, key...)
+	} else {
+		payload = []byte{replyInline}
+		for _, field := range []string{reply.ThreadId, reply.SubscriberId, reply.TransactionId} {
+			if len(field) > 255 {
+				return "", ErrReplyAddressTooLong
+			}
+			payload = appendReplyField(payload, field)
+		}
+	}
+
+	localPart := s.prefix() + "+" + encodeReply(payload) + "." + encodeReply(s.sign(payload))
+	if len(localPart) > MaxReplyLocalPartLength {
+		return "", errors.Wrapf(ErrReplyAddressTooLong, "%d characters, set a Store to shorten it", len(localPart))
+	}
+	return localPart, nil
+}
+
+// Address returns the signed reply-to address pointing to reply.
+func (s *ReplyAddressSigner) Address(ctx context.Context, reply ReplyContext) (string, error) {
+	localPart, err := s.LocalPart(ctx, reply)
+	if err != nil {
+		return "", err
+	}
+	return localPart + "@" + s.domain, nil
+}
+
+// Parse verifies a reply address, with or without a display name, and
+// returns the context it points to.
+func (s *ReplyAddressSigner) Parse(ctx context.Context, address string) (*ReplyContext, error) {
+	if parsed, err := mail.ParseAddress(address); err == nil {
+		address = parsed.Address
+	}
+	at := strings.LastIndex(address, "@")
+	if at < 0 {
+		return nil, errors.Wrap(ErrInvalidReplyAddress, "missing domain")
+	}
+	if !strings.EqualFold(address[at+1:], s.domain) {
+		return nil, errors.Wrapf(ErrInvalidReplyAddress, "domain %s", address[at+1:])
+	}
+	return s.ParseLocalPart(ctx, address[:at])
+}
+
+// ParseLocalPart verifies a signed local part and returns the context it
+// points to.
+func (s *ReplyAddressSigner) ParseLocalPart(ctx context.Context, localPart string) (*ReplyContext, error) {
+	localPart = strings.ToLower(localPart)
+	token := strings.TrimPrefix(localPart, s.prefix()+"+")
+	if token == localPart {
+		return nil, errors.Wrap(ErrInvalidReplyAddress, "unknown prefix")
+	}
+
+	encodedPayload, encodedMAC, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, errors.Wrap(ErrInvalidReplyAddress, "missing signature")
+	}
+	payload, err := decodeReply(encodedPayload)
+	if err != nil {
+		return nil, errors.Wrap(ErrInvalidReplyAddress, err.Error())
+	}
+	mac, err := decodeReply(encodedMAC)
+	if err != nil {
+		return nil, errors.Wrap(ErrInvalidReplyAddress, err.Error())
+	}
+	if !hmac.Equal(mac, s.sign(payload)) {
+		return nil, errors.Wrap(ErrInvalidReplyAddress, "signature mismatch")
+	}
+
+	switch {
+	case len(payload) == 1+replyKeySize && payload[0] == replyStored:
+		if s.Store == nil {
+			return nil, errors.Wrap(ErrInvalidReplyAddress, "stored context without a store")
+		}
+		reply, err := s.Store.LoadReplyContext(ctx, encodeReply(payload[1:]))
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to load reply context")
+		}
+		if reply == nil {
+			return nil, errors.Wrap(ErrInvalidReplyAddress, "unknown context")
+		}
+		return reply, nil
+	case len(payload) > 0 && payload[0] == replyInline:
+		var fields [3]string
+		rest := payload[1:]
+		for i := range fields {
+			if fields[i], rest, ok = readReplyField(rest); !ok {
+				return nil, errors.Wrap(ErrInvalidReplyAddress, "malformed context")
+			}
+		}
+		if len(rest) > 0 {
+			return nil, errors.Wrap(ErrInvalidReplyAddress, "malformed context")
+		}
+		return &ReplyContext{ThreadId: fields[0], SubscriberId: fields[1], TransactionId: fields[2]}, nil
+	}
+	return nil, errors.Wrap(ErrInvalidReplyAddress, "malformed context")
+}
+
+// appendReplyField encodes Mongo ids and UUIDs in binary and other values as
+// length-prefixed strings.
+func appendReplyField(b []byte, field string) []byte {
+	if len(field) == 24 {
+		if id, err := hex.DecodeString(field); err == nil && hex.EncodeToString(id) == field {
+			return append(append(b, replyFieldObjectId), id...)
+		}
+	}
+	if id, err := uuid.Parse(field); err == nil && id.String() == field {
+		return append(append(b, replyFieldUUID), id[:]...)
+	}
+	return append(append(b, replyFieldString, byte(len(field))), field...)
+}
+
+func readReplyField(b []byte) (string, []byte, bool) {
+	if len(b) == 0 {
+		return "", nil, false
+	}
+	switch b[0] {
+	case replyFieldObjectId:
+		if len(b) < 13 {
+			return "", nil, false
+		}
+		return hex.EncodeToString(b[1:13]), b[13:], true
+	case replyFieldUUID:
+		if len(b) < 17 {
+			return "", nil, false
+		}
+		id, _ := uuid.FromBytes(b[1:17])
+		return id.String(), b[17:], true
+	case replyFieldString:
+		if len(b) < 2 || len(b) < 2+int(b[1]) {
+			return "", nil, false
+		}
+		n := 2 + int(b[1])
+		return string(b[2:n]), b[n:], true
+	}
+	return "", nil, false
+}
+
+// MemoryReplyContextStore is a ReplyContextStore keeping the contexts in
+// memory, which suits tests and single processes that need not remember them
+// across restarts.
+type MemoryReplyContextStore struct {
+	mu       sync.Mutex
+	contexts map[string]ReplyContext
+}
+
+func NewMemoryReplyContextStore() *MemoryReplyContextStore {
+	return &MemoryReplyContextStore{contexts: map[string]ReplyContext{}}
+}
+
+func (m *MemoryReplyContextStore) SaveReplyContext(ctx context.Context, key string, reply ReplyContext) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.contexts[key] = reply
+	return nil
+}
+
+func (m *MemoryReplyContextStore) LoadReplyContext(ctx context.Context, key string) (*ReplyContext, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	reply, ok := m.contexts[key]
+	if !ok {
+		return nil, nil
+	}
+	return &reply, nil
+}
+
+// sign binds the payload to the domain so an address can't be replayed on
+// another inbound domain sharing the secret.
+func (s *ReplyAddressSigner) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(s.domain))
+	mac.Write([]byte{0})
+	mac.Write(payload)
+	return mac.Sum(nil)[:replyMACSize]
+}
+
+func encodeReply(b []byte) string {
+	return strings.ToLower(replyEncoding.EncodeToString(b))
+}
+
+func decodeReply(s string) ([]byte, error) {
+	return replyEncoding.DecodeString(strings.ToUpper(s))
+}
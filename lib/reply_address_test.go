@@ -0,0 +1,140 @@
+package lib_test
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/novuhq/go-novu/lib"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// address returns the reply address of reply, failing the test on errors.
+func address(t *testing.T, signer *lib.ReplyAddressSigner, reply lib.ReplyContext) string {
+	address, err := signer.Address(context.Background(), reply)
+	require.NoError(t, err)
+	return address
+}
+
+func TestReplyAddressSigner_RoundTrip(t *testing.T) {
+	signer := lib.NewReplyAddressSigner("secret", "Inbound.Example.com")
+	signer.Store = lib.NewMemoryReplyContextStore()
+	reply := lib.ReplyContext{ThreadId: "64f1b2c3d4e5f6a7b8c9d0e1", SubscriberId: "0f8fad5b-d9cb-469f-a165-70867728950e", TransactionId: "0f8fad5b-d9cb-469f-a165-70867728950f"}
+
+	address := address(t, signer, reply)
+	assert.True(t, strings.HasPrefix(address, "reply+"))
+	assert.True(t, strings.HasSuffix(address, "@inbound.example.com"))
+	assert.Equal(t, strings.ToLower(address), address)
+	assert.LessOrEqual(t, strings.Index(address, "@"), lib.MaxReplyLocalPartLength)
+
+	for _, received := range []string{
+		address,
+		strings.ToUpper(address),
+		`"Novu replies" <` + address + `>`,
+	} {
+		got, err := signer.Parse(context.Background(), received)
+		require.NoError(t, err, received)
+		assert.Equal(t, &reply, got)
+	}
+
+	signer.Prefix = "thread"
+	localPart, err := signer.LocalPart(context.Background(), reply)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(localPart, "thread+"))
+}
+
+func TestReplyAddressSigner_Inline(t *testing.T) {
+	signer := lib.NewReplyAddressSigner("secret", "inbound.example.com")
+	ctx := context.Background()
+
+	for _, reply := range []lib.ReplyContext{
+		{ThreadId: "t-42", SubscriberId: "s1", TransactionId: "tx-1"},
+		// Mongo ids and UUIDs are encoded in binary.
+		{ThreadId: "64f1b2c3d4e5f6a7b8c9d0e1"},
+		{TransactionId: "0f8fad5b-d9cb-469f-a165-70867728950e"},
+	} {
+		localPart, err := signer.LocalPart(ctx, reply)
+		require.NoError(t, err)
+		assert.LessOrEqual(t, len(localPart), lib.MaxReplyLocalPartLength)
+		got, err := signer.ParseLocalPart(ctx, localPart)
+		require.NoError(t, err)
+		assert.Equal(t, &reply, got)
+	}
+
+	_, err := signer.LocalPart(ctx, lib.ReplyContext{ThreadId: "64f1b2c3d4e5f6a7b8c9d0e1", SubscriberId: "sub_1", TransactionId: "0f8fad5b-d9cb-469f-a165-70867728950e"})
+	assert.True(t, errors.Is(err, lib.ErrReplyAddressTooLong))
+}
+
+func TestReplyAddressSigner_Rejects(t *testing.T) {
+	signer := lib.NewReplyAddressSigner("secret", "inbound.example.com")
+	reply := lib.ReplyContext{ThreadId: "t", SubscriberId: "s", TransactionId: "x"}
+	localPart := strings.TrimSuffix(address(t, signer, reply), "@inbound.example.com")
+	payload, mac, _ := strings.Cut(strings.TrimPrefix(localPart, "reply+"), ".")
+	other := address(t, signer, lib.ReplyContext{ThreadId: "other"})
+	_, otherMAC, _ := strings.Cut(strings.TrimSuffix(other, "@inbound.example.com"), ".")
+
+	stored := lib.NewReplyAddressSigner("secret", "inbound.example.com")
+	stored.Store = lib.NewMemoryReplyContextStore()
+	storedAddress := address(t, stored, reply)
+
+	tests := map[string]string{
+		"other secret":     address(t, lib.NewReplyAddressSigner("other", "inbound.example.com"), reply),
+		"other domain":     localPart + "@evil.example.com",
+		"signed elsewhere": strings.TrimSuffix(address(t, lib.NewReplyAddressSigner("secret", "evil.example.com"), reply), "evil.example.com") + "inbound.example.com",
+		"swapped mac":      "reply+" + payload + "." + otherMAC + "@inbound.example.com",
+		"tampered payload": "reply+b" + payload[1:] + "." + mac + "@inbound.example.com",
+		"no signature":     "reply+" + payload + "@inbound.example.com",
+		"wrong prefix":     "bounce+" + payload + "." + mac + "@inbound.example.com",
+		"not base32":       "reply+!!." + mac + "@inbound.example.com",
+		"no domain":        "reply+" + payload + "." + mac,
+		"stored, no store": storedAddress,
+	}
+	for name, address := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := signer.Parse(context.Background(), address)
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, lib.ErrInvalidReplyAddress))
+		})
+	}
+
+	t.Run("unknown key", func(t *testing.T) {
+		stored.Store = lib.NewMemoryReplyContextStore()
+		_, err := stored.Parse(context.Background(), storedAddress)
+		assert.True(t, errors.Is(err, lib.ErrInvalidReplyAddress))
+	})
+}
+
+func TestInboundWebhookHandler_ReplyAddresses(t *testing.T) {
+	signer := lib.NewReplyAddressSigner("secret", "inbound.example.com")
+	signer.Store = lib.NewMemoryReplyContextStore()
+	ctx := lib.ReplyContext{ThreadId: "thread-42", SubscriberId: "sub_1", TransactionId: "tx-123"}
+
+	var received *lib.InboundWebhookPayload
+	h := lib.NewInboundWebhookHandler(func(ctx context.Context, payload *lib.InboundWebhookPayload) error {
+		received = payload
+		return nil
+	}, &lib.InboundWebhookOptions{ReplyAddresses: signer})
+
+	t.Run("Valid address is decoded", func(t *testing.T) {
+		body := `{"mail": {"to": [{"address": "team@example.com"}], "cc": [{"address": "` + strings.ToUpper(address(t, signer, ctx)) + `"}]}}`
+		rec := postInbound(t, h, body)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, &ctx, received.Reply)
+	})
+
+	t.Run("Tampered address is rejected", func(t *testing.T) {
+		received = nil
+		tampered := strings.Replace(address(t, signer, ctx), "reply+", "reply+a", 1)
+		rec := postInbound(t, h, `{"mail": {"to": [{"address": "`+tampered+`"}]}}`)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		assert.Nil(t, received)
+	})
+
+	t.Run("Missing address is rejected", func(t *testing.T) {
+		rec := postInbound(t, h, `{"mail": {"to": [{"address": "team@example.com"}]}}`)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+}
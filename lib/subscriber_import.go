@@ -0,0 +1,387 @@
+package lib
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// MaxSubscriberBulkSize is the largest number of subscribers the API creates
+// in one bulk request.
+const MaxSubscriberBulkSize = 500
+
+type SubscriberImportFormat string
+
+const (
+	// SubscriberImportCSV reads comma separated values with a header row.
+	SubscriberImportCSV SubscriberImportFormat = "csv"
+	// SubscriberImportJSONL reads a SubscriberPayload JSON object per line.
+	SubscriberImportJSONL SubscriberImportFormat = "jsonl"
+)
+
+type SubscriberImportOptions struct {
+	// Format of the input. It defaults to CSV.
+	Format SubscriberImportFormat
+	// Columns maps CSV headers to the JSON name of a SubscriberPayload field,
+	// such as "email", or to a key of its data prefixed with "data.". Headers
+	// missing from Columns are mapped to the field of the same name, ignoring
+	// case, and to data otherwise. Columns mapped to "" are ignored.
+	Columns map[string]string
+	// BatchSize is the number of subscribers per request. It defaults to and
+	// cannot exceed MaxSubscriberBulkSize.
+	BatchSize int
+	// Concurrency limits the number of requests in flight, it defaults to
+	// DefaultBulkConcurrency.
+	Concurrency int
+	// CheckpointFile records the number of records imported after each round
+	// of requests. An import given the checkpoint of an interrupted one skips
+	// the records it imported. The file is removed once the import completes.
+	CheckpointFile string
+	// OnBatch is called with the outcome of every batch, in input order.
+	OnBatch func(batch SubscriberImportBatch)
+}
+
+// SubscriberImportBatch is the outcome of a bulk request. Records are numbered
+// from 1, the CSV header excluded.
+type SubscriberImportBatch struct {
+	FirstRecord int
+	LastRecord  int
+	Created     []string
+	Updated     []string
+	Failed      []SubscriberImportFailure
+}
+
+// SubscriberImportFailure is a record that was not imported, either because
+// it is invalid or because the API rejected it.
+type SubscriberImportFailure struct {
+	Record       int    `json:"record,omitempty"`
+	SubscriberId string `json:"subscriberId,omitempty"`
+	Message      string `json:"message"`
+}
+
+// SubscriberImportResult counts the imported records, those of the resumed
+// import included.
+type SubscriberImportResult struct {
+	Records int `json:"records"`
+	Created int `json:"created"`
+	Updated int `json:"updated"`
+	Failed  int `json:"failed"`
+	// Resumed is the number of records skipped thanks to the checkpoint.
+	Resumed int `json:"-"`
+}
+
+// Import creates or updates the subscribers read from r in batches of bulk
+// requests. Invalid records and the subscribers rejected by the API are
+// reported as failed without stopping the import; a failed request stops it
+// and returns the error. Resuming from the checkpoint sends again the batches
+// of the round that failed, which updates the subscribers created by them.
+func (s *SubscriberService) Import(ctx context.Context, r io.Reader, opts *SubscriberImportOptions) (*SubscriberImportResult, error) {
+	o := SubscriberImportOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.BatchSize <= 0 {
+		o.BatchSize = MaxSubscriberBulkSize
+	}
+	if o.BatchSize > MaxSubscriberBulkSize {
+		return nil, errors.Errorf("batch size %d exceeds the maximum of %d", o.BatchSize, MaxSubscriberBulkSize)
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = DefaultBulkConcurrency
+	}
+
+	var records subscriberRecords
+	switch o.Format {
+	case SubscriberImportCSV, "":
+		cr, err := newCSVSubscriberRecords(r, o.Columns)
+		if err != nil {
+			return nil, err
+		}
+		records = cr
+	case SubscriberImportJSONL:
+		records = newJSONLSubscriberRecords(r)
+	default:
+		return nil, errors.Errorf("unknown import format %q", o.Format)
+	}
+
+	result := &SubscriberImportResult{}
+	if o.CheckpointFile != "" {
+		if err := readImportCheckpoint(o.CheckpointFile, result); err != nil {
+			return nil, err
+		}
+		for result.Resumed < result.Records {
+			if _, _, err := records.next(); err == io.EOF {
+				return nil, errors.Errorf("checkpoint %s is past the end of the input, at record %d", o.CheckpointFile, result.Records)
+			} else if err != nil {
+				return nil, err
+			}
+			result.Resumed++
+		}
+	}
+
+	for {
+		batches, payloads, err := readImportRound(records, result.Records, o.BatchSize, o.Concurrency)
+		if err != nil {
+			return result, err
+		}
+		if len(batches) == 0 {
+			break
+		}
+
+		errs := runConcurrently(ctx, len(batches), o.Concurrency, func(ctx context.Context, i int) error {
+			if len(payloads[i]) == 0 {
+				return nil
+			}
+			resp, err := s.BulkCreate(ctx, SubscriberBulkPayload{Subscribers: payloads[i]})
+			if err != nil {
+				return err
+			}
+			batches[i].addResponse(resp)
+			return nil
+		})
+		for i, err := range errs {
+			if err != nil {
+				return result, errors.Wrapf(err, "unable to import records %d to %d", batches[i].FirstRecord, batches[i].LastRecord)
+			}
+		}
+
+		for _, b := range batches {
+			result.Records = b.LastRecord
+			result.Created += len(b.Created)
+			result.Updated += len(b.Updated)
+			result.Failed += len(b.Failed)
+			if o.OnBatch != nil {
+				o.OnBatch(*b)
+			}
+		}
+		if o.CheckpointFile != "" {
+			if err := writeImportCheckpoint(o.CheckpointFile, result); err != nil {
+				return result, err
+			}
+		}
+	}
+
+	if o.CheckpointFile != "" {
+		if err := os.Remove(o.CheckpointFile); err != nil && !os.IsNotExist(err) {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// readImportRound reads up to n batches following the record last.
+func readImportRound(records subscriberRecords, last, size, n int) ([]*SubscriberImportBatch, [][]SubscriberPayload, error) {
+	var batches []*SubscriberImportBatch
+	var payloads [][]SubscriberPayload
+	for len(batches) < n {
+		batch := &SubscriberImportBatch{FirstRecord: last + 1, LastRecord: last}
+		var payload []SubscriberPayload
+		for len(payload) < size {
+			subscriber, invalid, err := records.next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, nil, err
+			}
+			last++
+			batch.LastRecord = last
+			if invalid == "" && subscriber.SubscriberId == "" {
+				invalid = "subscriberId is required"
+			}
+			if invalid != "" {
+				batch.Failed = append(batch.Failed, SubscriberImportFailure{Record: last, SubscriberId: subscriber.SubscriberId, Message: invalid})
+				continue
+			}
+			payload = append(payload, subscriber)
+		}
+		if batch.LastRecord < batch.FirstRecord {
+			break
+		}
+		batches = append(batches, batch)
+		payloads = append(payloads, payload)
+	}
+	return batches, payloads, nil
+}
+
+func (b *SubscriberImportBatch) addResponse(resp SubscriberBulkCreateResponse) {
+	for _, c := range resp.Data.Created {
+		b.Created = append(b.Created, c.SubscriberId)
+	}
+	for _, u := range resp.Data.Updated {
+		b.Updated = append(b.Updated, u.SubscriberId)
+	}
+	for _, f := range resp.Data.Failed {
+		var failure SubscriberImportFailure
+		bb, _ := json.Marshal(f)
+		if json.Unmarshal(bb, &failure) != nil || failure.Message == "" {
+			failure.Message = string(bb)
+		}
+		b.Failed = append(b.Failed, failure)
+	}
+}
+
+// subscriberRecords reads subscribers one record at a time. Records that
+// cannot be decoded are returned with the reason they are invalid.
+type subscriberRecords interface {
+	next() (subscriber SubscriberPayload, invalid string, err error)
+}
+
+var subscriberFields = map[string]func(s *SubscriberPayload, v string){
+	"subscriberid": func(s *SubscriberPayload, v string) { s.SubscriberId = v },
+	"firstname":    func(s *SubscriberPayload, v string) { s.FirstName = v },
+	"lastname":     func(s *SubscriberPayload, v string) { s.LastName = v },
+	"email":        func(s *SubscriberPayload, v string) { s.Email = v },
+	"phone":        func(s *SubscriberPayload, v string) { s.Phone = v },
+	"avatar":       func(s *SubscriberPayload, v string) { s.Avatar = v },
+	"locale":       func(s *SubscriberPayload, v string) { s.Locale = v },
+}
+
+type csvSubscriberRecords struct {
+	r       *csv.Reader
+	columns []func(s *SubscriberPayload, v string)
+}
+
+func newCSVSubscriberRecords(r io.Reader, mapping map[string]string) (*csvSubscriberRecords, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, errors.New("the CSV input has no header")
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid CSV header")
+	}
+
+	records := &csvSubscriberRecords{r: cr}
+	for i, name := range header {
+		if i == 0 {
+			name = strings.TrimPrefix(name, "\ufeff")
+		}
+		name = strings.TrimSpace(name)
+		target, ok := mapping[name]
+		if !ok {
+			target = name
+		}
+
+		var set func(s *SubscriberPayload, v string)
+		switch {
+		case target == "":
+		case strings.HasPrefix(target, "data."):
+			set = dataSetter(strings.TrimPrefix(target, "data."))
+		case subscriberFields[strings.ToLower(target)] != nil:
+			set = subscriberFields[strings.ToLower(target)]
+		case ok:
+			return nil, errors.Errorf("column %q is mapped to unknown field %q", name, target)
+		default:
+			set = dataSetter(target)
+		}
+		records.columns = append(records.columns, set)
+	}
+	return records, nil
+}
+
+func dataSetter(key string) func(s *SubscriberPayload, v string) {
+	return func(s *SubscriberPayload, v string) {
+		if s.Data == nil {
+			s.Data = map[string]interface{}{}
+		}
+		s.Data[key] = v
+	}
+}
+
+func (c *csvSubscriberRecords) next() (SubscriberPayload, string, error) {
+	var s SubscriberPayload
+	fields, err := c.r.Read()
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) && parseErr.Err != csv.ErrQuote {
+			return s, parseErr.Error(), nil
+		}
+		return s, "", err
+	}
+	if len(fields) > len(c.columns) {
+		return s, fmt.Sprintf("%d fields for %d columns", len(fields), len(c.columns)), nil
+	}
+	for i, v := range fields {
+		if c.columns[i] != nil && v != "" {
+			c.columns[i](&s, v)
+		}
+	}
+	return s, "", nil
+}
+
+type jsonlSubscriberRecords struct {
+	s *bufio.Scanner
+}
+
+func newJSONLSubscriberRecords(r io.Reader) *jsonlSubscriberRecords {
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 64*1024), 1<<20)
+	return &jsonlSubscriberRecords{s: s}
+}
+
+// next skips blank lines, which are not counted as records.
+func (j *jsonlSubscriberRecords) next() (SubscriberPayload, string, error) {
+	var s SubscriberPayload
+	for j.s.Scan() {
+		line := strings.TrimSpace(j.s.Text())
+		if line == "" {
+			continue
+		}
+		if err := json.Unmarshal([]byte(line), &s); err != nil {
+			return s, err.Error(), nil
+		}
+		return s, "", nil
+	}
+	if err := j.s.Err(); err != nil {
+		return s, "", err
+	}
+	return s, "", io.EOF
+}
+
+func readImportCheckpoint(path string, result *SubscriberImportResult) error {
+	bb, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(bb, result); err != nil {
+		return errors.Wrapf(err, "invalid checkpoint %s", path)
+	}
+	return nil
+}
+
+// writeImportCheckpoint replaces the checkpoint atomically, so that an
+// interruption never leaves a partial one.
+func writeImportCheckpoint(path string, result *SubscriberImportResult) error {
+	bb, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(bb); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
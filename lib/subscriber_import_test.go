@@ -0,0 +1,168 @@
+package lib_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/novuhq/go-novu/lib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// bulkServer answers bulk requests by creating every subscriber except those
+// whose id starts with "reject", and fails the requests for which fail
+// returns true.
+func bulkServer(t *testing.T, fail func(payload lib.SubscriberBulkPayload) bool) (*lib.APIClient, *[]lib.SubscriberBulkPayload) {
+	var mu sync.Mutex
+	var received []lib.SubscriberBulkPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "/v1/subscribers/bulk", req.URL.Path)
+		var payload lib.SubscriberBulkPayload
+		require.NoError(t, json.NewDecoder(req.Body).Decode(&payload))
+		if fail != nil && fail(payload) {
+			w.WriteHeader(http.StatusBadGateway)
+			w.Write([]byte(`{"message": "bad gateway"}`))
+			return
+		}
+		mu.Lock()
+		received = append(received, payload)
+		mu.Unlock()
+
+		var resp lib.SubscriberBulkCreateResponse
+		var failed []interface{}
+		for _, s := range payload.Subscribers {
+			if strings.HasPrefix(s.SubscriberId, "reject") {
+				failed = append(failed, map[string]string{"subscriberId": s.SubscriberId, "message": "invalid email"})
+				continue
+			}
+			resp.Data.Created = append(resp.Data.Created, struct {
+				SubscriberId string `json:"subscriberId"`
+			}{s.SubscriberId})
+		}
+		resp.Data.Failed = failed
+		json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(server.Close)
+	return lib.NewAPIClient(novuApiKey, &lib.Config{BackendURL: lib.MustParseURL(server.URL)}), &received
+}
+
+func TestSubscriberService_Import_CSV(t *testing.T) {
+	c, received := bulkServer(t, nil)
+	input := "\ufeffID,Email,first name,plan,ignored\n" +
+		"sub-1,jane@example.com,Jane,pro,x\n" +
+		",nobody@example.com,,,\n" +
+		"reject-1,not-an-email,,,\n" +
+		"sub-2,john@example.com,John,free,y\n"
+
+	var batches []lib.SubscriberImportBatch
+	result, err := c.SubscriberApi.Import(context.Background(), strings.NewReader(input), &lib.SubscriberImportOptions{
+		Columns:   map[string]string{"ID": "subscriberId", "first name": "firstName", "plan": "data.plan", "ignored": ""},
+		BatchSize: 2,
+		OnBatch:   func(batch lib.SubscriberImportBatch) { batches = append(batches, batch) },
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, &lib.SubscriberImportResult{Records: 4, Created: 2, Failed: 2}, result)
+	assert.Equal(t, []lib.SubscriberImportBatch{
+		{FirstRecord: 1, LastRecord: 3, Created: []string{"sub-1"}, Failed: []lib.SubscriberImportFailure{
+			{Record: 2, Message: "subscriberId is required"},
+			{SubscriberId: "reject-1", Message: "invalid email"},
+		}},
+		{FirstRecord: 4, LastRecord: 4, Created: []string{"sub-2"}},
+	}, batches)
+
+	// The batches are sent concurrently.
+	require.Len(t, *received, 2)
+	var subscribers []lib.SubscriberPayload
+	for _, payload := range *received {
+		subscribers = append(subscribers, payload.Subscribers...)
+	}
+	assert.Contains(t, subscribers, lib.SubscriberPayload{
+		SubscriberId: "sub-1",
+		Email:        "jane@example.com",
+		FirstName:    "Jane",
+		Data:         map[string]interface{}{"plan": "pro"},
+	})
+}
+
+func TestSubscriberService_Import_JSONL(t *testing.T) {
+	c, received := bulkServer(t, nil)
+	input := `{"subscriberId": "sub-1", "email": "jane@example.com", "data": {"plan": "pro", "seats": 3}}
+
+{"subscriberId": "sub-2", "locale": "fr"}
+{"subscriberId":
+`
+	result, err := c.SubscriberApi.Import(context.Background(), strings.NewReader(input), &lib.SubscriberImportOptions{Format: lib.SubscriberImportJSONL})
+	require.NoError(t, err)
+	assert.Equal(t, &lib.SubscriberImportResult{Records: 3, Created: 2, Failed: 1}, result)
+	require.Len(t, *received, 1)
+	assert.Equal(t, map[string]interface{}{"plan": "pro", "seats": float64(3)}, (*received)[0].Subscribers[0].Data)
+	assert.Equal(t, "fr", (*received)[0].Subscribers[1].Locale)
+}
+
+func TestSubscriberService_Import_Resume(t *testing.T) {
+	var input strings.Builder
+	input.WriteString("subscriberId,email\n")
+	for i := 0; i < 10; i++ {
+		input.WriteString("sub-" + string(rune('a'+i)) + ",user@example.com\n")
+	}
+	checkpoint := filepath.Join(t.TempDir(), "import.checkpoint")
+
+	// The batch holding sub-g fails: the first round of two batches (a-d)
+	// completes, the second one (e-h) does not.
+	c, _ := bulkServer(t, func(payload lib.SubscriberBulkPayload) bool {
+		for _, s := range payload.Subscribers {
+			if s.SubscriberId == "sub-g" {
+				return true
+			}
+		}
+		return false
+	})
+	opts := &lib.SubscriberImportOptions{BatchSize: 2, Concurrency: 2, CheckpointFile: checkpoint}
+	result, err := c.SubscriberApi.Import(context.Background(), strings.NewReader(input.String()), opts)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unable to import records 7 to 8")
+	assert.Equal(t, 4, result.Records)
+	bb, err := os.ReadFile(checkpoint)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"records": 4, "created": 4, "updated": 0, "failed": 0}`, string(bb))
+
+	c, received := bulkServer(t, nil)
+	result, err = c.SubscriberApi.Import(context.Background(), strings.NewReader(input.String()), opts)
+	require.NoError(t, err)
+	assert.Equal(t, &lib.SubscriberImportResult{Records: 10, Created: 10, Resumed: 4}, result)
+	var ids []string
+	for _, payload := range *received {
+		for _, s := range payload.Subscribers {
+			ids = append(ids, s.SubscriberId)
+		}
+	}
+	assert.ElementsMatch(t, []string{"sub-e", "sub-f", "sub-g", "sub-h", "sub-i", "sub-j"}, ids)
+	assert.NoFileExists(t, checkpoint)
+}
+
+func TestSubscriberService_Import_Errors(t *testing.T) {
+	c, _ := bulkServer(t, nil)
+	ctx := context.Background()
+
+	_, err := c.SubscriberApi.Import(ctx, strings.NewReader("email\n"), &lib.SubscriberImportOptions{BatchSize: 501})
+	assert.ErrorContains(t, err, "exceeds the maximum of 500")
+
+	_, err = c.SubscriberApi.Import(ctx, strings.NewReader(""), nil)
+	assert.ErrorContains(t, err, "no header")
+
+	_, err = c.SubscriberApi.Import(ctx, strings.NewReader("id\n"), &lib.SubscriberImportOptions{Columns: map[string]string{"id": "identifier"}})
+	assert.ErrorContains(t, err, `unknown field "identifier"`)
+
+	checkpoint := filepath.Join(t.TempDir(), "checkpoint")
+	require.NoError(t, os.WriteFile(checkpoint, []byte(`{"records": 5}`), 0o600))
+	_, err = c.SubscriberApi.Import(ctx, strings.NewReader("subscriberId\nsub-1\n"), &lib.SubscriberImportOptions{CheckpointFile: checkpoint})
+	assert.ErrorContains(t, err, "past the end of the input")
+}
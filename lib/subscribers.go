@@ -12,10 +12,17 @@ import (
 	"github.com/pkg/errors"
 )
 
+// messagesOwnerPageSize is the number of messages fetched per page while
+// checking the owner of the messages to delete.
+const messagesOwnerPageSize = 100
+
 type ISubscribers interface {
 	Identify(ctx context.Context, subscriberID string, data interface{}) (SubscriberResponse, error)
 	BulkCreate(ctx context.Context, subscribers SubscriberBulkPayload) (SubscriberBulkCreateResponse, error)
 	Get(ctx context.Context, subscriberID string) (SubscriberResponse, error)
+	List(ctx context.Context, page int, limit int) (*SubscribersResponse, error)
+	IterateSubscribers(ctx context.Context, limit int, fn func(subscriber Subscriber) error) error
+	Import(ctx context.Context, r io.Reader, opts *SubscriberImportOptions) (*SubscriberImportResult, error)
 	Update(ctx context.Context, subscriberID string, data interface{}) (SubscriberResponse, error)
 	UpdateCredentials(ctx context.Context, subscriberID string, payload SubscriberCredentialPayload) (SubscriberResponse, error)
 	Delete(ctx context.Context, subscriberID string) (SubscriberResponse, error)
@@ -24,6 +31,13 @@ type ISubscribers interface {
 	MarkMessageSeen(ctx context.Context, subscriberID string, opts SubscriberMarkMessageSeenOptions) (*SubscriberNotificationFeedResponse, error)
 	GetPreferences(ctx context.Context, subscriberID string) (*SubscriberPreferencesResponse, error)
 	UpdatePreferences(ctx context.Context, subscriberID string, templateId string, opts *UpdateSubscriberPreferencesOptions) (*SubscriberPreferencesResponse, error)
+	GetPreferencesByLevel(ctx context.Context, subscriberID string, level PreferenceLevel) (*SubscriberPreferencesResponse, error)
+	GetGlobalPreferences(ctx context.Context, subscriberID string) (*SubscriberPreferencesResponse, error)
+	UpdateGlobalPreferences(ctx context.Context, subscriberID string, opts *UpdateSubscriberGlobalPreferencesOptions) (*SubscriberGlobalPreferencesResponse, error)
+	MarkAllMessagesAs(ctx context.Context, subscriberID string, opts SubscriberMarkAllMessagesOptions) (*SubscriberMarkAllMessagesResponse, error)
+	MarkMessagesAs(ctx context.Context, subscriberID string, opts SubscriberMarkMessagesOptions) (*SubscriberMarkMessagesResponse, error)
+	UpdateMessageAction(ctx context.Context, subscriberID string, messageID string, actionType MessageActionType, opts SubscriberMessageActionOptions) (*SubscriberMessageResponse, error)
+	DeleteMessages(ctx context.Context, subscriberID string, opts SubscriberDeleteMessagesOptions) (*SubscriberDeleteMessagesResponse, error)
 }
 
 type SubscriberService service
@@ -89,6 +103,49 @@ func (s *SubscriberService) Get(ctx context.Context, subscriberID string) (Subsc
 	return resp, nil
 }
 
+func (s *SubscriberService) List(ctx context.Context, page int, limit int) (*SubscribersResponse, error) {
+	var resp SubscribersResponse
+	URL := s.client.config.BackendURL.JoinPath("subscribers")
+	v := URL.Query()
+	v.Set("page", strconv.Itoa(page))
+	if limit > 0 {
+		v.Set("limit", strconv.Itoa(limit))
+	}
+	URL.RawQuery = v.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, URL.String(), http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.client.sendRequest(req, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// IterateSubscribers calls fn for every subscriber of the environment,
+// fetching limit subscribers per page. Iteration stops at the first error
+// returned by fn.
+func (s *SubscriberService) IterateSubscribers(ctx context.Context, limit int, fn func(subscriber Subscriber) error) error {
+	for page := 0; ; page++ {
+		resp, err := s.List(ctx, page, limit)
+		if err != nil {
+			return errors.Wrapf(err, "unable to list subscribers page %d", page)
+		}
+		for _, subscriber := range resp.Data {
+			if err := fn(subscriber); err != nil {
+				return err
+			}
+		}
+		if !resp.HasMore || len(resp.Data) == 0 {
+			return nil
+		}
+	}
+}
+
 func (s *SubscriberService) Update(ctx context.Context, subscriberID string, data interface{}) (SubscriberResponse, error) {
 	var resp SubscriberResponse
 	URL := s.client.config.BackendURL.JoinPath("subscribers", subscriberID)
@@ -112,6 +169,10 @@ func (s *SubscriberService) UpdateCredentials(ctx context.Context, subscriberID
 	var resp SubscriberResponse
 	URL := s.client.config.BackendURL.JoinPath("subscribers", subscriberID, "credentials")
 
+	if err := data.Validate(); err != nil {
+		return resp, err
+	}
+
 	jsonBody, _ := json.Marshal(data)
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodPut, URL.String(), bytes.NewBuffer(jsonBody))
@@ -241,6 +302,10 @@ func (s *SubscriberService) UpdatePreferences(ctx context.Context, subscriberID
 	var reqBody io.Reader = http.NoBody
 
 	if opts != nil {
+		if err := validatePreferenceChannels(opts.Channel); err != nil {
+			return nil, err
+		}
+
 		jsonBody, err := json.Marshal(opts)
 		if err != nil {
 			return nil, err
@@ -262,6 +327,59 @@ func (s *SubscriberService) UpdatePreferences(ctx context.Context, subscriberID
 	return &resp, nil
 }
 
+func (s *SubscriberService) GetPreferencesByLevel(ctx context.Context, subscriberID string, level PreferenceLevel) (*SubscriberPreferencesResponse, error) {
+	var resp SubscriberPreferencesResponse
+	URL := s.client.config.BackendURL.JoinPath("subscribers", subscriberID, "preferences", string(level))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, URL.String(), http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.client.sendRequest(req, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+func (s *SubscriberService) GetGlobalPreferences(ctx context.Context, subscriberID string) (*SubscriberPreferencesResponse, error) {
+	return s.GetPreferencesByLevel(ctx, subscriberID, PreferenceLevelGlobal)
+}
+
+func (s *SubscriberService) UpdateGlobalPreferences(ctx context.Context, subscriberID string, opts *UpdateSubscriberGlobalPreferencesOptions) (*SubscriberGlobalPreferencesResponse, error) {
+	var resp SubscriberGlobalPreferencesResponse
+	URL := s.client.config.BackendURL.JoinPath("subscribers", subscriberID, "preferences")
+
+	var reqBody io.Reader = http.NoBody
+
+	if opts != nil {
+		if err := validatePreferenceChannels(opts.Preferences); err != nil {
+			return nil, err
+		}
+
+		jsonBody, err := json.Marshal(opts)
+		if err != nil {
+			return nil, err
+		}
+
+		reqBody = bytes.NewBuffer(jsonBody)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, URL.String(), reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.client.sendRequest(req, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
 func (s *SubscriberService) MarkMessageSeen(ctx context.Context, subscriberID string, opts SubscriberMarkMessageSeenOptions) (*SubscriberNotificationFeedResponse, error) {
 	var resp SubscriberNotificationFeedResponse
 	URL := s.client.config.BackendURL.JoinPath("subscribers", subscriberID, "messages", "markAs")
@@ -284,4 +402,161 @@ func (s *SubscriberService) MarkMessageSeen(ctx context.Context, subscriberID st
 	return &resp, nil
 }
 
+func (s *SubscriberService) MarkAllMessagesAs(ctx context.Context, subscriberID string, opts SubscriberMarkAllMessagesOptions) (*SubscriberMarkAllMessagesResponse, error) {
+	var resp SubscriberMarkAllMessagesResponse
+	URL := s.client.config.BackendURL.JoinPath("subscribers", subscriberID, "messages", "mark-all")
+
+	jsonBody, err := json.Marshal(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, URL.String(), bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.client.sendRequest(req, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+func (s *SubscriberService) MarkMessagesAs(ctx context.Context, subscriberID string, opts SubscriberMarkMessagesOptions) (*SubscriberMarkMessagesResponse, error) {
+	var resp SubscriberMarkMessagesResponse
+	URL := s.client.config.BackendURL.JoinPath("subscribers", subscriberID, "messages", "mark-as")
+
+	jsonBody, err := json.Marshal(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, URL.String(), bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.client.sendRequest(req, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+func (s *SubscriberService) UpdateMessageAction(ctx context.Context, subscriberID string, messageID string, actionType MessageActionType, opts SubscriberMessageActionOptions) (*SubscriberMessageResponse, error) {
+	var resp SubscriberMessageResponse
+	URL := s.client.config.BackendURL.JoinPath("subscribers", subscriberID, "messages", messageID, "actions", string(actionType))
+
+	jsonBody, err := json.Marshal(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, URL.String(), bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.client.sendRequest(req, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// DeleteMessages deletes the given messages, or the whole feed of the subscriber
+// when no message IDs are passed. Message IDs that do not belong to the
+// subscriber fail the call before anything is deleted. The IDs deleted before a
+// failure are returned together with the error.
+func (s *SubscriberService) DeleteMessages(ctx context.Context, subscriberID string, opts SubscriberDeleteMessagesOptions) (*SubscriberDeleteMessagesResponse, error) {
+	resp := SubscriberDeleteMessagesResponse{Deleted: []string{}}
+
+	if len(opts.MessageIDs) > 0 {
+		if err := s.checkMessagesOwner(ctx, subscriberID, opts.MessageIDs); err != nil {
+			return &resp, err
+		}
+		for _, messageID := range opts.MessageIDs {
+			if err := s.deleteMessage(ctx, messageID); err != nil {
+				return &resp, errors.Wrapf(err, "unable to delete message %s", messageID)
+			}
+			resp.Deleted = append(resp.Deleted, messageID)
+		}
+		return &resp, nil
+	}
+
+	deleted := map[string]bool{}
+	for {
+		// Deleted messages leave the feed, so the first page always holds the next batch.
+		feed, err := s.GetNotificationFeed(ctx, subscriberID, &SubscriberNotificationFeedOptions{FeedIdentifier: opts.FeedIdentifier})
+		if err != nil {
+			return &resp, err
+		}
+
+		progressed := false
+		for _, message := range feed.Data {
+			if deleted[message.ID] {
+				continue
+			}
+			if err := s.deleteMessage(ctx, message.ID); err != nil {
+				return &resp, errors.Wrapf(err, "unable to delete message %s", message.ID)
+			}
+			deleted[message.ID] = true
+			resp.Deleted = append(resp.Deleted, message.ID)
+			progressed = true
+		}
+
+		if !progressed {
+			return &resp, nil
+		}
+	}
+}
+
+// checkMessagesOwner fails unless every message ID is among the messages of
+// the subscriber.
+func (s *SubscriberService) checkMessagesOwner(ctx context.Context, subscriberID string, messageIDs []string) error {
+	missing := map[string]bool{}
+	for _, messageID := range messageIDs {
+		missing[messageID] = true
+	}
+
+	q := MessagesQueryParams{SubscriberId: subscriberID, Limit: messagesOwnerPageSize}
+	for len(missing) > 0 {
+		page, err := s.client.MessagesApi.ListMessages(ctx, q)
+		if err != nil {
+			return errors.Wrapf(err, "unable to list the messages of subscriber %s", subscriberID)
+		}
+		for _, message := range page.Data {
+			delete(missing, message.ID)
+		}
+		if !page.HasMore || len(page.Data) == 0 {
+			break
+		}
+		q.Page++
+	}
+
+	for _, messageID := range messageIDs {
+		if missing[messageID] {
+			return errors.Errorf("message %s does not belong to subscriber %s", messageID, subscriberID)
+		}
+	}
+	return nil
+}
+
+func (s *SubscriberService) deleteMessage(ctx context.Context, messageID string) error {
+	var resp JsonResponse
+	URL := s.client.config.BackendURL.JoinPath("messages", messageID)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, URL.String(), http.NoBody)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.client.sendRequest(req, &resp)
+	return err
+}
+
 var _ ISubscribers = &SubscriberService{}
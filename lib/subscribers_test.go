@@ -414,7 +414,7 @@ func TestSubscriberService_UpdatePreferences_Success(t *testing.T) {
 	fileToStruct(filepath.Join("../testdata", "subscriber_preferences_response.json"), &expectedResponse)
 
 	var opts *lib.UpdateSubscriberPreferencesOptions = &lib.UpdateSubscriberPreferencesOptions{
-		Enabled: true,
+		Enabled: lib.Bool(true),
 		Channel: []lib.UpdateSubscriberPreferencesChannel{
 			{
 				Type:    "email",
@@ -437,3 +437,298 @@ func TestSubscriberService_UpdatePreferences_Success(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, resp, expectedResponse)
 }
+
+func TestSubscriberService_UpdatePreferences_SendsDisabled(t *testing.T) {
+	var topicID = "topicId"
+
+	httpServer := createTestServer(t, TestServerOptions[map[string]interface{}, *lib.SubscriberPreferencesResponse]{
+		expectedURLPath:    fmt.Sprintf("/v1/subscribers/%s/preferences/%s", subscriberID, topicID),
+		expectedSentMethod: http.MethodPatch,
+		expectedSentBody:   map[string]interface{}{"enabled": false},
+		responseStatusCode: http.StatusOK,
+		responseBody:       &lib.SubscriberPreferencesResponse{},
+	})
+
+	ctx := context.Background()
+	c := lib.NewAPIClient(novuApiKey, &lib.Config{BackendURL: lib.MustParseURL(httpServer.URL)})
+	_, err := c.SubscriberApi.UpdatePreferences(ctx, subscriberID, topicID, &lib.UpdateSubscriberPreferencesOptions{
+		Enabled: lib.Bool(false),
+	})
+
+	require.NoError(t, err)
+}
+
+func TestSubscriberService_GetGlobalPreferences_Success(t *testing.T) {
+	var expectedResponse *lib.SubscriberPreferencesResponse
+	fileToStruct(filepath.Join("../testdata", "subscriber_global_preferences_response.json"), &expectedResponse)
+
+	httpServer := createTestServer(t, TestServerOptions[map[string]string, *lib.SubscriberPreferencesResponse]{
+		expectedURLPath:    fmt.Sprintf("/v1/subscribers/%s/preferences/global", subscriberID),
+		expectedSentMethod: http.MethodGet,
+		expectedSentBody:   map[string]string{},
+		responseStatusCode: http.StatusOK,
+		responseBody:       expectedResponse,
+	})
+
+	ctx := context.Background()
+	c := lib.NewAPIClient(novuApiKey, &lib.Config{BackendURL: lib.MustParseURL(httpServer.URL)})
+	resp, err := c.SubscriberApi.GetGlobalPreferences(ctx, subscriberID)
+
+	require.NoError(t, err)
+	require.Equal(t, resp, expectedResponse)
+	require.Equal(t, lib.PreferenceLevelGlobal, resp.Data[0].Level)
+	require.False(t, *resp.Data[0].Preference.Channels.Sms)
+	require.Nil(t, resp.Data[0].Preference.Channels.Push)
+}
+
+func TestSubscriberService_GetPreferencesByLevel_Success(t *testing.T) {
+	var expectedResponse *lib.SubscriberPreferencesResponse
+	fileToStruct(filepath.Join("../testdata", "subscriber_preferences_response.json"), &expectedResponse)
+
+	httpServer := createTestServer(t, TestServerOptions[map[string]string, *lib.SubscriberPreferencesResponse]{
+		expectedURLPath:    fmt.Sprintf("/v1/subscribers/%s/preferences/template", subscriberID),
+		expectedSentMethod: http.MethodGet,
+		expectedSentBody:   map[string]string{},
+		responseStatusCode: http.StatusOK,
+		responseBody:       expectedResponse,
+	})
+
+	ctx := context.Background()
+	c := lib.NewAPIClient(novuApiKey, &lib.Config{BackendURL: lib.MustParseURL(httpServer.URL)})
+	resp, err := c.SubscriberApi.GetPreferencesByLevel(ctx, subscriberID, lib.PreferenceLevelTemplate)
+
+	require.NoError(t, err)
+	require.Equal(t, resp, expectedResponse)
+}
+
+func TestSubscriberService_UpdateGlobalPreferences_Success(t *testing.T) {
+	var expectedResponse *lib.SubscriberGlobalPreferencesResponse
+	fileToStruct(filepath.Join("../testdata", "subscriber_global_preferences_update_response.json"), &expectedResponse)
+
+	opts := &lib.UpdateSubscriberGlobalPreferencesOptions{
+		Enabled: lib.Bool(true),
+		Preferences: []lib.UpdateSubscriberPreferencesChannel{
+			{Type: lib.SMS, Enabled: false},
+			{Type: lib.IN_APP, Enabled: true},
+		},
+	}
+
+	httpServer := createTestServer(t, TestServerOptions[*lib.UpdateSubscriberGlobalPreferencesOptions, *lib.SubscriberGlobalPreferencesResponse]{
+		expectedURLPath:    fmt.Sprintf("/v1/subscribers/%s/preferences", subscriberID),
+		expectedSentMethod: http.MethodPatch,
+		expectedSentBody:   opts,
+		responseStatusCode: http.StatusOK,
+		responseBody:       expectedResponse,
+	})
+
+	ctx := context.Background()
+	c := lib.NewAPIClient(novuApiKey, &lib.Config{BackendURL: lib.MustParseURL(httpServer.URL)})
+	resp, err := c.SubscriberApi.UpdateGlobalPreferences(ctx, subscriberID, opts)
+
+	require.NoError(t, err)
+	require.Equal(t, resp, expectedResponse)
+}
+
+func TestSubscriberService_MarkAllMessagesAs_Success(t *testing.T) {
+	opts := lib.SubscriberMarkAllMessagesOptions{
+		FeedIdentifier: []string{"inbox"},
+		MarkAs:         lib.MessageMarkAsRead,
+	}
+
+	httpServer := createTestServer(t, TestServerOptions[lib.SubscriberMarkAllMessagesOptions, *lib.SubscriberMarkAllMessagesResponse]{
+		expectedURLPath:    fmt.Sprintf("/v1/subscribers/%s/messages/mark-all", subscriberID),
+		expectedSentMethod: http.MethodPost,
+		expectedSentBody:   opts,
+		responseStatusCode: http.StatusCreated,
+		responseBody:       &lib.SubscriberMarkAllMessagesResponse{Data: 4},
+	})
+
+	ctx := context.Background()
+	c := lib.NewAPIClient(novuApiKey, &lib.Config{BackendURL: lib.MustParseURL(httpServer.URL)})
+	resp, err := c.SubscriberApi.MarkAllMessagesAs(ctx, subscriberID, opts)
+
+	require.NoError(t, err)
+	require.Equal(t, 4, resp.Data)
+}
+
+func TestSubscriberService_MarkMessagesAs_Success(t *testing.T) {
+	var feed *lib.SubscriberNotificationFeedResponse
+	fileToStruct(filepath.Join("../testdata", "subscriber_notification_feed_response.json"), &feed)
+	expectedResponse := &lib.SubscriberMarkMessagesResponse{Data: feed.Data}
+
+	opts := lib.SubscriberMarkMessagesOptions{
+		MessageID: []string{"message_1", "message_2"},
+		MarkAs:    lib.MessageMarkAsSeen,
+	}
+
+	httpServer := createTestServer(t, TestServerOptions[lib.SubscriberMarkMessagesOptions, *lib.SubscriberMarkMessagesResponse]{
+		expectedURLPath:    fmt.Sprintf("/v1/subscribers/%s/messages/mark-as", subscriberID),
+		expectedSentMethod: http.MethodPost,
+		expectedSentBody:   opts,
+		responseStatusCode: http.StatusCreated,
+		responseBody:       expectedResponse,
+	})
+
+	ctx := context.Background()
+	c := lib.NewAPIClient(novuApiKey, &lib.Config{BackendURL: lib.MustParseURL(httpServer.URL)})
+	resp, err := c.SubscriberApi.MarkMessagesAs(ctx, subscriberID, opts)
+
+	require.NoError(t, err)
+	require.Equal(t, expectedResponse, resp)
+}
+
+func TestSubscriberService_UpdateMessageAction_Success(t *testing.T) {
+	const messageID = "message_id"
+
+	opts := lib.SubscriberMessageActionOptions{
+		Status:  lib.MessageActionStatusDone,
+		Payload: map[string]interface{}{"accepted": true},
+	}
+
+	httpServer := createTestServer(t, TestServerOptions[lib.SubscriberMessageActionOptions, *lib.SubscriberMessageResponse]{
+		expectedURLPath:    fmt.Sprintf("/v1/subscribers/%s/messages/%s/actions/primary", subscriberID, messageID),
+		expectedSentMethod: http.MethodPost,
+		expectedSentBody:   opts,
+		responseStatusCode: http.StatusCreated,
+		responseBody:       &lib.SubscriberMessageResponse{Data: lib.NotificationFeedData{ID: messageID}},
+	})
+
+	ctx := context.Background()
+	c := lib.NewAPIClient(novuApiKey, &lib.Config{BackendURL: lib.MustParseURL(httpServer.URL)})
+	resp, err := c.SubscriberApi.UpdateMessageAction(ctx, subscriberID, messageID, lib.MessageActionPrimary, opts)
+
+	require.NoError(t, err)
+	require.Equal(t, messageID, resp.Data.ID)
+}
+
+func TestSubscriberService_DeleteMessages_WholeFeed(t *testing.T) {
+	remaining := []string{"m1", "m2", "m3"}
+
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch {
+		case req.Method == http.MethodGet:
+			assert.Equal(t, fmt.Sprintf("/v1/subscribers/%s/notifications/feed", subscriberID), req.URL.Path)
+			assert.Equal(t, "inbox", req.URL.Query().Get("feedIdentifier"))
+
+			var resp lib.SubscriberNotificationFeedResponse
+			// serve at most two messages per page
+			for i, id := range remaining {
+				if i == 2 {
+					break
+				}
+				resp.Data = append(resp.Data, lib.NotificationFeedData{ID: id})
+			}
+			bb, _ := json.Marshal(resp)
+			w.Write(bb)
+		case req.Method == http.MethodDelete:
+			id := strings.TrimPrefix(req.URL.Path, "/v1/messages/")
+			for i, r := range remaining {
+				if r == id {
+					remaining = append(remaining[:i], remaining[i+1:]...)
+					break
+				}
+			}
+			w.Write([]byte(`{"data":{"acknowledged":true,"status":"deleted"}}`))
+		default:
+			t.Errorf("unexpected request %s %s", req.Method, req.URL.Path)
+		}
+	}))
+	defer httpServer.Close()
+
+	ctx := context.Background()
+	c := lib.NewAPIClient(novuApiKey, &lib.Config{BackendURL: lib.MustParseURL(httpServer.URL)})
+	resp, err := c.SubscriberApi.DeleteMessages(ctx, subscriberID, lib.SubscriberDeleteMessagesOptions{FeedIdentifier: "inbox"})
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"m1", "m2", "m3"}, resp.Deleted)
+	assert.Empty(t, remaining)
+}
+
+// ownedMessages answers the message list of subscriberID, one message per
+// page, and reports whether the request was one.
+func ownedMessages(t *testing.T, w http.ResponseWriter, req *http.Request, ids ...string) bool {
+	if req.Method != http.MethodGet {
+		return false
+	}
+	assert.Equal(t, "/v1/messages", req.URL.Path)
+	assert.Equal(t, subscriberID, req.URL.Query().Get("subscriberId"))
+	page, _ := strconv.Atoi(req.URL.Query().Get("page"))
+	resp := lib.MessagesResponse{Page: page, HasMore: page < len(ids)-1}
+	if page < len(ids) {
+		resp.Data = []lib.Message{{ID: ids[page]}}
+	}
+	json.NewEncoder(w).Encode(resp)
+	return true
+}
+
+func TestSubscriberService_DeleteMessages_ByID(t *testing.T) {
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if ownedMessages(t, w, req, "m1", "bad", "m2") {
+			return
+		}
+		assert.Equal(t, http.MethodDelete, req.Method)
+		if req.URL.Path == "/v1/messages/bad" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"data":{"acknowledged":true,"status":"deleted"}}`))
+	}))
+	defer httpServer.Close()
+
+	ctx := context.Background()
+	c := lib.NewAPIClient(novuApiKey, &lib.Config{BackendURL: lib.MustParseURL(httpServer.URL)})
+	resp, err := c.SubscriberApi.DeleteMessages(ctx, subscriberID, lib.SubscriberDeleteMessagesOptions{
+		MessageIDs: []string{"m1", "bad", "m2"},
+	})
+
+	require.Error(t, err)
+	assert.Equal(t, []string{"m1"}, resp.Deleted)
+}
+
+func TestSubscriberService_DeleteMessages_ForeignID(t *testing.T) {
+	var deleted []string
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if ownedMessages(t, w, req, "m1", "m2") {
+			return
+		}
+		deleted = append(deleted, req.URL.Path)
+		w.Write([]byte(`{"data":{"acknowledged":true,"status":"deleted"}}`))
+	}))
+	defer httpServer.Close()
+
+	ctx := context.Background()
+	c := lib.NewAPIClient(novuApiKey, &lib.Config{BackendURL: lib.MustParseURL(httpServer.URL)})
+	resp, err := c.SubscriberApi.DeleteMessages(ctx, subscriberID, lib.SubscriberDeleteMessagesOptions{
+		MessageIDs: []string{"m2", "other-subscriber-message"},
+	})
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "message other-subscriber-message does not belong to subscriber "+subscriberID)
+	assert.Empty(t, resp.Deleted)
+	assert.Empty(t, deleted)
+}
+
+func TestSubscriberService_IterateSubscribers_Success(t *testing.T) {
+	pages := map[string]string{
+		"0": `{"data": [{"_id": "1", "subscriberId": "sub-1", "email": "a@example.com"}, {"_id": "2", "subscriberId": "sub-2"}], "page": 0, "pageSize": 2, "hasMore": true}`,
+		"1": `{"data": [{"_id": "3", "subscriberId": "sub-3", "data": {"plan": "pro"}}], "page": 1, "pageSize": 2, "hasMore": false}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "/v1/subscribers", req.URL.Path)
+		assert.Equal(t, "2", req.URL.Query().Get("limit"))
+		w.Write([]byte(pages[req.URL.Query().Get("page")]))
+	}))
+	defer server.Close()
+
+	c := lib.NewAPIClient(novuApiKey, &lib.Config{BackendURL: lib.MustParseURL(server.URL)})
+	var subscribers []lib.Subscriber
+	err := c.SubscriberApi.IterateSubscribers(context.Background(), 2, func(subscriber lib.Subscriber) error {
+		subscribers = append(subscribers, subscriber)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, subscribers, 3)
+	assert.Equal(t, "a@example.com", subscribers[0].Email)
+	assert.Equal(t, "sub-3", subscribers[2].SubscriberId)
+	assert.Equal(t, "pro", subscribers[2].Data["plan"])
+}
@@ -5,86 +5,149 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
+	"strconv"
+
+	"github.com/pkg/errors"
 )
 
+type ITenant interface {
+	CreateTenant(ctx context.Context, name string, identifier string) (*TenantResponse, error)
+	GetTenants(ctx context.Context, page int, limit int) (*TenantsResponse, error)
+	IterateTenants(ctx context.Context, limit int, fn func(tenant Tenant) error) error
+	GetTenant(ctx context.Context, identifier string) (*TenantResponse, error)
+	DeleteTenant(ctx context.Context, identifier string) error
+	UpdateTenant(ctx context.Context, identifier string, updateTenantObject *UpdateTenantRequest) (*TenantResponse, error)
+	UpsertTenant(ctx context.Context, tenant CreateTenantRequest) (*TenantResponse, error)
+}
+
 type TenantService service
 
-func (e *TenantService) CreateTenant(ctx context.Context, name string,identifier string) (JsonResponse, error) {
-	var resp JsonResponse
+func (e *TenantService) CreateTenant(ctx context.Context, name string, identifier string) (*TenantResponse, error) {
+	return e.createTenant(ctx, CreateTenantRequest{Name: name, Identifier: identifier})
+}
+
+func (e *TenantService) createTenant(ctx context.Context, tenant CreateTenantRequest) (*TenantResponse, error) {
+	var resp TenantResponse
 	URL := e.client.config.BackendURL.JoinPath("tenants")
-	n := map[string]string{"name": name,"identifier":identifier}
-	jsonBody, _ := json.Marshal(n)
+	jsonBody, _ := json.Marshal(tenant)
 	b := bytes.NewBuffer(jsonBody)
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, URL.String(), b)
 	if err != nil {
-		return resp, err
+		return nil, err
 	}
 	_, err = e.client.sendRequest(req, &resp)
 	if err != nil {
-		return resp, err
+		return nil, err
 	}
-	return resp, nil
+	return &resp, nil
 }
 
-func (e *TenantService) GetTenants(ctx context.Context,page string,limit string) (JsonResponse, error) {
-	var resp JsonResponse
+func (e *TenantService) GetTenants(ctx context.Context, page int, limit int) (*TenantsResponse, error) {
+	var resp TenantsResponse
 	URL := e.client.config.BackendURL.JoinPath("tenants")
-	v := URL.Query();
-	v.Set("page",page)
-	v.Set("limit",limit)
+	v := URL.Query()
+	v.Set("page", strconv.Itoa(page))
+	if limit > 0 {
+		v.Set("limit", strconv.Itoa(limit))
+	}
 	URL.RawQuery = v.Encode()
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, URL.String(), http.NoBody)
 	if err != nil {
-		return resp, err
+		return nil, err
 	}
 	_, err = e.client.sendRequest(req, &resp)
 	if err != nil {
-		return resp, err
+		return nil, err
 	}
-	return resp, nil
+	return &resp, nil
 }
 
-func (e *TenantService) GetTenant(ctx context.Context,identifier string) (JsonResponse, error) {
-	var resp JsonResponse
-	URL := e.client.config.BackendURL.JoinPath("tenants",identifier)
+// IterateTenants calls fn for every tenant of the environment, fetching limit
+// tenants per page. Iteration stops at the first error returned by fn.
+func (e *TenantService) IterateTenants(ctx context.Context, limit int, fn func(tenant Tenant) error) error {
+	for page := 0; ; page++ {
+		resp, err := e.GetTenants(ctx, page, limit)
+		if err != nil {
+			return errors.Wrapf(err, "unable to list tenants page %d", page)
+		}
+		for _, tenant := range resp.Data {
+			if err := fn(tenant); err != nil {
+				return err
+			}
+		}
+		if !resp.HasMore || len(resp.Data) == 0 {
+			return nil
+		}
+	}
+}
+
+func (e *TenantService) GetTenant(ctx context.Context, identifier string) (*TenantResponse, error) {
+	var resp TenantResponse
+	URL := e.client.config.BackendURL.JoinPath("tenants", identifier)
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, URL.String(), http.NoBody)
 	if err != nil {
-		return resp, err
+		return nil, err
 	}
 	_, err = e.client.sendRequest(req, &resp)
 	if err != nil {
-		return resp, err
+		return nil, err
 	}
-	return resp, nil
+	return &resp, nil
 }
 
-func (e *TenantService) DeleteTenant(ctx context.Context, identifier string) (JsonResponse, error) {
+func (e *TenantService) DeleteTenant(ctx context.Context, identifier string) error {
 	var resp JsonResponse
 	URL := e.client.config.BackendURL.JoinPath("tenants", identifier)
 	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, URL.String(), http.NoBody)
 	if err != nil {
-		return resp, err
+		return err
 	}
 	_, err = e.client.sendRequest(req, &resp)
-	if err != nil {
-		return resp, err
-	}
-	return resp, nil
+	return err
 }
 
-
-func (e *TenantService) UpdateTenant(ctx context.Context, identifier string,updateTenantObject *UpdateTenantRequest) (JsonResponse, error) {
-	var resp JsonResponse
+func (e *TenantService) UpdateTenant(ctx context.Context, identifier string, updateTenantObject *UpdateTenantRequest) (*TenantResponse, error) {
+	var resp TenantResponse
 	URL := e.client.config.BackendURL.JoinPath("tenants", identifier)
 	jsonBody, _ := json.Marshal(updateTenantObject)
 	b := bytes.NewBuffer(jsonBody)
 	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, URL.String(), b)
 	if err != nil {
-		return resp, err
+		return nil, err
 	}
 	_, err = e.client.sendRequest(req, &resp)
 	if err != nil {
-		return resp, err
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// UpsertTenant creates the tenant, or updates its name and data when a tenant
+// with the same identifier already exists.
+func (e *TenantService) UpsertTenant(ctx context.Context, tenant CreateTenantRequest) (*TenantResponse, error) {
+	if tenant.Identifier == "" {
+		return nil, errors.New("tenant identifier is required")
+	}
+
+	URL := e.client.config.BackendURL.JoinPath("tenants", tenant.Identifier)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, URL.String(), http.NoBody)
+	if err != nil {
+		return nil, err
 	}
-	return resp, nil
+
+	var existing TenantResponse
+	res, err := e.client.sendRequest(req, &existing)
+	if err != nil {
+		if res != nil && res.StatusCode == http.StatusNotFound {
+			return e.createTenant(ctx, tenant)
+		}
+		return nil, err
+	}
+
+	return e.UpdateTenant(ctx, tenant.Identifier, &UpdateTenantRequest{
+		Name: tenant.Name,
+		Data: tenant.Data,
+	})
 }
+
+var _ ITenant = &TenantService{}
@@ -2,8 +2,10 @@ package lib_test
 
 import (
 	"context"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/novuhq/go-novu/lib"
@@ -14,15 +16,32 @@ var tenantsApiResponse = `{
 	  "_environmentId": "string",
 	  "_id": "string",
 	  "createdAt": "string",
-	  "data": "object",
-	  "identifier": "string",
-	  "name": "string",
+	  "data": {
+		"plan": "enterprise"
+	  },
+	  "identifier": "TenantId",
+	  "name": "Tenant",
 	  "updatedAt": "string"
 	}
   }
   
 `
 
+var tenantsListApiResponse = `{
+	"data": [
+	  {
+		"_environmentId": "string",
+		"_id": "string",
+		"identifier": "TenantId",
+		"name": "Tenant"
+	  }
+	],
+	"page": 0,
+	"pageSize": 10,
+	"hasMore": false
+  }
+`
+
 func TestCreateTenant(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
@@ -40,8 +59,8 @@ func TestCreateTenant(t *testing.T) {
 	if err != nil {
 		t.Errorf("Error should be nil, got %v", err)
 	}
-	if resp.Data == nil || resp.Data == "" {
-		t.Error("Expected response, got none")
+	if resp.Data.Identifier != "TenantId" || resp.Data.Data["plan"] != "enterprise" {
+		t.Errorf("Expected tenant, got %+v", resp.Data)
 	}
 }
 
@@ -53,17 +72,20 @@ func TestGetTenants(t *testing.T) {
 		if r.URL.Path != "/v1/tenants" {
 			t.Errorf("Want /v1/tenants, got %s", r.URL.Path)
 		}
+		if r.URL.RawQuery != "limit=10&page=1" {
+			t.Errorf("Want limit=10&page=1, got %s", r.URL.RawQuery)
+		}
 		w.Header().Set("Content-Type", "application/json")
-		w.Write([]byte(tenantsApiResponse))
+		w.Write([]byte(tenantsListApiResponse))
 	}))
 	defer server.Close()
 	c := lib.NewAPIClient(novuApiKey, &lib.Config{BackendURL: lib.MustParseURL(server.URL)})
-	resp, err := c.TenantApi.GetTenants(context.Background(), "1", "10")
+	resp, err := c.TenantApi.GetTenants(context.Background(), 1, 10)
 	if err != nil {
 		t.Errorf("Error should be nil, got %v", err)
 	}
-	if resp.Data == nil || resp.Data == "" {
-		t.Error("Expected response, got none")
+	if len(resp.Data) != 1 || resp.Data[0].Identifier != "TenantId" {
+		t.Errorf("Expected one tenant, got %+v", resp.Data)
 	}
 }
 
@@ -84,8 +106,8 @@ func TestGetTenant(t *testing.T) {
 	if err != nil {
 		t.Errorf("Error should be nil, got %v", err)
 	}
-	if resp.Data == nil || resp.Data == "" {
-		t.Error("Expected response, got none")
+	if resp.Data.Identifier != "TenantId" || resp.Data.Data["plan"] != "enterprise" {
+		t.Errorf("Expected tenant, got %+v", resp.Data)
 	}
 }
 
@@ -102,13 +124,10 @@ func TestDeleteTenant(t *testing.T) {
 	}))
 	defer server.Close()
 	c := lib.NewAPIClient(novuApiKey, &lib.Config{BackendURL: lib.MustParseURL(server.URL)})
-	resp, err := c.TenantApi.DeleteTenant(context.Background(), "TenantId")
+	err := c.TenantApi.DeleteTenant(context.Background(), "TenantId")
 	if err != nil {
 		t.Errorf("Error should be nil, got %v", err)
 	}
-	if resp.Data == nil || resp.Data == "" {
-		t.Error("Expected response, got none")
-	}
 }
 
 func TestUpdateTenant(t *testing.T) {
@@ -130,7 +149,83 @@ func TestUpdateTenant(t *testing.T) {
 	if err != nil {
 		t.Errorf("Error should be nil, got %v", err)
 	}
-	if resp.Data == nil || resp.Data == "" {
-		t.Error("Expected response, got none")
+	if resp.Data.Identifier != "TenantId" || resp.Data.Data["plan"] != "enterprise" {
+		t.Errorf("Expected tenant, got %+v", resp.Data)
+	}
+}
+
+func TestIterateTenants(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page := r.URL.Query().Get("page")
+		w.Header().Set("Content-Type", "application/json")
+		switch page {
+		case "0":
+			w.Write([]byte(`{"data":[{"identifier":"t1"},{"identifier":"t2"}],"page":0,"pageSize":2,"hasMore":true}`))
+		case "1":
+			w.Write([]byte(`{"data":[{"identifier":"t3"}],"page":1,"pageSize":2,"hasMore":false}`))
+		default:
+			t.Errorf("Unexpected page %s", page)
+		}
+	}))
+	defer server.Close()
+	c := lib.NewAPIClient(novuApiKey, &lib.Config{BackendURL: lib.MustParseURL(server.URL)})
+
+	var identifiers []string
+	err := c.TenantApi.IterateTenants(context.Background(), 2, func(tenant lib.Tenant) error {
+		identifiers = append(identifiers, tenant.Identifier)
+		return nil
+	})
+	if err != nil {
+		t.Errorf("Error should be nil, got %v", err)
+	}
+	if strings.Join(identifiers, ",") != "t1,t2,t3" {
+		t.Errorf("Want t1,t2,t3, got %v", identifiers)
+	}
+}
+
+func TestUpsertTenant(t *testing.T) {
+	for name, exists := range map[string]bool{"create": false, "update": true} {
+		t.Run(name, func(t *testing.T) {
+			var calls []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls = append(calls, r.Method+" "+r.URL.Path)
+				if r.Method == http.MethodGet && !exists {
+					w.WriteHeader(http.StatusNotFound)
+					w.Write([]byte(`{"statusCode":404,"message":"Tenant not found"}`))
+					return
+				}
+				if r.Method != http.MethodGet {
+					var body map[string]interface{}
+					json.NewDecoder(r.Body).Decode(&body)
+					if body["name"] != "Tenant" {
+						t.Errorf("Want name Tenant, got %v", body["name"])
+					}
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(tenantsApiResponse))
+			}))
+			defer server.Close()
+			c := lib.NewAPIClient(novuApiKey, &lib.Config{BackendURL: lib.MustParseURL(server.URL)})
+
+			resp, err := c.TenantApi.UpsertTenant(context.Background(), lib.CreateTenantRequest{
+				Identifier: "TenantId",
+				Name:       "Tenant",
+				Data:       map[string]interface{}{"plan": "enterprise"},
+			})
+			if err != nil {
+				t.Fatalf("Error should be nil, got %v", err)
+			}
+			if resp.Data.Identifier != "TenantId" {
+				t.Errorf("Expected tenant, got %+v", resp.Data)
+			}
+
+			want := "GET /v1/tenants/TenantId,POST /v1/tenants"
+			if exists {
+				want = "GET /v1/tenants/TenantId,PATCH /v1/tenants/TenantId"
+			}
+			if strings.Join(calls, ",") != want {
+				t.Errorf("Want %s, got %v", want, calls)
+			}
+		})
 	}
 }
@@ -12,6 +12,7 @@ import (
 type ITopic interface {
 	Create(ctx context.Context, key string, name string) error
 	List(ctx context.Context, options *ListTopicsOptions) (*ListTopicsResponse, error)
+	IterateTopics(ctx context.Context, limit int, fn func(topic GetTopicResponse) error) error
 	CheckTopicSubscriber(ctx context.Context, key string, externalsubscriber string) (*CheckTopicSubscriberResponse, error)
 	AddSubscribers(ctx context.Context, key string, subscribers []string) error
 	RemoveSubscribers(ctx context.Context, key string, subscribers []string) error
@@ -72,6 +73,28 @@ func (t *TopicService) List(ctx context.Context, options *ListTopicsOptions) (*L
 	return &resp, nil
 }
 
+// IterateTopics calls fn for every topic of the environment, fetching limit
+// topics per page. Listed topics do not hold their subscribers. Iteration
+// stops at the first error returned by fn.
+func (t *TopicService) IterateTopics(ctx context.Context, limit int, fn func(topic GetTopicResponse) error) error {
+	seen := 0
+	for page := 0; ; page++ {
+		resp, err := t.List(ctx, &ListTopicsOptions{Page: &page, PageSize: &limit})
+		if err != nil {
+			return errors.Wrapf(err, "unable to list topics page %d", page)
+		}
+		for _, topic := range resp.Data {
+			if err := fn(topic); err != nil {
+				return err
+			}
+		}
+		seen += len(resp.Data)
+		if lastPage(len(resp.Data), seen, resp.TotalCount, limit) {
+			return nil
+		}
+	}
+}
+
 func (t *TopicService) CheckTopicSubscriber(ctx context.Context, key string, externalsubscriber string) (*CheckTopicSubscriberResponse, error) {
 	var resp CheckTopicSubscriberResponse
 	URL := t.client.config.BackendURL.JoinPath("topics", key, "subscribers", externalsubscriber)
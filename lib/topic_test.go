@@ -262,3 +262,28 @@ func TestDeleteTopic_Success(t *testing.T) {
 
 	require.NoError(t, err)
 }
+
+func TestIterateTopics(t *testing.T) {
+	// The server caps pages at 2 topics, below the requested limit.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var options lib.ListTopicsOptions
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&options))
+		resp := lib.ListTopicsResponse{TotalCount: 3, PageSize: 2, Page: *options.Page}
+		for i := *options.Page * 2; i < 3 && i < (*options.Page+1)*2; i++ {
+			resp.Data = append(resp.Data, lib.GetTopicResponse{Key: fmt.Sprintf("topic-%d", i)})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	c := lib.NewAPIClient(novuApiKey, &lib.Config{BackendURL: lib.MustParseURL(server.URL)})
+	var keys []string
+	err := c.TopicsApi.IterateTopics(context.Background(), 10, func(topic lib.GetTopicResponse) error {
+		keys = append(keys, topic.Key)
+		return nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"topic-0", "topic-1", "topic-2"}, keys)
+}
@@ -0,0 +1,310 @@
+package lib
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrTriggerQueueFull is returned by Enqueue when the queue holds
+	// TriggerQueueOptions.Capacity events waiting to be sent.
+	ErrTriggerQueueFull = errors.New("trigger queue is full")
+	// ErrTriggerQueueClosed is returned by Enqueue once the queue is closed.
+	ErrTriggerQueueClosed = errors.New("trigger queue is closed")
+)
+
+type TriggerQueueOptions struct {
+	// Capacity is the number of events waiting to be sent above which Enqueue
+	// fails. It defaults to 10000.
+	Capacity int
+	// BatchSize is the number of events sent per bulk request. It defaults to
+	// and cannot exceed MaxBulkTriggerEvents.
+	BatchSize int
+	// FlushInterval is the longest time an event waits for its batch to fill
+	// up. It defaults to one second.
+	FlushInterval time.Duration
+	// Concurrency limits the number of requests in flight, it defaults to DefaultBulkConcurrency.
+	Concurrency int
+	// MaxRetries is the number of times an event is sent again after a network
+	// error, a rate limit or a server error. It defaults to 5; a negative
+	// value disables retries.
+	MaxRetries int
+	// MinBackoff and MaxBackoff bound the exponential delay between retries.
+	// They default to 500ms and 30s.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// OnFailure is called with the events that are not delivered: those
+	// rejected by Novu, those still failing after MaxRetries and those left
+	// when Close gives up.
+	OnFailure func(event BulkTriggerOptions, err error)
+}
+
+// TriggerQueueStats is a snapshot of the queue. The totals count events since
+// the queue was created.
+type TriggerQueueStats struct {
+	// Queued is the number of events waiting for a batch.
+	Queued int64
+	// InFlight is the number of events being sent or waiting for a retry.
+	InFlight int64
+
+	Enqueued  int64
+	Rejected  int64
+	Delivered int64
+	Failed    int64
+	Retried   int64
+}
+
+// TriggerQueue triggers events in the background. Enqueue returns without
+// waiting for Novu; the events are batched into bulk triggers sent when a
+// batch is full or when the flush interval elapses. Failed requests are
+// retried with an exponential backoff. Close sends the pending events before
+// returning, which makes it suitable for graceful shutdowns.
+type TriggerQueue struct {
+	events IEvent
+	opts   TriggerQueueOptions
+
+	mu      sync.RWMutex
+	closed  bool
+	in      chan BulkTriggerOptions
+	batches chan []BulkTriggerOptions
+
+	// ctx is cancelled when Close gives up, which aborts the requests and
+	// backoffs.
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	queued, inFlight                               int64
+	enqueued, rejected, delivered, failed, retried int64
+}
+
+// NewTriggerQueue starts a queue triggering events with events, usually the
+// EventApi of a client.
+func NewTriggerQueue(events IEvent, opts *TriggerQueueOptions) (*TriggerQueue, error) {
+	q := &TriggerQueue{events: events}
+	if opts != nil {
+		q.opts = *opts
+	}
+	if q.opts.Capacity <= 0 {
+		q.opts.Capacity = 10000
+	}
+	if q.opts.BatchSize <= 0 {
+		q.opts.BatchSize = MaxBulkTriggerEvents
+	}
+	if q.opts.BatchSize > MaxBulkTriggerEvents {
+		return nil, errors.Errorf("batch size %d exceeds the maximum of %d", q.opts.BatchSize, MaxBulkTriggerEvents)
+	}
+	if q.opts.FlushInterval <= 0 {
+		q.opts.FlushInterval = time.Second
+	}
+	if q.opts.Concurrency <= 0 {
+		q.opts.Concurrency = DefaultBulkConcurrency
+	}
+	if q.opts.MaxRetries == 0 {
+		q.opts.MaxRetries = 5
+	}
+	if q.opts.MinBackoff <= 0 {
+		q.opts.MinBackoff = 500 * time.Millisecond
+	}
+	if q.opts.MaxBackoff <= 0 {
+		q.opts.MaxBackoff = 30 * time.Second
+	}
+
+	q.in = make(chan BulkTriggerOptions, q.opts.Capacity)
+	q.batches = make(chan []BulkTriggerOptions)
+	q.ctx, q.cancel = context.WithCancel(context.Background())
+
+	go q.batch()
+	q.wg.Add(q.opts.Concurrency)
+	for i := 0; i < q.opts.Concurrency; i++ {
+		go q.send()
+	}
+	return q, nil
+}
+
+// Enqueue adds an event to the queue without blocking. Events without a
+// transaction id are given one, which is returned to correlate the event with
+// its notifications.
+func (q *TriggerQueue) Enqueue(event BulkTriggerOptions) (string, error) {
+	if event.TransactionId == "" {
+		event.TransactionId = uuid.New().String()
+	}
+
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.closed {
+		return "", ErrTriggerQueueClosed
+	}
+	select {
+	case q.in <- event:
+		atomic.AddInt64(&q.enqueued, 1)
+		atomic.AddInt64(&q.queued, 1)
+		return event.TransactionId, nil
+	default:
+		atomic.AddInt64(&q.rejected, 1)
+		return "", ErrTriggerQueueFull
+	}
+}
+
+// Stats returns the depth of the queue and its totals.
+func (q *TriggerQueue) Stats() TriggerQueueStats {
+	return TriggerQueueStats{
+		Queued:    atomic.LoadInt64(&q.queued),
+		InFlight:  atomic.LoadInt64(&q.inFlight),
+		Enqueued:  atomic.LoadInt64(&q.enqueued),
+		Rejected:  atomic.LoadInt64(&q.rejected),
+		Delivered: atomic.LoadInt64(&q.delivered),
+		Failed:    atomic.LoadInt64(&q.failed),
+		Retried:   atomic.LoadInt64(&q.retried),
+	}
+}
+
+// Close stops accepting events and waits for the pending ones to be sent.
+// When ctx is done first, the requests and retries in progress are aborted,
+// the events left are passed to OnFailure and ctx.Err() is returned.
+func (q *TriggerQueue) Close(ctx context.Context) error {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return ErrTriggerQueueClosed
+	}
+	q.closed = true
+	close(q.in)
+	q.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		q.cancel()
+		return nil
+	case <-ctx.Done():
+		q.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// batch groups the enqueued events until a batch is full or the flush
+// interval elapses.
+func (q *TriggerQueue) batch() {
+	defer close(q.batches)
+	ticker := time.NewTicker(q.opts.FlushInterval)
+	defer ticker.Stop()
+
+	var batch []BulkTriggerOptions
+	flush := func() {
+		if len(batch) > 0 {
+			q.batches <- batch
+			batch = nil
+		}
+	}
+	for {
+		select {
+		case event, ok := <-q.in:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, event)
+			if len(batch) >= q.opts.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+func (q *TriggerQueue) send() {
+	defer q.wg.Done()
+	for batch := range q.batches {
+		n := int64(len(batch))
+		atomic.AddInt64(&q.queued, -n)
+		atomic.AddInt64(&q.inFlight, n)
+
+		for attempt := 0; len(batch) > 0; attempt++ {
+			results, _ := q.events.TriggerBulkChunked(q.ctx, batch, &TriggerBulkChunkedOptions{Concurrency: 1})
+			var retry []BulkTriggerOptions
+			for i := range batch {
+				result := BulkTriggerResult{Err: errors.New("no result returned for the event")}
+				if i < len(results) {
+					result = results[i]
+				}
+				switch {
+				case result.Err == nil:
+					atomic.AddInt64(&q.delivered, 1)
+					atomic.AddInt64(&q.inFlight, -1)
+				case attempt < q.opts.MaxRetries && q.retryable(result):
+					retry = append(retry, batch[i])
+				default:
+					q.fail(batch[i], result.Err)
+				}
+			}
+			if len(retry) > 0 {
+				atomic.AddInt64(&q.retried, int64(len(retry)))
+				if !q.sleep(q.backoff(attempt)) {
+					for _, event := range retry {
+						q.fail(event, q.ctx.Err())
+					}
+					retry = nil
+				}
+			}
+			batch = retry
+		}
+	}
+}
+
+func (q *TriggerQueue) fail(event BulkTriggerOptions, err error) {
+	atomic.AddInt64(&q.failed, 1)
+	atomic.AddInt64(&q.inFlight, -1)
+	if q.opts.OnFailure != nil {
+		q.opts.OnFailure(event, err)
+	}
+}
+
+// retryable tells whether the request of an event may succeed when sent again.
+// Events Novu answered for, such as those of inactive workflows, are not.
+func (q *TriggerQueue) retryable(result BulkTriggerResult) bool {
+	if q.ctx.Err() != nil || result.Status != "" {
+		return false
+	}
+	var apiErr *APIError
+	if errors.As(result.Err, &apiErr) {
+		return apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode >= http.StatusInternalServerError
+	}
+	return !errors.Is(result.Err, context.Canceled) && !errors.Is(result.Err, context.DeadlineExceeded)
+}
+
+func (q *TriggerQueue) backoff(attempt int) time.Duration {
+	d := q.opts.MinBackoff
+	for i := 0; i < attempt && d < q.opts.MaxBackoff; i++ {
+		d *= 2
+	}
+	if d > q.opts.MaxBackoff {
+		d = q.opts.MaxBackoff
+	}
+	return d
+}
+
+// sleep waits for d unless the queue gives up first.
+func (q *TriggerQueue) sleep(d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-q.ctx.Done():
+		return false
+	}
+}
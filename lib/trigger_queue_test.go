@@ -0,0 +1,198 @@
+package lib_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/novuhq/go-novu/lib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// bulkTriggerServer records the bulk triggers it receives and answers them
+// with handle, which processes every event when nil.
+func bulkTriggerServer(t *testing.T, handle func(w http.ResponseWriter, events []lib.BulkTriggerOptions) bool) (*lib.APIClient, func() [][]lib.BulkTriggerOptions) {
+	var mu sync.Mutex
+	var received [][]lib.BulkTriggerOptions
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "/v1/events/trigger/bulk", req.URL.Path)
+		var body lib.BulkTriggerEvent
+		require.NoError(t, json.NewDecoder(req.Body).Decode(&body))
+		mu.Lock()
+		received = append(received, body.Events)
+		mu.Unlock()
+		if handle != nil && handle(w, body.Events) {
+			return
+		}
+
+		var results []map[string]interface{}
+		for _, event := range body.Events {
+			results = append(results, map[string]interface{}{"acknowledged": true, "status": "processed", "transactionId": event.TransactionId})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": results})
+	}))
+	t.Cleanup(server.Close)
+
+	c := lib.NewAPIClient(novuApiKey, &lib.Config{BackendURL: lib.MustParseURL(server.URL)})
+	return c, func() [][]lib.BulkTriggerOptions {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([][]lib.BulkTriggerOptions{}, received...)
+	}
+}
+
+func TestTriggerQueue_BatchesBySize(t *testing.T) {
+	c, received := bulkTriggerServer(t, nil)
+	q, err := lib.NewTriggerQueue(c.EventApi, &lib.TriggerQueueOptions{BatchSize: 3, FlushInterval: time.Hour, Concurrency: 1})
+	require.NoError(t, err)
+
+	var ids []string
+	for i := 0; i < 7; i++ {
+		id, err := q.Enqueue(lib.BulkTriggerOptions{Name: "welcome", To: "sub-1"})
+		require.NoError(t, err)
+		ids = append(ids, id)
+	}
+	require.NoError(t, q.Close(context.Background()))
+
+	var sizes []int
+	var sent []string
+	for _, batch := range received() {
+		sizes = append(sizes, len(batch))
+		for _, event := range batch {
+			sent = append(sent, event.TransactionId)
+		}
+	}
+	assert.Equal(t, []int{3, 3, 1}, sizes)
+	assert.Equal(t, ids, sent)
+	assert.Equal(t, lib.TriggerQueueStats{Enqueued: 7, Delivered: 7}, q.Stats())
+
+	_, err = q.Enqueue(lib.BulkTriggerOptions{Name: "welcome"})
+	assert.ErrorIs(t, err, lib.ErrTriggerQueueClosed)
+	assert.ErrorIs(t, q.Close(context.Background()), lib.ErrTriggerQueueClosed)
+}
+
+func TestTriggerQueue_FlushesOnInterval(t *testing.T) {
+	c, received := bulkTriggerServer(t, nil)
+	q, err := lib.NewTriggerQueue(c.EventApi, &lib.TriggerQueueOptions{FlushInterval: 10 * time.Millisecond})
+	require.NoError(t, err)
+	defer q.Close(context.Background())
+
+	_, err = q.Enqueue(lib.BulkTriggerOptions{Name: "welcome", To: "sub-1", TransactionId: "tx-1"})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return q.Stats().Delivered == 1 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, "tx-1", received()[0][0].TransactionId)
+}
+
+func TestTriggerQueue_Retries(t *testing.T) {
+	var mu sync.Mutex
+	attempts := 0
+	c, received := bulkTriggerServer(t, func(w http.ResponseWriter, events []lib.BulkTriggerOptions) bool {
+		mu.Lock()
+		defer mu.Unlock()
+		attempts++
+		if attempts <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"message": "unavailable"}`))
+			return true
+		}
+		var results []map[string]interface{}
+		for _, event := range events {
+			status := "processed"
+			if event.TransactionId == "tx-inactive" {
+				status = "trigger_not_active"
+			}
+			results = append(results, map[string]interface{}{"acknowledged": true, "status": status, "transactionId": event.TransactionId})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": results})
+		return true
+	})
+
+	var failures []string
+	q, err := lib.NewTriggerQueue(c.EventApi, &lib.TriggerQueueOptions{
+		BatchSize:     2,
+		FlushInterval: time.Hour,
+		MinBackoff:    time.Millisecond,
+		OnFailure: func(event lib.BulkTriggerOptions, err error) {
+			failures = append(failures, event.TransactionId+": "+err.Error())
+		},
+	})
+	require.NoError(t, err)
+	q.Enqueue(lib.BulkTriggerOptions{Name: "welcome", TransactionId: "tx-1"})
+	q.Enqueue(lib.BulkTriggerOptions{Name: "inactive", TransactionId: "tx-inactive"})
+	require.NoError(t, q.Close(context.Background()))
+
+	assert.Len(t, received(), 3)
+	assert.Equal(t, []string{"tx-inactive: event not processed: trigger_not_active"}, failures)
+	assert.Equal(t, lib.TriggerQueueStats{Enqueued: 2, Delivered: 1, Failed: 1, Retried: 4}, q.Stats())
+}
+
+func TestTriggerQueue_ClientErrorsAreNotRetried(t *testing.T) {
+	c, received := bulkTriggerServer(t, func(w http.ResponseWriter, events []lib.BulkTriggerOptions) bool {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"message": "invalid payload"}`))
+		return true
+	})
+	var failed int
+	q, err := lib.NewTriggerQueue(c.EventApi, &lib.TriggerQueueOptions{MinBackoff: time.Millisecond, OnFailure: func(lib.BulkTriggerOptions, error) { failed++ }})
+	require.NoError(t, err)
+	q.Enqueue(lib.BulkTriggerOptions{Name: "welcome"})
+	require.NoError(t, q.Close(context.Background()))
+	assert.Len(t, received(), 1)
+	assert.Equal(t, 1, failed)
+}
+
+func TestTriggerQueue_FullAndCloseDeadline(t *testing.T) {
+	release := make(chan struct{})
+	c, _ := bulkTriggerServer(t, func(w http.ResponseWriter, events []lib.BulkTriggerOptions) bool {
+		<-release
+		return false
+	})
+	// The server is closed after the pending handlers are released.
+	defer close(release)
+
+	var mu sync.Mutex
+	var dropped int
+	q, err := lib.NewTriggerQueue(c.EventApi, &lib.TriggerQueueOptions{
+		Capacity:    2,
+		BatchSize:   1,
+		Concurrency: 1,
+		OnFailure: func(lib.BulkTriggerOptions, error) {
+			mu.Lock()
+			dropped++
+			mu.Unlock()
+		},
+	})
+	require.NoError(t, err)
+
+	// One event is sent, one waits for the sender and two fill the queue.
+	var accepted int
+	for i := 0; i < 10; i++ {
+		if _, err := q.Enqueue(lib.BulkTriggerOptions{Name: "welcome"}); err == nil {
+			accepted++
+		} else {
+			assert.ErrorIs(t, err, lib.ErrTriggerQueueFull)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	assert.Equal(t, 4, accepted)
+	assert.Equal(t, int64(6), q.Stats().Rejected)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, q.Close(ctx), context.DeadlineExceeded)
+	assert.Equal(t, 4, dropped)
+	stats := q.Stats()
+	assert.Zero(t, stats.Queued)
+	assert.Zero(t, stats.InFlight)
+	assert.Equal(t, int64(4), stats.Failed)
+}
+
+func TestNewTriggerQueue_BatchSize(t *testing.T) {
+	_, err := lib.NewTriggerQueue(nil, &lib.TriggerQueueOptions{BatchSize: 101})
+	assert.ErrorContains(t, err, "exceeds the maximum of 100")
+}
@@ -1,12 +1,21 @@
 package lib
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"net/url"
 	"reflect"
 	"strconv"
+	"strings"
+	"sync"
 )
 
+// DefaultBulkConcurrency is the number of parallel requests used by the bulk
+// helpers when no concurrency is configured.
+const DefaultBulkConcurrency = 5
+
 func MustParseURL(rawURL string) *url.URL {
 	u, err := url.Parse(rawURL)
 	if err != nil {
@@ -16,6 +25,24 @@ func MustParseURL(rawURL string) *url.URL {
 	return u
 }
 
+// lastPage tells whether a page of n items ends a list of total items once
+// seen items were read. Novu caps the page size, so a page shorter than limit
+// only ends the list when the total is unknown.
+func lastPage(n, seen, total, limit int) bool {
+	if n == 0 {
+		return true
+	}
+	if total > 0 {
+		return seen >= total
+	}
+	return n < limit
+}
+
+// Bool returns a pointer to b, for optional fields where false must be sent.
+func Bool(b bool) *bool {
+	return &b
+}
+
 type QueryParam struct {
 	Key   string
 	Value string
@@ -64,3 +91,85 @@ func GenerateQueryParamsFromStruct[T interface{}](queryParamsStruct T) ([]QueryP
 
 	return queryParamList, nil
 }
+
+// APIError is returned when Novu answers a request with an error status. Its
+// message holds the raw body; Message and Reason are decoded from it when it
+// is a Novu error document.
+type APIError struct {
+	StatusCode int
+	Message    string
+	Reason     string
+	Body       []byte
+}
+
+func newAPIError(statusCode int, body []byte) *APIError {
+	e := &APIError{StatusCode: statusCode, Body: body}
+	var document struct {
+		Message interface{} `json:"message"`
+		Error   string      `json:"error"`
+	}
+	if json.Unmarshal(body, &document) == nil {
+		e.Reason = document.Error
+		switch m := document.Message.(type) {
+		case string:
+			e.Message = m
+		case []interface{}:
+			messages := make([]string, 0, len(m))
+			for _, message := range m {
+				messages = append(messages, fmt.Sprint(message))
+			}
+			e.Message = strings.Join(messages, "; ")
+		}
+	}
+	return e
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("request was not successful, status code %d, %s", e.StatusCode, string(e.Body))
+}
+
+// BulkError is returned by the bulk helpers when some of the items failed.
+// The per item errors are also reported in the result slices.
+type BulkError struct {
+	Total  int
+	Errors []error
+}
+
+func (e *BulkError) Error() string {
+	messages := make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		messages = append(messages, err.Error())
+	}
+	return fmt.Sprintf("%d of %d operations failed: %s", len(e.Errors), e.Total, strings.Join(messages, "; "))
+}
+
+// runConcurrently calls fn for every index in [0, n) with at most limit calls
+// in flight. Once ctx is done the remaining indexes are passed ctx.Err().
+func runConcurrently(ctx context.Context, n int, limit int, fn func(ctx context.Context, i int) error) []error {
+	if limit <= 0 {
+		limit = DefaultBulkConcurrency
+	}
+
+	errs := make([]error, n)
+	sem := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+
+	for i := 0; i < n; i++ {
+		select {
+		case <-ctx.Done():
+			errs[i] = ctx.Err()
+			continue
+		case sem <- struct{}{}:
+		}
+
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			errs[i] = fn(ctx, i)
+		}(i)
+	}
+	wg.Wait()
+
+	return errs
+}
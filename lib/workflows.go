@@ -0,0 +1,185 @@
+package lib
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+type WorkflowService service
+
+type WorkflowsResponse struct {
+	Data       []Workflow `json:"data"`
+	TotalCount int        `json:"totalCount"`
+	Page       int        `json:"page"`
+	PageSize   int        `json:"pageSize"`
+}
+
+// UpdateWorkflowRequest replaces the settings and steps of a workflow. Its
+// status is changed with UpdateStatus.
+type UpdateWorkflowRequest struct {
+	Name                string         `json:"name,omitempty"`
+	NotificationGroupId string         `json:"notificationGroupId,omitempty"`
+	Description         string         `json:"description,omitempty"`
+	Tags                []string       `json:"tags,omitempty"`
+	Steps               []WorkflowStep `json:"steps,omitempty"`
+	Critical            *bool          `json:"critical,omitempty"`
+	PreferenceSettings  interface{}    `json:"preferenceSettings,omitempty"`
+}
+
+func (w *WorkflowService) List(ctx context.Context, page int, limit int) (*WorkflowsResponse, error) {
+	var resp WorkflowsResponse
+	URL := w.client.config.BackendURL.JoinPath("workflows")
+	v := URL.Query()
+	v.Set("page", strconv.Itoa(page))
+	if limit > 0 {
+		v.Set("limit", strconv.Itoa(limit))
+	}
+	URL.RawQuery = v.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, URL.String(), http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+	_, err = w.client.sendRequest(req, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// IterateWorkflows calls fn for every workflow of the environment, fetching
+// limit workflows per page. Iteration stops at the first error returned by fn.
+func (w *WorkflowService) IterateWorkflows(ctx context.Context, limit int, fn func(workflow Workflow) error) error {
+	seen := 0
+	for page := 0; ; page++ {
+		resp, err := w.List(ctx, page, limit)
+		if err != nil {
+			return errors.Wrapf(err, "unable to list workflows page %d", page)
+		}
+		for _, workflow := range resp.Data {
+			if err := fn(workflow); err != nil {
+				return err
+			}
+		}
+		seen += len(resp.Data)
+		if lastPage(len(resp.Data), seen, resp.TotalCount, limit) {
+			return nil
+		}
+	}
+}
+
+func (w *WorkflowService) Get(ctx context.Context, workflowId string) (*Workflow, error) {
+	var resp WorkflowResponse
+	URL := w.client.config.BackendURL.JoinPath("workflows", workflowId)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, URL.String(), http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+	_, err = w.client.sendRequest(req, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp.Data, nil
+}
+
+func (w *WorkflowService) Create(ctx context.Context, request CreateWorkflowRequest) (*Workflow, error) {
+	return w.send(ctx, http.MethodPost, request, "workflows")
+}
+
+func (w *WorkflowService) Update(ctx context.Context, workflowId string, request UpdateWorkflowRequest) (*Workflow, error) {
+	return w.send(ctx, http.MethodPut, request, "workflows", workflowId)
+}
+
+// UpdateStatus activates or deactivates a workflow.
+func (w *WorkflowService) UpdateStatus(ctx context.Context, workflowId string, active bool) (*Workflow, error) {
+	return w.send(ctx, http.MethodPut, map[string]bool{"active": active}, "workflows", workflowId, "status")
+}
+
+func (w *WorkflowService) Delete(ctx context.Context, workflowId string) error {
+	var resp interface{}
+	URL := w.client.config.BackendURL.JoinPath("workflows", workflowId)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, URL.String(), http.NoBody)
+	if err != nil {
+		return err
+	}
+	_, err = w.client.sendRequest(req, &resp)
+	return err
+}
+
+func (w *WorkflowService) send(ctx context.Context, method string, body interface{}, path ...string) (*Workflow, error) {
+	var resp WorkflowResponse
+	URL := w.client.config.BackendURL.JoinPath(path...)
+	jsonBody, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, URL.String(), bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return nil, err
+	}
+	_, err = w.client.sendRequest(req, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp.Data, nil
+}
+
+func (w *WorkflowService) ListNotificationGroups(ctx context.Context) ([]NotificationGroup, error) {
+	var resp NotificationGroupsResponse
+	URL := w.client.config.BackendURL.JoinPath("notification-groups")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, URL.String(), http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = w.client.sendRequest(req, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Data, nil
+}
+
+func (w *WorkflowService) CreateNotificationGroup(ctx context.Context, name string) (*NotificationGroup, error) {
+	var resp NotificationGroupResponse
+	URL := w.client.config.BackendURL.JoinPath("notification-groups")
+	jsonBody, err := json.Marshal(map[string]string{"name": name})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, URL.String(), bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return nil, err
+	}
+	if _, err = w.client.sendRequest(req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Data, nil
+}
+
+// NotificationGroupId returns the id of the notification group with the given
+// name, creating the group when it doesn't exist.
+func (w *WorkflowService) NotificationGroupId(ctx context.Context, name string) (string, error) {
+	groups, err := w.ListNotificationGroups(ctx)
+	if err != nil {
+		return "", err
+	}
+	for _, group := range groups {
+		if group.Name == name {
+			return group.Id, nil
+		}
+	}
+
+	created, err := w.CreateNotificationGroup(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	return created.Id, nil
+}
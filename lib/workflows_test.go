@@ -0,0 +1,117 @@
+package lib_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/novuhq/go-novu/lib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var workflowsListApiResponse = `{
+	"data": [
+	  {
+		"_id": "wf-1",
+		"name": "Welcome",
+		"active": true,
+		"_notificationGroupId": "group-1",
+		"triggers": [{"type": "event", "identifier": "welcome"}],
+		"steps": [{"active": true, "template": {"type": "email", "subject": "Hi", "content": "Hello"}}]
+	  }
+	],
+	"totalCount": 1,
+	"page": 0,
+	"pageSize": 10
+  }
+`
+
+func TestWorkflowService(t *testing.T) {
+	type request struct {
+		method, path, query string
+		body                map[string]interface{}
+	}
+	var requests []request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := request{method: r.Method, path: r.URL.Path, query: r.URL.RawQuery}
+		if bb, _ := io.ReadAll(r.Body); len(bb) > 0 {
+			require.NoError(t, json.Unmarshal(bb, &req.body))
+		}
+		requests = append(requests, req)
+
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/workflows":
+			w.Write([]byte(workflowsListApiResponse))
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/notification-groups":
+			w.Write([]byte(`{"data": [{"_id": "group-1", "name": "General"}]}`))
+		case r.URL.Path == "/v1/notification-groups":
+			w.Write([]byte(`{"data": {"_id": "group-2", "name": "Billing"}}`))
+		default:
+			w.Write([]byte(`{"data": {"_id": "wf-1", "name": "Welcome"}}`))
+		}
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	c := lib.NewAPIClient(novuApiKey, &lib.Config{BackendURL: lib.MustParseURL(server.URL)})
+
+	list, err := c.WorkflowApi.List(ctx, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, list.Data, 1)
+	assert.Equal(t, "welcome", list.Data[0].Triggers[0].Identifier)
+	assert.Equal(t, "Hello", list.Data[0].Steps[0].Template.Content.Text)
+
+	_, err = c.WorkflowApi.Update(ctx, "wf-1", lib.UpdateWorkflowRequest{Name: "Welcome", Critical: lib.Bool(false)})
+	require.NoError(t, err)
+	_, err = c.WorkflowApi.UpdateStatus(ctx, "wf-1", false)
+	require.NoError(t, err)
+	require.NoError(t, c.WorkflowApi.Delete(ctx, "wf-1"))
+
+	id, err := c.WorkflowApi.NotificationGroupId(ctx, "General")
+	require.NoError(t, err)
+	assert.Equal(t, "group-1", id)
+	id, err = c.WorkflowApi.NotificationGroupId(ctx, "Billing")
+	require.NoError(t, err)
+	assert.Equal(t, "group-2", id)
+
+	assert.Equal(t, []request{
+		{method: http.MethodGet, path: "/v1/workflows", query: "limit=10&page=0"},
+		{method: http.MethodPut, path: "/v1/workflows/wf-1", body: map[string]interface{}{"name": "Welcome", "critical": false}},
+		{method: http.MethodPut, path: "/v1/workflows/wf-1/status", body: map[string]interface{}{"active": false}},
+		{method: http.MethodDelete, path: "/v1/workflows/wf-1"},
+		{method: http.MethodGet, path: "/v1/notification-groups"},
+		{method: http.MethodGet, path: "/v1/notification-groups"},
+		{method: http.MethodPost, path: "/v1/notification-groups", body: map[string]interface{}{"name": "Billing"}},
+	}, requests)
+}
+
+func TestWorkflowService_IterateWorkflows(t *testing.T) {
+	// The server caps pages at 2 workflows, below the requested limit.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		resp := lib.WorkflowsResponse{TotalCount: 3, PageSize: 2, Page: page}
+		for i := page * 2; i < 3 && i < (page+1)*2; i++ {
+			resp.Data = append(resp.Data, lib.Workflow{Id: fmt.Sprintf("wf-%d", i)})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	c := lib.NewAPIClient(novuApiKey, &lib.Config{BackendURL: lib.MustParseURL(server.URL)})
+	var ids []string
+	err := c.WorkflowApi.IterateWorkflows(context.Background(), 10, func(workflow lib.Workflow) error {
+		ids = append(ids, workflow.Id)
+		return nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"wf-0", "wf-1", "wf-2"}, ids)
+}
@@ -0,0 +1,267 @@
+// Package lint checks the variables referenced by Novu layouts and workflow
+// steps against trigger payloads.
+//
+// Variables are extracted with the render package and compared with a sample
+// payload or a typed payload struct. Variables the payload lacks are reported
+// as missing, payload fields no template uses as unused and values that don't
+// fit their usage, e.g. a string given to {{#each}}, as mistyped.
+package lint
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/novuhq/go-novu/lib"
+	"github.com/novuhq/go-novu/render"
+)
+
+type IssueKind string
+
+const (
+	Missing  IssueKind = "missing"
+	Unused   IssueKind = "unused"
+	Mistyped IssueKind = "mistyped"
+)
+
+// PayloadSource is the source of the issues about the payload itself.
+const PayloadSource = "payload"
+
+// Source is a template to lint, such as a layout or the content of a step.
+type Source struct {
+	Name    string
+	Content string
+	// Optional lists the variables that may be absent from the payload, e.g.
+	// layout variables with a default value.
+	Optional []string
+}
+
+type Issue struct {
+	Kind   IssueKind
+	Source string
+	Line   int
+	Path   string
+	// Expected and Actual are set for mistyped variables.
+	Expected render.VariableType
+	Actual   string
+}
+
+func (i Issue) String() string {
+	location := i.Source
+	if i.Line > 0 {
+		location += ":" + strconv.Itoa(i.Line)
+	}
+	switch i.Kind {
+	case Missing:
+		return fmt.Sprintf("%s: missing variable %s", location, i.Path)
+	case Unused:
+		return fmt.Sprintf("%s: unused variable %s", location, i.Path)
+	}
+	return fmt.Sprintf("%s: variable %s should be %s, got %s", location, i.Path, article(string(i.Expected)), i.Actual)
+}
+
+type Report struct {
+	Issues []Issue
+}
+
+// Filter returns the issues of the given kinds.
+func (r *Report) Filter(kinds ...IssueKind) []Issue {
+	var issues []Issue
+	for _, issue := range r.Issues {
+		for _, k := range kinds {
+			if issue.Kind == k {
+				issues = append(issues, issue)
+				break
+			}
+		}
+	}
+	return issues
+}
+
+// Linter lints templates against payloads. The zero value uses Novu's helpers.
+type Linter struct {
+	// Renderer provides the helpers, set it when templates use custom helpers.
+	Renderer *render.Renderer
+}
+
+// Lint lints sources against payload using Novu's helpers.
+func Lint(payload interface{}, sources ...Source) (*Report, error) {
+	return (&Linter{}).Lint(payload, sources...)
+}
+
+// contextShapes describes the variables Novu adds next to the payload.
+var contextShapes = map[string]*shape{
+	"subscriber": shapeOfType(reflect.TypeOf(lib.SubscriberPayload{}), map[reflect.Type]bool{}),
+	"step":       {kind: kindAny},
+	"tenant":     {kind: kindAny},
+	"branding":   {kind: kindAny},
+	"body":       {kind: kindString},
+}
+
+// Lint checks the variables of sources against payload, which is either a
+// sample payload or a value of the struct type used for the payload.
+func (l *Linter) Lint(payload interface{}, sources ...Source) (*Report, error) {
+	root, err := shapeOf(payload)
+	if err != nil {
+		return nil, fmt.Errorf("payload: %w", err)
+	}
+	if root.kind == kindNull {
+		root = &shape{kind: kindObject, fields: map[string]*shape{}}
+	}
+
+	report := &Report{}
+	var used []render.Variable
+	for _, source := range sources {
+		var vars []render.Variable
+		if l.Renderer != nil {
+			vars, err = l.Renderer.Variables(source.Content)
+		} else {
+			vars, err = render.Variables(source.Content)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", source.Name, err)
+		}
+
+		reported := map[Issue]bool{}
+		for _, v := range vars {
+			if issue := check(root, v); issue != nil {
+				if issue.Kind == Missing && isOptional(source.Optional, v.Path) {
+					continue
+				}
+				// The items of a list are reported with the list.
+				if reported[*issue] {
+					continue
+				}
+				reported[*issue] = true
+				issue.Source = source.Name
+				issue.Line = v.Line
+				report.Issues = append(report.Issues, *issue)
+			}
+		}
+		used = append(used, vars...)
+	}
+
+	var leaves []string
+	root.leaves("", &leaves)
+	sort.Strings(leaves)
+	for _, leaf := range leaves {
+		if !isUsed(leaf, used) {
+			report.Issues = append(report.Issues, Issue{Kind: Unused, Source: PayloadSource, Path: leaf})
+		}
+	}
+	return report, nil
+}
+
+// check walks the payload shape along the path of v.
+func check(root *shape, v render.Variable) *Issue {
+	var segments []string
+	for _, part := range strings.Split(v.Path, ".") {
+		if name := strings.TrimSuffix(part, "[]"); name != part {
+			segments = append(segments, name, "[]")
+		} else {
+			segments = append(segments, part)
+		}
+	}
+
+	cur := root
+	if s, ok := contextShapes[segments[0]]; ok {
+		cur = &shape{kind: kindObject, fields: map[string]*shape{segments[0]: s}}
+	}
+
+	path := ""
+	for _, seg := range segments {
+		switch {
+		case cur == nil || cur.kind == kindAny || cur.kind == kindNull:
+			return nil
+		case seg == "[]":
+			if cur.kind != kindList {
+				return &Issue{Kind: Mistyped, Path: path, Expected: render.TypeList, Actual: string(cur.kind)}
+			}
+			path += "[]"
+			cur = cur.item
+			continue
+		case cur.kind == kindList && isIndex(seg):
+			cur = cur.item
+		case seg == "length" && (cur.kind == kindList || cur.kind == kindString):
+			return nil
+		case cur.kind == kindObject:
+			if cur.fields == nil {
+				return nil
+			}
+			field, ok := cur.fields[seg]
+			if !ok {
+				return &Issue{Kind: Missing, Path: v.Path}
+			}
+			cur = field
+		default:
+			return &Issue{Kind: Mistyped, Path: path, Expected: render.TypeObject, Actual: string(cur.kind)}
+		}
+		path = join(path, seg)
+	}
+
+	if cur == nil || fits(cur, v.Type) {
+		return nil
+	}
+	return &Issue{Kind: Mistyped, Path: v.Path, Expected: v.Type, Actual: string(cur.kind)}
+}
+
+func fits(s *shape, typ render.VariableType) bool {
+	if s.kind == kindAny || s.kind == kindNull {
+		return true
+	}
+	switch typ {
+	case render.TypeScalar:
+		return s.kind != kindList && s.kind != kindObject
+	case render.TypeNumber:
+		return s.kind == kindNumber || (s.kind == kindString && s.numeric)
+	case render.TypeDate:
+		return s.kind == kindDate || s.kind == kindNumber || (s.kind == kindString && s.date)
+	case render.TypeList:
+		return s.kind == kindList
+	case render.TypeObject:
+		return s.kind == kindObject
+	}
+	return true
+}
+
+// isUsed tells whether a template uses the payload value at path, either
+// directly, through one of its fields or by rendering a parent as a whole.
+func isUsed(path string, vars []render.Variable) bool {
+	for _, v := range vars {
+		if v.Path == path || hasPathPrefix(v.Path, path) {
+			return true
+		}
+		if hasPathPrefix(path, v.Path) && v.Type != render.TypeList && v.Type != render.TypeObject && v.Type != render.TypeBoolean {
+			return true
+		}
+	}
+	return false
+}
+
+func isOptional(optional []string, path string) bool {
+	for _, o := range optional {
+		if o == path || hasPathPrefix(path, o) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasPathPrefix tells whether prefix is a parent of path.
+func hasPathPrefix(path, prefix string) bool {
+	return strings.HasPrefix(path, prefix+".") || strings.HasPrefix(path, prefix+"[]")
+}
+
+func isIndex(s string) bool {
+	_, err := strconv.Atoi(s)
+	return err == nil
+}
+
+func article(s string) string {
+	if strings.ContainsAny(s[:1], "aeiou") {
+		return "an " + s
+	}
+	return "a " + s
+}
@@ -0,0 +1,140 @@
+package lint_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/novuhq/go-novu/lib"
+	"github.com/novuhq/go-novu/lint"
+	"github.com/novuhq/go-novu/render"
+)
+
+const orderTemplate = `Hi {{subscriber.firstName}} {{subscriber.nickname}},
+{{#each order.items}}{{name}}: {{numberFormat price}}{{/each}}
+Ordered on {{dateFormat order.date "d MMMM"}} by {{customer.name}}
+{{#each tags}}{{this}}{{/each}} {{coupon}}`
+
+func TestLintSamplePayload(t *testing.T) {
+	payload := map[string]interface{}{
+		"order": map[string]interface{}{
+			"items": []interface{}{
+				map[string]interface{}{"name": "Book", "price": "12.50", "sku": "b-1"},
+			},
+			"date": "2023-04-01T10:00:00Z",
+		},
+		"customer":  "Jane",
+		"tags":      "new",
+		"reference": "A-42",
+	}
+
+	report, err := lint.Lint(payload, lint.Source{Name: "email", Content: orderTemplate})
+	require.NoError(t, err)
+
+	var got []string
+	for _, issue := range report.Issues {
+		got = append(got, issue.String())
+	}
+	assert.Equal(t, []string{
+		"email:1: missing variable subscriber.nickname",
+		"email:3: variable customer should be an object, got string",
+		"email:4: variable tags should be a list, got string",
+		"email:4: missing variable coupon",
+		"payload: unused variable order.items[].sku",
+		"payload: unused variable reference",
+	}, got)
+	assert.Len(t, report.Filter(lint.Missing), 2)
+	assert.Len(t, report.Filter(lint.Unused, lint.Mistyped), 4)
+}
+
+type orderItem struct {
+	Name  string  `json:"name"`
+	Price float64 `json:"price"`
+}
+
+type orderPayload struct {
+	Order struct {
+		Items []orderItem `json:"items"`
+		Date  time.Time   `json:"date"`
+	} `json:"order"`
+	Customer struct {
+		Name string `json:"name"`
+	} `json:"customer"`
+	Tags   []string `json:"tags,omitempty"`
+	Coupon *string  `json:"coupon,omitempty"`
+	Total  int      `json:"total"`
+	Notes  string   `json:"-"`
+}
+
+func TestLintTypedPayload(t *testing.T) {
+	report, err := lint.Lint(orderPayload{}, lint.Source{Name: "email", Content: orderTemplate + " {{numberFormat customer.name}}"})
+	require.NoError(t, err)
+
+	assert.Equal(t, []lint.Issue{
+		{Kind: lint.Missing, Source: "email", Line: 1, Path: "subscriber.nickname"},
+		{Kind: lint.Mistyped, Source: "email", Line: 3, Path: "customer.name", Expected: render.TypeNumber, Actual: "string"},
+		{Kind: lint.Unused, Source: lint.PayloadSource, Path: "total"},
+	}, report.Issues)
+}
+
+func TestLintOptionalLayoutVariables(t *testing.T) {
+	layout := lib.LayoutResponse{
+		Identifier: "default",
+		Content:    `<img src="{{logo}}">{{{body}}}<footer>{{footer}}</footer>`,
+		Variables: []interface{}{
+			map[string]interface{}{"name": "logo", "type": "String", "required": false},
+			map[string]interface{}{"name": "footer", "type": "String", "required": true},
+		},
+	}
+
+	report, err := lint.Lint(nil, lint.LayoutSource(layout))
+	require.NoError(t, err)
+	assert.Equal(t, []lint.Issue{
+		{Kind: lint.Missing, Source: "layout default", Line: 1, Path: "footer"},
+	}, report.Issues)
+}
+
+func TestWorkflowSources(t *testing.T) {
+	steps := []lib.WorkflowStep{
+		{
+			Template: &lib.StepTemplate{
+				Name:    "Welcome email",
+				Subject: "Welcome {{name}}",
+				Content: &lib.StepContent{Blocks: []lib.EmailBlock{
+					{Type: "text", Content: "Hello {{name}}"},
+					{Type: "button", Content: "Open", URL: "{{link}}"},
+				}},
+			},
+			Filters: []lib.StepFilter{{
+				Children: []lib.StepFilterChild{
+					{On: "payload", Field: "plan.tier", Value: "pro"},
+					{On: "subscriber", Field: "email", Value: ""},
+				},
+			}},
+		},
+		{Template: &lib.StepTemplate{Content: &lib.StepContent{Text: "{{count}} new messages"}}},
+	}
+
+	sources := lint.WorkflowSources("onboarding", steps)
+	assert.Equal(t, []lint.Source{
+		{Name: "onboarding/Welcome email block 1", Content: "Hello {{name}}"},
+		{Name: "onboarding/Welcome email block 2", Content: "Open"},
+		{Name: "onboarding/Welcome email block 2", Content: "{{link}}"},
+		{Name: "onboarding/Welcome email subject", Content: "Welcome {{name}}"},
+		{Name: "onboarding/Welcome email filters", Content: "{{#if plan.tier}}{{/if}}"},
+		{Name: "onboarding/step 2", Content: "{{count}} new messages"},
+	}, sources)
+
+	report, err := lint.Lint(map[string]interface{}{"name": "Jane", "link": "https://novu.co", "count": 2}, sources...)
+	require.NoError(t, err)
+	assert.Equal(t, []lint.Issue{
+		{Kind: lint.Missing, Source: "onboarding/Welcome email filters", Line: 1, Path: "plan.tier"},
+	}, report.Issues)
+}
+
+func TestLintInvalidTemplate(t *testing.T) {
+	_, err := lint.Lint(nil, lint.Source{Name: "broken", Content: "{{#if x}}"})
+	assert.ErrorContains(t, err, "broken")
+}
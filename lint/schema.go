@@ -0,0 +1,208 @@
+package lint
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type kind string
+
+const (
+	kindAny    kind = "any"
+	kindString kind = "string"
+	kindNumber kind = "number"
+	kindBool   kind = "boolean"
+	kindDate   kind = "date"
+	kindList   kind = "list"
+	kindObject kind = "object"
+	kindNull   kind = "null"
+)
+
+// shape describes a payload. Objects have fields, unless their keys are
+// dynamic, and lists an item shape.
+type shape struct {
+	kind   kind
+	fields map[string]*shape
+	item   *shape
+	// numeric and date tell whether a sampled string can be used as a number
+	// or a date.
+	numeric bool
+	date    bool
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// shapeOf returns the shape of a payload. Structs are described by their type
+// so optional fields are known even when empty, anything else by its value.
+func shapeOf(payload interface{}) (*shape, error) {
+	t := reflect.TypeOf(payload)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t != nil && t.Kind() == reflect.Struct && t != timeType {
+		return shapeOfType(t, map[reflect.Type]bool{}), nil
+	}
+
+	bb, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	var value interface{}
+	if err := json.Unmarshal(bb, &value); err != nil {
+		return nil, err
+	}
+	return shapeOfValue(value), nil
+}
+
+func shapeOfValue(value interface{}) *shape {
+	switch v := value.(type) {
+	case nil:
+		return &shape{kind: kindNull}
+	case bool:
+		return &shape{kind: kindBool}
+	case float64:
+		return &shape{kind: kindNumber}
+	case string:
+		_, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return &shape{kind: kindString, numeric: err == nil, date: isDate(v)}
+	case []interface{}:
+		s := &shape{kind: kindList}
+		for _, item := range v {
+			s.item = merge(s.item, shapeOfValue(item))
+		}
+		return s
+	case map[string]interface{}:
+		s := &shape{kind: kindObject, fields: map[string]*shape{}}
+		for k, item := range v {
+			s.fields[k] = shapeOfValue(item)
+		}
+		return s
+	}
+	return &shape{kind: kindAny}
+}
+
+// merge combines the shapes of the items of a list.
+func merge(a, b *shape) *shape {
+	switch {
+	case a == nil:
+		return b
+	case a.kind == kindNull:
+		return b
+	case b.kind == kindNull:
+		return a
+	case a.kind != b.kind:
+		return &shape{kind: kindAny}
+	}
+
+	out := *a
+	out.numeric = a.numeric && b.numeric
+	out.date = a.date && b.date
+	if a.fields != nil {
+		out.fields = map[string]*shape{}
+		for k, v := range a.fields {
+			out.fields[k] = v
+		}
+		for k, v := range b.fields {
+			out.fields[k] = merge(out.fields[k], v)
+		}
+	}
+	if a.item != nil || b.item != nil {
+		out.item = merge(a.item, b.item)
+	}
+	return &out
+}
+
+func shapeOfType(t reflect.Type, seen map[reflect.Type]bool) *shape {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return &shape{kind: kindDate}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &shape{kind: kindBool}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return &shape{kind: kindNumber}
+	case reflect.String:
+		return &shape{kind: kindString}
+	case reflect.Slice, reflect.Array:
+		return &shape{kind: kindList, item: shapeOfType(t.Elem(), seen)}
+	case reflect.Map:
+		return &shape{kind: kindObject}
+	case reflect.Struct:
+		if seen[t] {
+			return &shape{kind: kindObject}
+		}
+		seen[t] = true
+		defer delete(seen, t)
+
+		s := &shape{kind: kindObject, fields: map[string]*shape{}}
+		addFields(s, t, seen)
+		return s
+	}
+	return &shape{kind: kindAny}
+}
+
+func addFields(s *shape, t reflect.Type, seen map[reflect.Type]bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" || (!f.IsExported() && !f.Anonymous) {
+			continue
+		}
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			for ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				addFields(s, ft, seen)
+				continue
+			}
+		}
+		if name == "" {
+			name = f.Name
+		}
+		s.fields[name] = shapeOfType(f.Type, seen)
+	}
+}
+
+func isDate(s string) bool {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02"} {
+		if _, err := time.Parse(layout, s); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// leaves returns the paths of the values held by s, using the same notation
+// as render.Variable.
+func (s *shape) leaves(prefix string, out *[]string) {
+	switch {
+	case s.kind == kindObject && len(s.fields) > 0:
+		for k, v := range s.fields {
+			v.leaves(join(prefix, k), out)
+		}
+	case s.kind == kindList && s.item != nil && (s.item.kind == kindObject || s.item.kind == kindList):
+		s.item.leaves(prefix+"[]", out)
+	default:
+		if prefix != "" {
+			*out = append(*out, prefix)
+		}
+	}
+}
+
+func join(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
@@ -0,0 +1,94 @@
+package lint
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/novuhq/go-novu/lib"
+)
+
+// LayoutSource returns the source of a layout. Layout variables that are not
+// required are optional.
+func LayoutSource(layout lib.LayoutResponse) Source {
+	name := layout.Identifier
+	if name == "" {
+		name = layout.Name
+	}
+	source := Source{Name: "layout " + name, Content: layout.Content}
+	for _, v := range layout.Variables {
+		variable, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		path, _ := variable["name"].(string)
+		if required, _ := variable["required"].(bool); path != "" && !required {
+			source.Optional = append(source.Optional, path)
+		}
+	}
+	return source
+}
+
+// WorkflowSources returns the sources of the steps of a workflow: the
+// content, subject, title, preheader and sender name of each step template,
+// and the payload fields used by step filters.
+func WorkflowSources(workflow string, steps []lib.WorkflowStep) []Source {
+	var sources []Source
+	for i, step := range steps {
+		template := step.Template
+		if template == nil {
+			template = &lib.StepTemplate{}
+		}
+		name := template.Name
+		if name == "" {
+			name = step.Name
+		}
+		if name == "" {
+			name = fmt.Sprintf("step %d", i+1)
+		}
+		prefix := workflow + "/" + name
+
+		if content := template.Content; content != nil {
+			if content.Blocks == nil && content.Text != "" {
+				sources = append(sources, Source{Name: prefix, Content: content.Text})
+			}
+			// Email editor blocks.
+			for j, block := range content.Blocks {
+				for _, text := range []string{block.Content, block.URL} {
+					if text != "" {
+						sources = append(sources, Source{Name: fmt.Sprintf("%s block %d", prefix, j+1), Content: text})
+					}
+				}
+			}
+		}
+		for _, field := range []struct{ name, text string }{
+			{"subject", template.Subject},
+			{"title", template.Title},
+			{"preheader", template.Preheader},
+			{"senderName", template.SenderName},
+		} {
+			if field.text != "" {
+				sources = append(sources, Source{Name: prefix + " " + field.name, Content: field.text})
+			}
+		}
+		if filters := filterSource(step.Filters); filters != "" {
+			sources = append(sources, Source{Name: prefix + " filters", Content: filters})
+		}
+	}
+	return sources
+}
+
+var filterField = regexp.MustCompile(`^[\w$-]+(\.[\w$-]+)*$`)
+
+// filterSource turns the payload fields used by step filters into a template,
+// so they are linted like the other variables.
+func filterSource(filters []lib.StepFilter) string {
+	content := ""
+	for _, filter := range filters {
+		for _, child := range filter.Children {
+			if child.On == "payload" && filterField.MatchString(child.Field) {
+				content += "{{#if " + child.Field + "}}{{/if}}"
+			}
+		}
+	}
+	return content
+}
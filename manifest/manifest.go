@@ -0,0 +1,249 @@
+// Package manifest describes the configuration of a Novu environment in a
+// YAML or JSON file and reconciles the live environment with it.
+//
+// A Reconciler compares the manifest with the environment and plans the
+// changes that make them match, terraform-style; an empty plan means the
+// environment has not drifted from the manifest. Applying the plan creates,
+// updates and, when the manifest prunes, deletes the resources.
+package manifest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"regexp"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+
+	"github.com/novuhq/go-novu/lib"
+)
+
+// Version is the version of the manifest format.
+const Version = 1
+
+// Manifest is the desired configuration of an environment. Resources are
+// matched with the live ones by their key: the identifier of layouts, tenants
+// and integrations, the key of topics and the name of feeds and workflows.
+type Manifest struct {
+	Version      int           `json:"version"`
+	Layouts      []Layout      `json:"layouts,omitempty"`
+	Feeds        []Feed        `json:"feeds,omitempty"`
+	Topics       []Topic       `json:"topics,omitempty"`
+	Tenants      []Tenant      `json:"tenants,omitempty"`
+	Integrations []Integration `json:"integrations,omitempty"`
+	Workflows    []Workflow    `json:"workflows,omitempty"`
+	// Prune deletes the live resources of the kinds listed that are not in
+	// the manifest. Resources of other kinds are left alone.
+	Prune []Kind `json:"prune,omitempty"`
+}
+
+type Layout struct {
+	Identifier  string        `json:"identifier"`
+	Name        string        `json:"name"`
+	Description string        `json:"description,omitempty"`
+	Content     string        `json:"content"`
+	Variables   []interface{} `json:"variables,omitempty"`
+	IsDefault   bool          `json:"isDefault,omitempty"`
+}
+
+type Feed struct {
+	Name string `json:"name"`
+}
+
+type Topic struct {
+	Key  string `json:"key"`
+	Name string `json:"name"`
+	// Subscribers are the subscriber ids of the topic. Memberships are left
+	// alone when nil.
+	Subscribers []string `json:"subscribers,omitempty"`
+}
+
+type Tenant struct {
+	Identifier string                 `json:"identifier"`
+	Name       string                 `json:"name"`
+	Data       map[string]interface{} `json:"data,omitempty"`
+}
+
+type Integration struct {
+	Identifier string          `json:"identifier"`
+	Name       string          `json:"name,omitempty"`
+	ProviderID string          `json:"providerId"`
+	Channel    lib.ChannelType `json:"channel"`
+	Active     bool            `json:"active"`
+	// Credentials only manages the fields that are set. Use ${VAR}
+	// references to keep secrets out of the manifest.
+	Credentials lib.IntegrationCredentials `json:"credentials,omitempty"`
+	Conditions  []lib.IntegrationCondition `json:"conditions,omitempty"`
+}
+
+type Workflow struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Group is the name of the notification group. It defaults to General
+	// and is created when missing.
+	Group    string   `json:"group,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+	Active   bool     `json:"active"`
+	Critical bool     `json:"critical,omitempty"`
+	Steps    []Step   `json:"steps"`
+}
+
+// Step is a step of a workflow. Layouts and feeds are referenced by
+// identifier and name, as their ids change between environments.
+type Step struct {
+	Name       string                 `json:"name"`
+	Type       lib.StepType           `json:"type"`
+	Subject    string                 `json:"subject,omitempty"`
+	Title      string                 `json:"title,omitempty"`
+	Preheader  string                 `json:"preheader,omitempty"`
+	SenderName string                 `json:"senderName,omitempty"`
+	Content    *lib.StepContent       `json:"content,omitempty"`
+	Layout     string                 `json:"layout,omitempty"`
+	Feed       string                 `json:"feed,omitempty"`
+	Filters    []lib.StepFilter       `json:"filters,omitempty"`
+	Metadata   map[string]interface{} `json:"metadata,omitempty"`
+}
+
+var envReference = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// Parse decodes a YAML or JSON manifest. ${VAR} references in string values
+// are replaced with the value of the environment variable VAR, once the
+// document is parsed, so values such as keys or JSON documents need no quoting.
+func Parse(data []byte) (*Manifest, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, errors.Wrap(err, "invalid manifest")
+	}
+	var missing []string
+	expandEnv(&root, &missing)
+	if len(missing) > 0 {
+		return nil, errors.Errorf("manifest references unset environment variables %v", missing)
+	}
+
+	// The YAML document is converted to JSON so that the json tags of the
+	// SDK types apply to both formats.
+	var doc interface{}
+	if err := root.Decode(&doc); err != nil {
+		return nil, errors.Wrap(err, "invalid manifest")
+	}
+	jsonDoc, err := json.Marshal(doc)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid manifest")
+	}
+
+	var m Manifest
+	decoder := json.NewDecoder(bytes.NewReader(jsonDoc))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&m); err != nil {
+		return nil, errors.Wrap(err, "invalid manifest")
+	}
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// expandEnv replaces the ${VAR} references of the string values under n,
+// leaving mapping keys alone.
+func expandEnv(n *yaml.Node, missing *[]string) {
+	switch n.Kind {
+	case yaml.ScalarNode:
+		if n.ShortTag() != "!!str" {
+			return
+		}
+		n.Value = envReference.ReplaceAllStringFunc(n.Value, func(ref string) string {
+			name := envReference.FindStringSubmatch(ref)[1]
+			value, ok := os.LookupEnv(name)
+			if !ok {
+				*missing = append(*missing, name)
+			}
+			return value
+		})
+	case yaml.MappingNode:
+		for i := 1; i < len(n.Content); i += 2 {
+			expandEnv(n.Content[i], missing)
+		}
+	default:
+		for _, child := range n.Content {
+			expandEnv(child, missing)
+		}
+	}
+}
+
+// Load reads and parses a manifest.
+func Load(r io.Reader) (*Manifest, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Validate checks the version of the manifest and that every resource has a
+// unique key.
+func (m *Manifest) Validate() error {
+	if m.Version != Version {
+		return errors.Errorf("unsupported manifest version %d, expected %d", m.Version, Version)
+	}
+
+	keys := map[Kind][]string{}
+	for _, l := range m.Layouts {
+		keys[KindLayout] = append(keys[KindLayout], l.Identifier)
+	}
+	for _, f := range m.Feeds {
+		keys[KindFeed] = append(keys[KindFeed], f.Name)
+	}
+	for _, t := range m.Topics {
+		keys[KindTopic] = append(keys[KindTopic], t.Key)
+	}
+	for _, t := range m.Tenants {
+		keys[KindTenant] = append(keys[KindTenant], t.Identifier)
+	}
+	for _, i := range m.Integrations {
+		keys[KindIntegration] = append(keys[KindIntegration], i.Identifier)
+	}
+	for _, w := range m.Workflows {
+		keys[KindWorkflow] = append(keys[KindWorkflow], w.Name)
+	}
+	for kind, kindKeys := range keys {
+		seen := map[string]bool{}
+		for _, key := range kindKeys {
+			if key == "" {
+				return errors.Errorf("%s without key", kind)
+			}
+			if seen[key] {
+				return errors.Errorf("duplicate %s %q", kind, key)
+			}
+			seen[key] = true
+		}
+	}
+
+	layouts := map[string]bool{}
+	for _, l := range m.Layouts {
+		layouts[l.Identifier] = true
+	}
+	for _, p := range m.Prune {
+		if !p.valid() {
+			return errors.Errorf("cannot prune unknown kind %q", p)
+		}
+	}
+	for _, w := range m.Workflows {
+		for _, s := range w.Steps {
+			if s.Layout != "" && !layouts[s.Layout] && m.prunes(KindLayout) {
+				return errors.Errorf("step %q of workflow %q uses layout %q, which the manifest prunes", s.Name, w.Name, s.Layout)
+			}
+		}
+	}
+	return nil
+}
+
+func (m *Manifest) prunes(kind Kind) bool {
+	for _, p := range m.Prune {
+		if p == kind {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,230 @@
+package manifest_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/novuhq/go-novu/lib"
+	"github.com/novuhq/go-novu/manifest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// liveResponses is the state of the environment served by the fake API.
+var liveResponses = map[string]string{
+	"/v1/layouts": `{"data": [
+		{"_id": "layout-1", "identifier": "default", "name": "Default", "content": "<div>{{{body}}}</div>", "isDefault": true},
+		{"_id": "layout-2", "identifier": "legacy", "name": "Legacy", "content": "{{{body}}}"}
+	], "totalCount": 2}`,
+	"/v1/feeds":   `{"data": [{"_id": "feed-1", "name": "News", "identifier": "news"}]}`,
+	"/v1/tenants": `{"data": [{"_id": "t-1", "identifier": "acme", "name": "Acme", "data": {"plan": "enterprise"}}], "hasMore": false}`,
+	"/v1/integrations": `{"data": [{
+		"_id": "int-1", "identifier": "sendgrid-main", "name": "SendGrid", "providerId": "sendgrid", "channel": "email", "active": true,
+		"credentials": {"apiKey": "old-key", "from": "notifications@example.com", "senderName": "Example"}
+	}]}`,
+	"/v1/topics":      `{"data": [{"key": "beta", "name": "Beta testers"}, {"key": "alpha", "name": "Alpha"}], "totalCount": 2}`,
+	"/v1/topics/beta": `{"key": "beta", "name": "Beta testers", "subscribers": ["sub-1", "sub-2"]}`,
+	"/v1/workflows": `{"data": [{
+		"_id": "wf-1", "name": "Welcome", "active": true, "_notificationGroupId": "group-1",
+		"steps": [
+			{"_id": "step-1", "name": "welcome-email", "active": true, "filters": [], "metadata": {"timed": {}},
+			 "template": {"_id": "tpl-1", "type": "email", "subject": "Welcome", "content": "<p>Glad to have you</p>", "_layoutId": "layout-1"}},
+			{"_id": "step-2", "name": "welcome-inbox", "active": true,
+			 "template": {"_id": "tpl-2", "type": "in_app", "content": "Welcome aboard", "_feedId": "feed-1"}}
+		]
+	}], "totalCount": 1}`,
+	"/v1/notification-groups": `{"data": [{"_id": "group-1", "name": "General"}]}`,
+}
+
+type request struct {
+	Method string
+	Path   string
+	Body   map[string]interface{}
+}
+
+func newFakeNovu(t *testing.T) (*lib.APIClient, *[]request) {
+	var requests []request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodGet {
+			resp, ok := liveResponses[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`{"message": "not found"}`))
+				return
+			}
+			w.Write([]byte(resp))
+			return
+		}
+
+		req := request{Method: r.Method, Path: r.URL.Path}
+		if bb, _ := io.ReadAll(r.Body); len(bb) > 0 {
+			require.NoError(t, json.Unmarshal(bb, &req.Body))
+		}
+		requests = append(requests, req)
+		if r.Method == http.MethodPost && r.URL.Path == "/v1/topics" {
+			w.WriteHeader(http.StatusCreated)
+		}
+		id := strings.ReplaceAll(strings.TrimPrefix(r.URL.Path, "/v1/"), "/", "-") + "-new"
+		w.Write([]byte(`{"data": {"_id": "` + id + `"}}`))
+	}))
+	t.Cleanup(server.Close)
+	return lib.NewAPIClient("api-key", &lib.Config{BackendURL: lib.MustParseURL(server.URL)}), &requests
+}
+
+func loadManifest(t *testing.T) *manifest.Manifest {
+	t.Setenv("SENDGRID_API_KEY", "new-key")
+	f, err := os.Open("../testdata/environment_manifest.yaml")
+	require.NoError(t, err)
+	defer f.Close()
+
+	m, err := manifest.Load(f)
+	require.NoError(t, err)
+	return m
+}
+
+const expectedPlan = `~ layout "default"
+    content: "<div>{{{body}}}</div>" -> "<main>{{{body}}}</main>"
++ layout "receipts"
++ feed "Billing"
+~ tenant "acme"
+    name: "Acme" -> "Acme Inc"
++ tenant "globex"
+~ integration "sendgrid-main"
+    credentials.apiKey: "(sensitive)" -> "(sensitive)"
+~ topic "beta"
+    subscribers: ["sub-1","sub-2"] -> ["sub-1","sub-3"]
+~ workflow "Welcome"
+    steps[0].subject: "Welcome" -> "Welcome {{subscriber.firstName}}"
++ workflow "Invoice ready"
+- topic "alpha"
+- layout "legacy"
+
+Plan: 4 to create, 5 to update, 2 to delete.
+`
+
+func TestPlanAndApply(t *testing.T) {
+	ctx := context.Background()
+	client, requests := newFakeNovu(t)
+	m := loadManifest(t)
+	assert.Equal(t, "new-key", m.Integrations[0].Credentials.ApiKey)
+
+	r := manifest.NewReconciler(client)
+	plan, err := r.Plan(ctx, m)
+	require.NoError(t, err)
+	assert.Equal(t, expectedPlan, plan.String())
+	assert.Empty(t, *requests, "planning must not change the environment")
+
+	require.NoError(t, r.Apply(ctx, plan))
+
+	var calls []string
+	bodies := map[string]map[string]interface{}{}
+	for _, req := range *requests {
+		calls = append(calls, req.Method+" "+req.Path)
+		bodies[req.Method+" "+req.Path] = req.Body
+	}
+	assert.Equal(t, []string{
+		"PATCH /v1/layouts/layout-1",
+		"POST /v1/layouts",
+		"POST /v1/feeds",
+		"PATCH /v1/tenants/acme",
+		"POST /v1/tenants",
+		"PUT /v1/integrations/int-1",
+		"POST /v1/topics/beta/subscribers",
+		"POST /v1/topics/beta/subscribers/removal",
+		"PUT /v1/workflows/wf-1",
+		"POST /v1/notification-groups",
+		"POST /v1/workflows",
+		"DELETE /v1/topics/alpha",
+		"DELETE /v1/layouts/layout-2",
+	}, calls)
+
+	// Updates keep the credentials missing from the manifest.
+	assert.Equal(t, map[string]interface{}{
+		"apiKey":     "new-key",
+		"from":       "notifications@example.com",
+		"senderName": "Example",
+	}, bodies["PUT /v1/integrations/int-1"]["credentials"])
+	// The conditions are sent even when empty, so that removed ones are cleared.
+	assert.Equal(t, []interface{}{}, bodies["PUT /v1/integrations/int-1"]["conditions"])
+
+	// Workflow steps keep their ids and reference created resources.
+	updated := bodies["PUT /v1/workflows/wf-1"]["steps"].([]interface{})
+	assert.Equal(t, "step-1", updated[0].(map[string]interface{})["_id"])
+	assert.Equal(t, "layout-1", updated[0].(map[string]interface{})["template"].(map[string]interface{})["_layoutId"])
+	created := bodies["POST /v1/workflows"]
+	assert.Equal(t, "notification-groups-new", created["notificationGroupId"])
+	assert.Equal(t, true, created["draft"])
+	template := created["steps"].([]interface{})[0].(map[string]interface{})["template"].(map[string]interface{})
+	assert.Equal(t, "layouts-new", template["_layoutId"])
+}
+
+func TestPlanWithoutDrift(t *testing.T) {
+	client, requests := newFakeNovu(t)
+	m, err := manifest.Parse([]byte(`{
+		"version": 1,
+		"feeds": [{"name": "News"}],
+		"tenants": [{"identifier": "acme", "name": "Acme", "data": {"plan": "enterprise"}}],
+		"topics": [{"key": "beta", "name": "Beta testers"}]
+	}`))
+	require.NoError(t, err)
+
+	plan, err := manifest.NewReconciler(client).Plan(context.Background(), m)
+	require.NoError(t, err)
+	assert.False(t, plan.HasChanges())
+	assert.Equal(t, "No changes. The environment matches the manifest.\n", plan.String())
+	assert.Empty(t, *requests)
+}
+
+func TestParseEnvironmentVariables(t *testing.T) {
+	secret := "-----BEGIN KEY-----\n\"a\": b # c\n-----END KEY-----\n"
+	t.Setenv("MANIFEST_TEST_SECRET", secret)
+	t.Setenv("MANIFEST_TEST_INJECTION", "x\n  secretKey: injected")
+	m, err := manifest.Parse([]byte(`version: 1
+# ${MANIFEST_TEST_UNSET} in a comment is ignored.
+integrations:
+  - identifier: sendgrid
+    providerId: sendgrid
+    channel: email
+    credentials:
+      apiKey: ${MANIFEST_TEST_SECRET}
+      user: "${MANIFEST_TEST_INJECTION}"
+`))
+	require.NoError(t, err)
+	credentials := m.Integrations[0].Credentials
+	assert.Equal(t, secret, credentials.ApiKey)
+	assert.Equal(t, "x\n  secretKey: injected", credentials.User)
+	assert.Empty(t, credentials.SecretKey)
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest string
+		err      string
+	}{
+		{"Unsupported version", `version: 2`, "unsupported manifest version 2"},
+		{"Unknown field", "version: 1\nlayout: []", `unknown field "layout"`},
+		{"Duplicate key", "version: 1\ntenants: [{identifier: a, name: A}, {identifier: a, name: B}]", `duplicate tenant "a"`},
+		{"Missing key", "version: 1\ntopics: [{name: A}]", "topic without key"},
+		{"Unknown prune kind", "version: 1\nprune: [subscriber]", `cannot prune unknown kind "subscriber"`},
+		{"Unset variable", "version: 1\nfeeds: [{name: '${MANIFEST_TEST_UNSET}'}]", "unset environment variables [MANIFEST_TEST_UNSET]"},
+		{
+			"Pruned layout in use",
+			"version: 1\nprune: [layout]\nworkflows: [{name: W, steps: [{name: s, type: email, layout: gone}]}]",
+			`uses layout "gone", which the manifest prunes`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := manifest.Parse([]byte(tt.manifest))
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+}
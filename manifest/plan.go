@@ -0,0 +1,148 @@
+package manifest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Kind is a kind of resource of a manifest.
+type Kind string
+
+const (
+	KindLayout      Kind = "layout"
+	KindFeed        Kind = "feed"
+	KindTopic       Kind = "topic"
+	KindTenant      Kind = "tenant"
+	KindIntegration Kind = "integration"
+	KindWorkflow    Kind = "workflow"
+)
+
+// kinds are in the order changes are applied: workflows reference layouts
+// and feeds, integration conditions reference tenants.
+var kinds = []Kind{KindLayout, KindFeed, KindTenant, KindIntegration, KindTopic, KindWorkflow}
+
+func (k Kind) valid() bool {
+	for _, kind := range kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+type Action string
+
+const (
+	ActionCreate Action = "create"
+	ActionUpdate Action = "update"
+	ActionDelete Action = "delete"
+)
+
+// FieldDiff is a field of a resource whose live value differs from the
+// manifest.
+type FieldDiff struct {
+	Field   string
+	Live    interface{}
+	Desired interface{}
+}
+
+// Change is a change of a resource planned by a Reconciler.
+type Change struct {
+	Action Action
+	Kind   Kind
+	Key    string
+	// Diff lists the fields changed by an update.
+	Diff  []FieldDiff
+	apply func(ctx context.Context) error
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("%s %s %q", c.Action, c.Kind, c.Key)
+}
+
+// Plan is the list of changes that make an environment match a manifest, in
+// the order they are applied.
+type Plan struct {
+	Changes []Change
+}
+
+// HasChanges tells whether the environment differs from the manifest.
+func (p *Plan) HasChanges() bool {
+	return len(p.Changes) > 0
+}
+
+// Count returns the number of changes of each action.
+func (p *Plan) Count() map[Action]int {
+	count := map[Action]int{}
+	for _, c := range p.Changes {
+		count[c.Action]++
+	}
+	return count
+}
+
+// String renders the plan with one line per change, followed by the changed
+// fields of updates.
+func (p *Plan) String() string {
+	if !p.HasChanges() {
+		return "No changes. The environment matches the manifest.\n"
+	}
+
+	var b strings.Builder
+	for _, c := range p.Changes {
+		symbol := map[Action]string{ActionCreate: "+", ActionUpdate: "~", ActionDelete: "-"}[c.Action]
+		fmt.Fprintf(&b, "%s %s %q\n", symbol, c.Kind, c.Key)
+		for _, d := range c.Diff {
+			fmt.Fprintf(&b, "    %s: %s -> %s\n", d.Field, formatValue(d.Live), formatValue(d.Desired))
+		}
+	}
+	count := p.Count()
+	fmt.Fprintf(&b, "\nPlan: %d to create, %d to update, %d to delete.\n", count[ActionCreate], count[ActionUpdate], count[ActionDelete])
+	return b.String()
+}
+
+func formatValue(v interface{}) string {
+	var b strings.Builder
+	encoder := json.NewEncoder(&b)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		return fmt.Sprint(v)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// diff collects the fields whose live and desired values differ, comparing
+// their JSON representations.
+type diff []FieldDiff
+
+func (d *diff) field(name string, live, desired interface{}) {
+	if !reflect.DeepEqual(normalize(live), normalize(desired)) {
+		*d = append(*d, FieldDiff{Field: name, Live: live, Desired: desired})
+	}
+}
+
+func normalize(v interface{}) interface{} {
+	bb, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var n interface{}
+	json.Unmarshal(bb, &n)
+	switch n := n.(type) {
+	case []interface{}:
+		if len(n) == 0 {
+			return nil
+		}
+	case map[string]interface{}:
+		if len(n) == 0 {
+			return nil
+		}
+	case string:
+		if n == "" {
+			return nil
+		}
+	}
+	return n
+}
@@ -0,0 +1,739 @@
+package manifest
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/novuhq/go-novu/internal/convert"
+	"github.com/novuhq/go-novu/lib"
+)
+
+const (
+	defaultPageSize = 100
+	defaultGroup    = "General"
+	sensitiveValue  = "(sensitive)"
+)
+
+// Reconciler plans and applies the changes that make an environment match a
+// manifest.
+type Reconciler struct {
+	client *lib.APIClient
+	// PageSize is the number of resources fetched per page. It defaults to
+	// 100.
+	PageSize int
+}
+
+func NewReconciler(client *lib.APIClient) *Reconciler {
+	return &Reconciler{client: client, PageSize: defaultPageSize}
+}
+
+// state holds the ids of the live resources referenced by others. Applying
+// a plan records the ids of the resources it creates.
+type state struct {
+	layouts map[string]string
+	feeds   map[string]string
+	groups  map[string]string
+}
+
+func (r *Reconciler) pageSize() int {
+	if r.PageSize <= 0 {
+		return defaultPageSize
+	}
+	return r.PageSize
+}
+
+// Plan compares the environment with m. Creations and updates are ordered so
+// that resources exist before being referenced; deletions come last.
+func (r *Reconciler) Plan(ctx context.Context, m *Manifest) (*Plan, error) {
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+
+	s := &state{layouts: map[string]string{}, feeds: map[string]string{}, groups: map[string]string{}}
+	planners := map[Kind]func(ctx context.Context, m *Manifest, s *state) (changes, deletes []Change, err error){
+		KindLayout:      r.planLayouts,
+		KindFeed:        r.planFeeds,
+		KindTenant:      r.planTenants,
+		KindIntegration: r.planIntegrations,
+		KindTopic:       r.planTopics,
+		KindWorkflow:    r.planWorkflows,
+	}
+
+	plan := &Plan{}
+	var deletes [][]Change
+	for _, kind := range kinds {
+		changes, kindDeletes, err := planners[kind](ctx, m, s)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to plan %ss", kind)
+		}
+		plan.Changes = append(plan.Changes, changes...)
+		if m.prunes(kind) {
+			deletes = append(deletes, kindDeletes)
+		}
+	}
+	for i := len(deletes) - 1; i >= 0; i-- {
+		plan.Changes = append(plan.Changes, deletes[i]...)
+	}
+	return plan, nil
+}
+
+// Apply applies the changes of plan in order, stopping at the first failure.
+// The changes applied before the failure are not rolled back; planning again
+// shows what is left.
+func (r *Reconciler) Apply(ctx context.Context, plan *Plan) error {
+	for _, c := range plan.Changes {
+		if err := c.apply(ctx); err != nil {
+			return errors.Wrapf(err, "unable to %s", c)
+		}
+	}
+	return nil
+}
+
+func (r *Reconciler) planLayouts(ctx context.Context, m *Manifest, s *state) ([]Change, []Change, error) {
+	live := map[string]lib.LayoutResponse{}
+	err := r.client.LayoutApi.IterateLayouts(ctx, r.pageSize(), func(l lib.LayoutResponse) error {
+		live[l.Identifier] = l
+		s.layouts[l.Identifier] = l.Id
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var changes []Change
+	for _, desired := range m.Layouts {
+		desired := desired
+		request := lib.CreateLayoutRequest{
+			Name:        desired.Name,
+			Identifier:  desired.Identifier,
+			Description: desired.Description,
+			Content:     desired.Content,
+			Variables:   desired.Variables,
+			IsDefault:   desired.IsDefault,
+		}
+
+		l, ok := live[desired.Identifier]
+		if !ok {
+			changes = append(changes, Change{Action: ActionCreate, Kind: KindLayout, Key: desired.Identifier, apply: func(ctx context.Context) error {
+				resp, err := r.client.LayoutApi.Create(ctx, request)
+				if err != nil {
+					return err
+				}
+				s.layouts[desired.Identifier] = resp.Data.Id
+				if desired.IsDefault {
+					return r.client.LayoutApi.SetDefault(ctx, resp.Data.Id)
+				}
+				return nil
+			}})
+			continue
+		}
+
+		var d diff
+		d.field("name", l.Name, desired.Name)
+		d.field("description", l.Description, desired.Description)
+		d.field("content", l.Content, desired.Content)
+		if desired.Variables != nil {
+			d.field("variables", l.Variables, desired.Variables)
+		}
+		if desired.IsDefault {
+			d.field("isDefault", l.IsDefault, desired.IsDefault)
+		}
+		if len(d) == 0 {
+			continue
+		}
+		changes = append(changes, Change{Action: ActionUpdate, Kind: KindLayout, Key: desired.Identifier, Diff: d, apply: func(ctx context.Context) error {
+			if _, err := r.client.LayoutApi.Update(ctx, l.Id, request); err != nil {
+				return err
+			}
+			if desired.IsDefault && !l.IsDefault {
+				return r.client.LayoutApi.SetDefault(ctx, l.Id)
+			}
+			return nil
+		}})
+	}
+
+	desired := map[string]bool{}
+	for _, l := range m.Layouts {
+		desired[l.Identifier] = true
+	}
+	var deletes []Change
+	for _, identifier := range sortedKeys(live) {
+		if desired[identifier] {
+			continue
+		}
+		id := live[identifier].Id
+		deletes = append(deletes, Change{Action: ActionDelete, Kind: KindLayout, Key: identifier, apply: func(ctx context.Context) error {
+			return r.client.LayoutApi.Delete(ctx, id)
+		}})
+	}
+	return changes, deletes, nil
+}
+
+type liveFeed struct {
+	Id         string `json:"_id"`
+	Name       string `json:"name"`
+	Identifier string `json:"identifier"`
+}
+
+func (r *Reconciler) planFeeds(ctx context.Context, m *Manifest, s *state) ([]Change, []Change, error) {
+	resp, err := r.client.FeedsApi.GetFeeds(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	var feeds []liveFeed
+	if err := convert.JSON(resp.Data, &feeds); err != nil {
+		return nil, nil, err
+	}
+	live := map[string]liveFeed{}
+	for _, f := range feeds {
+		live[f.Name] = f
+		s.feeds[f.Name] = f.Id
+	}
+
+	var changes []Change
+	desired := map[string]bool{}
+	for _, f := range m.Feeds {
+		name := f.Name
+		desired[name] = true
+		if _, ok := live[name]; ok {
+			continue
+		}
+		changes = append(changes, Change{Action: ActionCreate, Kind: KindFeed, Key: name, apply: func(ctx context.Context) error {
+			resp, err := r.client.FeedsApi.CreateFeed(ctx, name)
+			if err != nil {
+				return err
+			}
+			var created liveFeed
+			if err := convert.JSON(resp.Data, &created); err != nil {
+				return err
+			}
+			s.feeds[name] = created.Id
+			return nil
+		}})
+	}
+
+	var deletes []Change
+	for _, name := range sortedKeys(live) {
+		if desired[name] {
+			continue
+		}
+		id := live[name].Id
+		deletes = append(deletes, Change{Action: ActionDelete, Kind: KindFeed, Key: name, apply: func(ctx context.Context) error {
+			_, err := r.client.FeedsApi.DeleteFeed(ctx, id)
+			return err
+		}})
+	}
+	return changes, deletes, nil
+}
+
+func (r *Reconciler) planTenants(ctx context.Context, m *Manifest, s *state) ([]Change, []Change, error) {
+	live := map[string]lib.Tenant{}
+	err := r.client.TenantApi.IterateTenants(ctx, r.pageSize(), func(tenant lib.Tenant) error {
+		live[tenant.Identifier] = tenant
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var changes []Change
+	desired := map[string]bool{}
+	for _, t := range m.Tenants {
+		t := t
+		desired[t.Identifier] = true
+		l, ok := live[t.Identifier]
+		if !ok {
+			changes = append(changes, Change{Action: ActionCreate, Kind: KindTenant, Key: t.Identifier, apply: func(ctx context.Context) error {
+				_, err := r.client.TenantApi.UpsertTenant(ctx, lib.CreateTenantRequest{Identifier: t.Identifier, Name: t.Name, Data: t.Data})
+				return err
+			}})
+			continue
+		}
+
+		var d diff
+		d.field("name", l.Name, t.Name)
+		d.field("data", l.Data, t.Data)
+		if len(d) == 0 {
+			continue
+		}
+		changes = append(changes, Change{Action: ActionUpdate, Kind: KindTenant, Key: t.Identifier, Diff: d, apply: func(ctx context.Context) error {
+			_, err := r.client.TenantApi.UpdateTenant(ctx, t.Identifier, &lib.UpdateTenantRequest{Name: t.Name, Data: t.Data})
+			return err
+		}})
+	}
+
+	var deletes []Change
+	for _, identifier := range sortedKeys(live) {
+		if desired[identifier] {
+			continue
+		}
+		identifier := identifier
+		deletes = append(deletes, Change{Action: ActionDelete, Kind: KindTenant, Key: identifier, apply: func(ctx context.Context) error {
+			return r.client.TenantApi.DeleteTenant(ctx, identifier)
+		}})
+	}
+	return changes, deletes, nil
+}
+
+func (r *Reconciler) planIntegrations(ctx context.Context, m *Manifest, s *state) ([]Change, []Change, error) {
+	resp, err := r.client.IntegrationsApi.GetAll(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	live := map[string]lib.Integration{}
+	for _, i := range resp.Data {
+		if i.Identifier != "" {
+			live[i.Identifier] = i
+		}
+	}
+
+	var changes []Change
+	desired := map[string]bool{}
+	for _, i := range m.Integrations {
+		i := i
+		desired[i.Identifier] = true
+		l, ok := live[i.Identifier]
+		if !ok {
+			changes = append(changes, Change{Action: ActionCreate, Kind: KindIntegration, Key: i.Identifier, apply: func(ctx context.Context) error {
+				_, err := r.client.IntegrationsApi.Create(ctx, lib.CreateIntegrationRequest{
+					Name:        i.Name,
+					Identifier:  i.Identifier,
+					ProviderID:  i.ProviderID,
+					Channel:     i.Channel,
+					Credentials: i.Credentials,
+					Active:      i.Active,
+					Conditions:  i.Conditions,
+				})
+				return err
+			}})
+			continue
+		}
+		if l.ProviderID != i.ProviderID || l.Channel != i.Channel {
+			return nil, nil, errors.Errorf("integration %q is a %s %s integration, its provider cannot change to %s %s", i.Identifier, l.Channel, l.ProviderID, i.Channel, i.ProviderID)
+		}
+
+		var d diff
+		if i.Name != "" {
+			d.field("name", l.Name, i.Name)
+		}
+		d.field("active", l.Active, i.Active)
+		d.credentials(l.Credentials, i.Credentials)
+		d.field("conditions", l.Conditions, i.Conditions)
+		if len(d) == 0 {
+			continue
+		}
+
+		// Updates replace every credential: the live ones are kept unless
+		// the manifest sets them.
+		credentials := lib.MergeCredentials(l.Credentials, i.Credentials)
+		id := l.Id
+		changes = append(changes, Change{Action: ActionUpdate, Kind: KindIntegration, Key: i.Identifier, Diff: d, apply: func(ctx context.Context) error {
+			_, err := r.client.IntegrationsApi.Update(ctx, id, lib.UpdateIntegrationRequest{
+				Name:        i.Name,
+				Identifier:  i.Identifier,
+				Credentials: credentials,
+				Active:      i.Active,
+				Conditions:  lib.IntegrationConditions(i.Conditions...),
+			})
+			return err
+		}})
+	}
+
+	var deletes []Change
+	for _, identifier := range sortedKeys(live) {
+		if desired[identifier] {
+			continue
+		}
+		id := live[identifier].Id
+		deletes = append(deletes, Change{Action: ActionDelete, Kind: KindIntegration, Key: identifier, apply: func(ctx context.Context) error {
+			_, err := r.client.IntegrationsApi.Delete(ctx, id)
+			return err
+		}})
+	}
+	return changes, deletes, nil
+}
+
+func (r *Reconciler) planTopics(ctx context.Context, m *Manifest, s *state) ([]Change, []Change, error) {
+	live := map[string]lib.GetTopicResponse{}
+	err := r.client.TopicsApi.IterateTopics(ctx, r.pageSize(), func(t lib.GetTopicResponse) error {
+		live[t.Key] = t
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var changes []Change
+	desired := map[string]bool{}
+	for _, t := range m.Topics {
+		t := t
+		desired[t.Key] = true
+		l, ok := live[t.Key]
+		if !ok {
+			changes = append(changes, Change{Action: ActionCreate, Kind: KindTopic, Key: t.Key, apply: func(ctx context.Context) error {
+				if err := r.client.TopicsApi.Create(ctx, t.Key, t.Name); err != nil {
+					return err
+				}
+				if len(t.Subscribers) == 0 {
+					return nil
+				}
+				return r.client.TopicsApi.AddSubscribers(ctx, t.Key, t.Subscribers)
+			}})
+			continue
+		}
+
+		var d diff
+		d.field("name", l.Name, t.Name)
+		var added, removed []string
+		if t.Subscribers != nil {
+			topic, err := r.client.TopicsApi.Get(ctx, t.Key)
+			if err != nil {
+				return nil, nil, err
+			}
+			added, removed = difference(topic.Subscribers, t.Subscribers)
+			if len(added) > 0 || len(removed) > 0 {
+				d = append(d, FieldDiff{Field: "subscribers", Live: sortedCopy(topic.Subscribers), Desired: sortedCopy(t.Subscribers)})
+			}
+		}
+		if len(d) == 0 {
+			continue
+		}
+		changes = append(changes, Change{Action: ActionUpdate, Kind: KindTopic, Key: t.Key, Diff: d, apply: func(ctx context.Context) error {
+			if l.Name != t.Name {
+				if _, err := r.client.TopicsApi.Rename(ctx, t.Key, t.Name); err != nil {
+					return err
+				}
+			}
+			if len(added) > 0 {
+				if err := r.client.TopicsApi.AddSubscribers(ctx, t.Key, added); err != nil {
+					return err
+				}
+			}
+			if len(removed) > 0 {
+				return r.client.TopicsApi.RemoveSubscribers(ctx, t.Key, removed)
+			}
+			return nil
+		}})
+	}
+
+	var deletes []Change
+	for _, key := range sortedKeys(live) {
+		if desired[key] {
+			continue
+		}
+		key := key
+		deletes = append(deletes, Change{Action: ActionDelete, Kind: KindTopic, Key: key, apply: func(ctx context.Context) error {
+			return r.client.TopicsApi.Delete(ctx, key)
+		}})
+	}
+	return changes, deletes, nil
+}
+
+func (r *Reconciler) planWorkflows(ctx context.Context, m *Manifest, s *state) ([]Change, []Change, error) {
+	live := map[string]lib.Workflow{}
+	err := r.client.WorkflowApi.IterateWorkflows(ctx, r.pageSize(), func(w lib.Workflow) error {
+		live[w.Name] = w
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	groups, err := r.client.WorkflowApi.ListNotificationGroups(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	groupNames := map[string]string{}
+	for _, g := range groups {
+		groupNames[g.Id] = g.Name
+		s.groups[g.Name] = g.Id
+	}
+	layoutIdentifiers := invert(s.layouts)
+	feedNames := invert(s.feeds)
+
+	var changes []Change
+	desired := map[string]bool{}
+	for _, w := range m.Workflows {
+		w := w
+		desired[w.Name] = true
+		group := w.Group
+		if group == "" {
+			group = defaultGroup
+		}
+
+		l, ok := live[w.Name]
+		if !ok {
+			changes = append(changes, Change{Action: ActionCreate, Kind: KindWorkflow, Key: w.Name, apply: func(ctx context.Context) error {
+				groupId, err := r.groupId(ctx, s, group)
+				if err != nil {
+					return err
+				}
+				steps, err := workflowSteps(w.Steps, nil, s)
+				if err != nil {
+					return err
+				}
+				_, err = r.client.WorkflowApi.Create(ctx, lib.CreateWorkflowRequest{
+					Name:                w.Name,
+					NotificationGroupId: groupId,
+					Description:         w.Description,
+					Tags:                w.Tags,
+					Steps:               steps,
+					Active:              w.Active,
+					Draft:               !w.Active,
+					Critical:            w.Critical,
+				})
+				return err
+			}})
+			continue
+		}
+
+		var d diff
+		d.field("description", l.Description, w.Description)
+		d.field("group", groupNames[l.NotificationGroupID], group)
+		d.field("tags", l.Tags, w.Tags)
+		d.field("active", l.Active, w.Active)
+		d.field("critical", l.Critical, w.Critical)
+		liveSteps := manifestSteps(l.Steps, layoutIdentifiers, feedNames)
+		if len(liveSteps) != len(w.Steps) {
+			d.field("steps", liveSteps, w.Steps)
+		} else {
+			for i := range w.Steps {
+				d.object(fmt.Sprintf("steps[%d].", i), liveSteps[i], desiredStep(w.Steps[i], liveSteps[i]))
+			}
+		}
+		if len(d) == 0 {
+			continue
+		}
+
+		changes = append(changes, Change{Action: ActionUpdate, Kind: KindWorkflow, Key: w.Name, Diff: d, apply: func(ctx context.Context) error {
+			groupId, err := r.groupId(ctx, s, group)
+			if err != nil {
+				return err
+			}
+			steps, err := workflowSteps(w.Steps, l.Steps, s)
+			if err != nil {
+				return err
+			}
+			_, err = r.client.WorkflowApi.Update(ctx, l.Id, lib.UpdateWorkflowRequest{
+				Name:                w.Name,
+				NotificationGroupId: groupId,
+				Description:         w.Description,
+				Tags:                w.Tags,
+				Steps:               steps,
+				Critical:            lib.Bool(w.Critical),
+			})
+			if err != nil {
+				return err
+			}
+			if l.Active != w.Active {
+				_, err = r.client.WorkflowApi.UpdateStatus(ctx, l.Id, w.Active)
+			}
+			return err
+		}})
+	}
+
+	var deletes []Change
+	for _, name := range sortedKeys(live) {
+		if desired[name] {
+			continue
+		}
+		id := live[name].Id
+		deletes = append(deletes, Change{Action: ActionDelete, Kind: KindWorkflow, Key: name, apply: func(ctx context.Context) error {
+			return r.client.WorkflowApi.Delete(ctx, id)
+		}})
+	}
+	return changes, deletes, nil
+}
+
+func (r *Reconciler) groupId(ctx context.Context, s *state, name string) (string, error) {
+	if id, ok := s.groups[name]; ok {
+		return id, nil
+	}
+	id, err := r.client.WorkflowApi.NotificationGroupId(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	s.groups[name] = id
+	return id, nil
+}
+
+// manifestSteps converts live steps to the manifest format, replacing the ids
+// of layouts and feeds with their identifier and name.
+func manifestSteps(steps []lib.WorkflowStep, layoutIdentifiers, feedNames map[string]string) []Step {
+	converted := []Step{}
+	for _, step := range steps {
+		s := Step{Name: step.Name, Filters: step.Filters, Metadata: step.Metadata}
+		if t := step.Template; t != nil {
+			s.Type = t.Type
+			s.Subject = t.Subject
+			s.Title = t.Title
+			s.Preheader = t.Preheader
+			s.SenderName = t.SenderName
+			s.Content = t.Content
+			s.Layout = layoutIdentifiers[t.LayoutId]
+			s.Feed = feedNames[t.FeedId]
+			if s.Name == "" {
+				s.Name = t.Name
+			}
+		}
+		converted = append(converted, s)
+	}
+	return converted
+}
+
+// desiredStep returns the step of the manifest with the metadata Novu adds
+// to live steps, so that only the metadata of the manifest is compared.
+func desiredStep(desired, live Step) Step {
+	if desired.Metadata == nil {
+		desired.Metadata = live.Metadata
+		return desired
+	}
+	metadata := map[string]interface{}{}
+	for k, v := range live.Metadata {
+		metadata[k] = v
+	}
+	for k, v := range desired.Metadata {
+		metadata[k] = v
+	}
+	desired.Metadata = metadata
+	return desired
+}
+
+// workflowSteps converts the steps of the manifest, keeping the ids of the
+// live steps with the same name so that updates don't recreate them.
+func workflowSteps(steps []Step, live []lib.WorkflowStep, s *state) ([]lib.WorkflowStep, error) {
+	liveByName := map[string]lib.WorkflowStep{}
+	for _, step := range live {
+		liveByName[step.Name] = step
+	}
+
+	converted := []lib.WorkflowStep{}
+	for _, step := range steps {
+		template := &lib.StepTemplate{
+			Type:       step.Type,
+			Name:       step.Name,
+			Subject:    step.Subject,
+			Title:      step.Title,
+			Preheader:  step.Preheader,
+			SenderName: step.SenderName,
+			Content:    step.Content,
+		}
+		if step.Layout != "" {
+			id, ok := s.layouts[step.Layout]
+			if !ok {
+				return nil, errors.Errorf("step %q uses unknown layout %q", step.Name, step.Layout)
+			}
+			template.LayoutId = id
+		}
+		if step.Feed != "" {
+			id, ok := s.feeds[step.Feed]
+			if !ok {
+				return nil, errors.Errorf("step %q uses unknown feed %q", step.Name, step.Feed)
+			}
+			template.FeedId = id
+		}
+
+		converted = append(converted, lib.WorkflowStep{
+			Name:     step.Name,
+			Active:   true,
+			Filters:  step.Filters,
+			Metadata: step.Metadata,
+			Template: template,
+		})
+		if l, ok := liveByName[step.Name]; ok {
+			last := &converted[len(converted)-1]
+			last.Id = l.Id
+			last.TemplateId = l.TemplateId
+			if l.Template != nil {
+				template.Id = l.Template.Id
+			}
+		}
+	}
+	return converted, nil
+}
+
+// object compares the fields of the JSON objects live and desired.
+func (d *diff) object(prefix string, live, desired interface{}) {
+	var liveFields, desiredFields map[string]interface{}
+	convert.JSON(live, &liveFields)
+	convert.JSON(desired, &desiredFields)
+	keys := map[string]bool{}
+	for k := range liveFields {
+		keys[k] = true
+	}
+	for k := range desiredFields {
+		keys[k] = true
+	}
+	for _, k := range sortedKeys(keys) {
+		d.field(prefix+k, liveFields[k], desiredFields[k])
+	}
+}
+
+// credentials compares the credentials set in desired, masking the secrets.
+func (d *diff) credentials(live, desired lib.IntegrationCredentials) {
+	liveValues := map[string]interface{}{}
+	for key, value := range live.Values() {
+		liveValues[string(key)] = value
+	}
+	desiredValues := map[string]interface{}{}
+	for key, value := range desired.Values() {
+		desiredValues[string(key)] = value
+	}
+
+	for _, key := range sortedKeys(desiredValues) {
+		var field diff
+		field.field("credentials."+key, liveValues[key], desiredValues[key])
+		if len(field) > 0 && lib.CredentialKey(key).IsSecret() {
+			field[0].Live, field[0].Desired = sensitiveValue, sensitiveValue
+		}
+		*d = append(*d, field...)
+	}
+}
+
+// difference returns the values of desired missing from live, and those of
+// live missing from desired.
+func difference(live, desired []string) (added, removed []string) {
+	inLive := map[string]bool{}
+	for _, v := range live {
+		inLive[v] = true
+	}
+	inDesired := map[string]bool{}
+	for _, v := range desired {
+		inDesired[v] = true
+		if !inLive[v] {
+			added = append(added, v)
+		}
+	}
+	for _, v := range live {
+		if !inDesired[v] {
+			removed = append(removed, v)
+		}
+	}
+	return added, removed
+}
+
+func sortedCopy(values []string) []string {
+	sorted := append([]string{}, values...)
+	sort.Strings(sorted)
+	return sorted
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func invert(m map[string]string) map[string]string {
+	inverted := map[string]string{}
+	for k, v := range m {
+		inverted[v] = k
+	}
+	return inverted
+}
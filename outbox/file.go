@@ -0,0 +1,278 @@
+package outbox
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+type FileStoreOptions struct {
+	// NoSync skips the fsync after each write. Entries may then be lost when
+	// the machine, rather than the process, stops.
+	NoSync bool
+	// CompactAfter is the number of removed entries above which the log is
+	// rewritten without them. It defaults to 1000.
+	CompactAfter int
+}
+
+// ErrFileStoreLocked is returned by OpenFileStore when another FileStore, of
+// this process or another one, has the log open.
+var ErrFileStoreLocked = errors.New("outbox is in use by another process")
+
+// FileStore is a Store keeping the entries in an append-only log of JSON
+// lines, which records the appended entries and the removed keys. The log is
+// compacted as entries are removed.
+//
+// The store holds an advisory lock on a ".lock" file next to the log while it
+// is open, on the systems supporting flock, so that two processes do not
+// write the same log.
+type FileStore struct {
+	path string
+	opts FileStoreOptions
+
+	mu      sync.Mutex
+	lock    *os.File
+	file    *os.File
+	entries map[string]Entry
+	// order holds the keys in the order of the log, including removed ones
+	// until the next compaction.
+	order   []string
+	removed int
+}
+
+type logRecord struct {
+	Entry  *Entry `json:"entry,omitempty"`
+	Remove string `json:"remove,omitempty"`
+}
+
+// OpenFileStore opens the log at path, creating it when missing. A partial
+// record left at the end of the log by a crash is discarded.
+func OpenFileStore(path string, opts *FileStoreOptions) (*FileStore, error) {
+	s := &FileStore{path: path, entries: map[string]Entry{}}
+	if opts != nil {
+		s.opts = *opts
+	}
+	if s.opts.CompactAfter <= 0 {
+		s.opts.CompactAfter = 1000
+	}
+
+	// The log itself is not locked, as compactions replace it.
+	lock, err := os.OpenFile(path+".lock", os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to lock outbox")
+	}
+	if err := lockFile(lock); err != nil {
+		lock.Close()
+		if errors.Is(err, ErrFileStoreLocked) {
+			return nil, err
+		}
+		return nil, errors.Wrap(err, "unable to lock outbox")
+	}
+
+	// Writes go to the end of the log, including after a truncation.
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		lock.Close()
+		return nil, errors.Wrap(err, "unable to open outbox")
+	}
+	valid, err := s.load(f)
+	if err != nil {
+		f.Close()
+		lock.Close()
+		return nil, err
+	}
+	// Drop what follows the last complete record, so that appends start on a
+	// new line.
+	if err := f.Truncate(valid); err != nil {
+		f.Close()
+		lock.Close()
+		return nil, errors.Wrap(err, "unable to repair outbox")
+	}
+	s.lock = lock
+	s.file = f
+	return s, nil
+}
+
+// load reads the log and returns the size of its complete records.
+func (s *FileStore) load(f *os.File) (int64, error) {
+	r := bufio.NewReader(f)
+	var valid int64
+	for {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			return valid, nil
+		}
+		if err != nil {
+			return 0, errors.Wrap(err, "unable to read outbox")
+		}
+
+		var record logRecord
+		if err := json.Unmarshal(line, &record); err != nil {
+			// Only the last record may be partial.
+			if _, err := r.Peek(1); err == io.EOF {
+				return valid, nil
+			}
+			return 0, errors.Wrapf(err, "outbox is corrupted at offset %d", valid)
+		}
+		s.apply(record)
+		valid += int64(len(line))
+	}
+}
+
+func (s *FileStore) apply(record logRecord) {
+	if record.Entry != nil {
+		if _, ok := s.entries[record.Entry.Key]; !ok {
+			s.order = append(s.order, record.Entry.Key)
+		}
+		s.entries[record.Entry.Key] = *record.Entry
+	}
+	if _, ok := s.entries[record.Remove]; ok {
+		delete(s.entries, record.Remove)
+		s.removed++
+	}
+}
+
+func (s *FileStore) Append(entries ...Entry) error {
+	records := make([]logRecord, len(entries))
+	for i := range entries {
+		records[i] = logRecord{Entry: &entries[i]}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.write(records)
+}
+
+func (s *FileStore) Pending() ([]Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries := make([]Entry, 0, len(s.entries))
+	for _, key := range s.order {
+		if entry, ok := s.entries[key]; ok {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+func (s *FileStore) Remove(keys ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var records []logRecord
+	for _, key := range keys {
+		if _, ok := s.entries[key]; ok {
+			records = append(records, logRecord{Remove: key})
+		}
+	}
+	if len(records) == 0 {
+		return nil
+	}
+	if err := s.write(records); err != nil {
+		return err
+	}
+	if s.removed >= s.opts.CompactAfter || len(s.entries) == 0 {
+		return s.compact()
+	}
+	return nil
+}
+
+// Compact rewrites the log without the removed entries.
+func (s *FileStore) Compact() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.compact()
+}
+
+// Close closes the log and releases its lock.
+func (s *FileStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err := s.file.Close()
+	if lerr := s.lock.Close(); err == nil {
+		err = lerr
+	}
+	return err
+}
+
+func (s *FileStore) write(records []logRecord) error {
+	var buf []byte
+	for _, record := range records {
+		line, err := json.Marshal(record)
+		if err != nil {
+			return errors.Wrap(err, "unable to encode outbox record")
+		}
+		buf = append(append(buf, line...), '\n')
+	}
+	info, err := s.file.Stat()
+	if err != nil {
+		return errors.Wrap(err, "unable to write outbox")
+	}
+	if _, err := s.file.Write(buf); err != nil {
+		// Do not leave a partial record for the next one to follow.
+		s.file.Truncate(info.Size())
+		return errors.Wrap(err, "unable to write outbox")
+	}
+	if !s.opts.NoSync {
+		if err := s.file.Sync(); err != nil {
+			return errors.Wrap(err, "unable to sync outbox")
+		}
+	}
+	for _, record := range records {
+		s.apply(record)
+	}
+	return nil
+}
+
+// compact writes the pending entries to a new log which replaces the current
+// one, so that a crash leaves either of them intact.
+func (s *FileStore) compact() error {
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".outbox-*")
+	if err != nil {
+		return errors.Wrap(err, "unable to compact outbox")
+	}
+	defer os.Remove(tmp.Name())
+
+	var order []string
+	w := bufio.NewWriter(tmp)
+	enc := json.NewEncoder(w)
+	for _, key := range s.order {
+		entry, ok := s.entries[key]
+		if !ok {
+			continue
+		}
+		if err := enc.Encode(logRecord{Entry: &entry}); err != nil {
+			tmp.Close()
+			return errors.Wrap(err, "unable to compact outbox")
+		}
+		order = append(order, key)
+	}
+	err = w.Flush()
+	if err == nil && !s.opts.NoSync {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return errors.Wrap(err, "unable to compact outbox")
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return errors.Wrap(err, "unable to compact outbox")
+	}
+
+	f, err := os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return errors.Wrap(err, "unable to reopen outbox")
+	}
+	s.file.Close()
+	s.file = f
+	s.order = order
+	s.removed = 0
+	return nil
+}
@@ -0,0 +1,80 @@
+// Package outboxtest holds the fake Novu API the outbox tests replay against.
+package outboxtest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/novuhq/go-novu/lib"
+)
+
+// Trigger is a trigger received by the fake Novu API.
+type Trigger struct {
+	Name           string
+	TransactionId  string
+	IdempotencyKey string
+}
+
+// NovuServer records the triggers it receives. It answers 503 while down is
+// set and rejects the workflow "unknown".
+func NovuServer(t *testing.T, down *int32) (*lib.APIClient, func() []Trigger) {
+	var mu sync.Mutex
+	var received []Trigger
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/v1/events/trigger/bulk" {
+			var body lib.BulkTriggerEvent
+			require.NoError(t, json.NewDecoder(req.Body).Decode(&body))
+			if atomic.LoadInt32(down) == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			var results []map[string]interface{}
+			mu.Lock()
+			for _, event := range body.Events {
+				received = append(received, Trigger{event.Name.(string), event.TransactionId, req.Header.Get("Idempotency-Key")})
+				status := "processed"
+				if event.Name == "unknown" {
+					status = "error"
+				}
+				results = append(results, map[string]interface{}{"acknowledged": true, "status": status, "transactionId": event.TransactionId})
+			}
+			mu.Unlock()
+			json.NewEncoder(w).Encode(map[string]interface{}{"data": results})
+			return
+		}
+
+		assert.Equal(t, "/v1/events/trigger", req.URL.Path)
+		var body lib.EventRequest
+		require.NoError(t, json.NewDecoder(req.Body).Decode(&body))
+		mu.Lock()
+		received = append(received, Trigger{body.Name, body.TransactionId, req.Header.Get("Idempotency-Key")})
+		mu.Unlock()
+
+		switch {
+		case atomic.LoadInt32(down) == 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"message": "unavailable"}`))
+		case body.Name == "unknown":
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"message": "workflow not found"}`))
+		default:
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"data": {"acknowledged": true, "status": "processed"}}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	c := lib.NewAPIClient("api-key", &lib.Config{BackendURL: lib.MustParseURL(server.URL)})
+	return c, func() []Trigger {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]Trigger{}, received...)
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package outbox
+
+import "os"
+
+// lockFile does not lock f: flock is not available on this system.
+func lockFile(f *os.File) error {
+	return nil
+}
@@ -0,0 +1,18 @@
+//go:build unix
+
+package outbox
+
+import (
+	"os"
+	"syscall"
+)
+
+// lockFile takes an exclusive advisory lock on f, which is released when f is
+// closed.
+func lockFile(f *os.File) error {
+	err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if err == syscall.EWOULDBLOCK {
+		return ErrFileStoreLocked
+	}
+	return err
+}
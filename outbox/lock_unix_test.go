@@ -0,0 +1,32 @@
+//go:build unix
+
+package outbox_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/novuhq/go-novu/outbox"
+)
+
+func TestFileStore_Lock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox.log")
+	store, err := outbox.OpenFileStore(path, nil)
+	require.NoError(t, err)
+
+	_, err = outbox.OpenFileStore(path, nil)
+	assert.ErrorIs(t, err, outbox.ErrFileStoreLocked)
+
+	// The lock survives compactions, which replace the log.
+	require.NoError(t, store.Compact())
+	_, err = outbox.OpenFileStore(path, nil)
+	assert.ErrorIs(t, err, outbox.ErrFileStoreLocked)
+
+	require.NoError(t, store.Close())
+	store, err = outbox.OpenFileStore(path, nil)
+	require.NoError(t, err)
+	require.NoError(t, store.Close())
+}
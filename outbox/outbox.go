@@ -0,0 +1,242 @@
+// Package outbox stores triggers durably until Novu accepts them, so that they
+// survive outages and restarts.
+//
+// Triggers are appended to the store before being sent. A relay replays the
+// pending entries in order with their original transaction ids and
+// idempotency keys, so that a trigger Novu received before a crash or a
+// timeout is not processed twice, and removes them once delivered.
+//
+// Outbox appends the triggers to a Store such as FileStore, while SQLOutbox
+// inserts them in a database table, within the transactions of the
+// application.
+package outbox
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/novuhq/go-novu/lib"
+)
+
+// Entry is a trigger waiting to be delivered.
+type Entry struct {
+	// Key identifies the entry in its store and is sent as the
+	// Idempotency-Key header of every attempt.
+	Key       string                     `json:"key"`
+	Name      string                     `json:"name"`
+	Trigger   lib.ITriggerPayloadOptions `json:"trigger"`
+	CreatedAt time.Time                  `json:"createdAt"`
+}
+
+// Store persists the pending entries.
+type Store interface {
+	// Append adds entries to the store. It returns once they are durable.
+	Append(entries ...Entry) error
+	// Pending returns the entries that were not removed, oldest first.
+	Pending() ([]Entry, error)
+	// Remove drops the entries with the given keys. Unknown keys are ignored.
+	Remove(keys ...string) error
+}
+
+type Options struct {
+	// Interval is how often Run replays the pending entries when Novu is
+	// reachable. It defaults to one second.
+	Interval time.Duration
+	// MinBackoff and MaxBackoff bound the exponential delay between replays
+	// while Novu is unreachable. They default to one second and one minute.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// OnFailure is called with the entries Novu rejects, such as those of
+	// unknown workflows. They are removed from the store.
+	OnFailure func(entry Entry, err error)
+	// Now returns the creation time of the entries. It defaults to time.Now.
+	Now func() time.Time
+}
+
+// Outbox triggers events through a durable store.
+type Outbox struct {
+	events lib.IEvent
+	store  Store
+	opts   Options
+
+	// mu serializes the replays.
+	mu     sync.Mutex
+	notify chan struct{}
+}
+
+// New returns an outbox triggering the entries of store with events, usually
+// the EventApi of a client.
+func New(events lib.IEvent, store Store, opts *Options) *Outbox {
+	o := &Outbox{events: events, store: store, notify: make(chan struct{}, 1)}
+	if opts != nil {
+		o.opts = *opts
+	}
+	o.opts.setDefaults()
+	return o
+}
+
+func (opts *Options) setDefaults() {
+	if opts.Interval <= 0 {
+		opts.Interval = time.Second
+	}
+	if opts.MinBackoff <= 0 {
+		opts.MinBackoff = time.Second
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = time.Minute
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+}
+
+// Enqueue stores the trigger of the workflow name and wakes up Run. The
+// trigger is given a transaction id when it has none.
+func (o *Outbox) Enqueue(name string, trigger lib.ITriggerPayloadOptions) (Entry, error) {
+	if trigger.TransactionId == "" {
+		trigger.TransactionId = uuid.New().String()
+	}
+	entry := Entry{Key: uuid.New().String(), Name: name, Trigger: trigger, CreatedAt: o.opts.Now()}
+	if err := o.store.Append(entry); err != nil {
+		return Entry{}, errors.Wrap(err, "unable to store trigger")
+	}
+
+	select {
+	case o.notify <- struct{}{}:
+	default:
+	}
+	return entry, nil
+}
+
+// Flush sends the pending entries in order and returns the number delivered.
+// It stops at the first entry that fails because Novu is unreachable,
+// returning that error; entries Novu rejects are passed to OnFailure.
+func (o *Outbox) Flush(ctx context.Context) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	pending, err := o.store.Pending()
+	if err != nil {
+		return 0, &permanentError{errors.Wrap(err, "unable to read pending triggers")}
+	}
+
+	// The entries are removed once the loop stops: those sent again after a
+	// crash are ignored by Novu thanks to their idempotency key.
+	var done []string
+	delivered := 0
+	for _, entry := range pending {
+		_, err = o.events.Trigger(lib.WithIdempotencyKey(ctx, entry.Key), entry.Name, entry.Trigger)
+		if err != nil && (retryable(err) || ctx.Err() != nil) {
+			err = errors.Wrapf(err, "unable to trigger %s", entry.Key)
+			break
+		}
+		done = append(done, entry.Key)
+		if err != nil {
+			if o.opts.OnFailure != nil {
+				o.opts.OnFailure(entry, err)
+			}
+			err = nil
+			continue
+		}
+		delivered++
+	}
+
+	if len(done) > 0 {
+		if rerr := o.store.Remove(done...); rerr != nil {
+			return delivered, &permanentError{errors.Wrap(rerr, "unable to remove delivered triggers")}
+		}
+	}
+	return delivered, err
+}
+
+// Run replays the pending entries until ctx is done, every Interval, right
+// after Enqueue, and with an exponential backoff while Novu is unreachable.
+// It returns ctx.Err(), or the error of the store.
+func (o *Outbox) Run(ctx context.Context) error {
+	return relay(ctx, o.opts, o.notify, func(ctx context.Context) (bool, error) {
+		_, err := o.Flush(ctx)
+		return false, err
+	})
+}
+
+// relay calls pass until ctx is done: right away when it reports more work,
+// every Interval or on notify otherwise, and with an exponential backoff
+// after a retryable error. Other errors stop it.
+func relay(ctx context.Context, opts Options, notify <-chan struct{}, pass func(context.Context) (bool, error)) error {
+	backoff := time.Duration(0)
+	for {
+		more, err := pass(ctx)
+		wait := opts.Interval
+		switch {
+		case ctx.Err() != nil:
+			return ctx.Err()
+		case err == nil:
+			backoff = 0
+			if more {
+				continue
+			}
+		case retryable(err):
+			backoff *= 2
+			if backoff < opts.MinBackoff {
+				backoff = opts.MinBackoff
+			}
+			if backoff > opts.MaxBackoff {
+				backoff = opts.MaxBackoff
+			}
+			wait = backoff
+		default:
+			return err
+		}
+
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-notify:
+			// New entries are sent right away, unless Novu is unreachable.
+			if backoff > 0 {
+				select {
+				case <-ctx.Done():
+					t.Stop()
+					return ctx.Err()
+				case <-t.C:
+				}
+			}
+		case <-t.C:
+		}
+		t.Stop()
+	}
+}
+
+// retryable tells whether a trigger failed because Novu could not be reached
+// or could not process it at the time, rather than because it is invalid.
+func retryable(err error) bool {
+	var permanent *permanentError
+	if errors.As(err, &permanent) {
+		return false
+	}
+	var apiErr *lib.APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.StatusCode {
+		case http.StatusConflict, http.StatusTooManyRequests:
+			// Novu answers 409 while a request with the same idempotency key
+			// is in progress.
+			return true
+		}
+		return apiErr.StatusCode >= http.StatusInternalServerError
+	}
+	return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+}
+
+// permanentError marks the errors that retrying does not help, such as the
+// failures of a Store or the events Novu answered for in a bulk request.
+type permanentError struct{ err error }
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
@@ -0,0 +1,149 @@
+package outbox_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/novuhq/go-novu/lib"
+	"github.com/novuhq/go-novu/outbox"
+	"github.com/novuhq/go-novu/outbox/internal/outboxtest"
+)
+
+func TestOutbox_ReplaysAfterOutage(t *testing.T) {
+	down := int32(1)
+	c, received := outboxtest.NovuServer(t, &down)
+	path := filepath.Join(t.TempDir(), "outbox.log")
+	ctx := context.Background()
+
+	store, err := outbox.OpenFileStore(path, nil)
+	require.NoError(t, err)
+	o := outbox.New(c.EventApi, store, nil)
+	first, err := o.Enqueue("welcome", lib.ITriggerPayloadOptions{To: "sub-1", TransactionId: "tx-1"})
+	require.NoError(t, err)
+	second, err := o.Enqueue("unknown", lib.ITriggerPayloadOptions{To: "sub-1"})
+	require.NoError(t, err)
+	third, err := o.Enqueue("welcome", lib.ITriggerPayloadOptions{To: "sub-2"})
+	require.NoError(t, err)
+	assert.NotEmpty(t, second.Trigger.TransactionId)
+
+	n, err := o.Flush(ctx)
+	assert.Zero(t, n)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unable to trigger "+first.Key)
+	require.NoError(t, store.Close())
+
+	// After a restart, the entries are replayed as they were first sent.
+	atomic.StoreInt32(&down, 0)
+	store, err = outbox.OpenFileStore(path, nil)
+	require.NoError(t, err)
+	defer store.Close()
+	var rejected []string
+	o = outbox.New(c.EventApi, store, &outbox.Options{OnFailure: func(entry outbox.Entry, err error) {
+		rejected = append(rejected, entry.Key)
+	}})
+	n, err = o.Flush(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, []string{second.Key}, rejected)
+
+	assert.Equal(t, []outboxtest.Trigger{
+		{Name: "welcome", TransactionId: "tx-1", IdempotencyKey: first.Key},
+		{Name: "welcome", TransactionId: "tx-1", IdempotencyKey: first.Key},
+		{Name: "unknown", TransactionId: second.Trigger.TransactionId, IdempotencyKey: second.Key},
+		{Name: "welcome", TransactionId: third.Trigger.TransactionId, IdempotencyKey: third.Key},
+	}, received())
+
+	pending, err := store.Pending()
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+	bb, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Empty(t, bb, "the delivered entries are compacted")
+}
+
+func TestOutbox_Run(t *testing.T) {
+	down := int32(0)
+	c, received := outboxtest.NovuServer(t, &down)
+	store, err := outbox.OpenFileStore(filepath.Join(t.TempDir(), "outbox.log"), &outbox.FileStoreOptions{NoSync: true})
+	require.NoError(t, err)
+	defer store.Close()
+
+	o := outbox.New(c.EventApi, store, &outbox.Options{Interval: time.Hour, MinBackoff: 5 * time.Millisecond})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- o.Run(ctx) }()
+
+	// Enqueue wakes Run up.
+	_, err = o.Enqueue("welcome", lib.ITriggerPayloadOptions{To: "sub-1"})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return len(received()) == 1 }, time.Second, time.Millisecond)
+
+	// While Novu is down, Run backs off and retries.
+	atomic.StoreInt32(&down, 1)
+	_, err = o.Enqueue("welcome", lib.ITriggerPayloadOptions{To: "sub-2"})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return len(received()) >= 3 }, time.Second, time.Millisecond)
+	atomic.StoreInt32(&down, 0)
+	require.Eventually(t, func() bool {
+		pending, _ := store.Pending()
+		return len(pending) == 0
+	}, time.Second, time.Millisecond)
+
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+}
+
+func TestFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox.log")
+	store, err := outbox.OpenFileStore(path, &outbox.FileStoreOptions{CompactAfter: 2})
+	require.NoError(t, err)
+
+	entry := func(key string) outbox.Entry {
+		return outbox.Entry{Key: key, Name: "welcome", Trigger: lib.ITriggerPayloadOptions{To: "sub-1", TransactionId: key}}
+	}
+	require.NoError(t, store.Append(entry("a"), entry("b"), entry("c"), entry("d")))
+	require.NoError(t, store.Remove("b", "unknown"))
+	lines := func() int {
+		bb, err := os.ReadFile(path)
+		require.NoError(t, err)
+		return strings.Count(string(bb), "\n")
+	}
+	assert.Equal(t, 5, lines())
+
+	require.NoError(t, store.Remove("a"))
+	assert.Equal(t, 2, lines(), "the log is compacted past two removals")
+	require.NoError(t, store.Append(entry("e")))
+	require.NoError(t, store.Close())
+
+	// A record cut short by a crash is discarded.
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o600)
+	require.NoError(t, err)
+	f.WriteString(`{"entry": {"key": "f", "na`)
+	f.Close()
+
+	store, err = outbox.OpenFileStore(path, nil)
+	require.NoError(t, err)
+	require.NoError(t, store.Append(entry("g")))
+	pending, err := store.Pending()
+	require.NoError(t, err)
+	var keys []string
+	for _, e := range pending {
+		keys = append(keys, e.Key)
+	}
+	assert.Equal(t, []string{"c", "d", "e", "g"}, keys)
+	assert.Equal(t, "welcome", pending[0].Name)
+	assert.Equal(t, "c", pending[0].Trigger.TransactionId)
+	require.NoError(t, store.Close())
+
+	require.NoError(t, os.WriteFile(path, []byte("{}\nnot json\n{}\n"), 0o600))
+	_, err = outbox.OpenFileStore(path, nil)
+	assert.ErrorContains(t, err, "outbox is corrupted at offset 3")
+}
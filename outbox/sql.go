@@ -0,0 +1,364 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/novuhq/go-novu/lib"
+)
+
+const DefaultTable = "novu_outbox"
+
+// Dialect adapts the queries of SQLOutbox to a database.
+type Dialect struct {
+	// Placeholder returns the bind parameter n, counted from 1.
+	Placeholder func(n int) string
+	// Lock is appended to the query claiming the rows, so that concurrent
+	// relays skip the rows claimed by the others.
+	Lock string
+	// Schema holds the statements creating the table, with %[1]s standing for
+	// its name.
+	Schema []string
+}
+
+var (
+	Postgres = Dialect{
+		Placeholder: func(n int) string { return "$" + strconv.Itoa(n) },
+		Lock:        "FOR UPDATE SKIP LOCKED",
+		Schema: []string{`CREATE TABLE IF NOT EXISTS %[1]s (
+	id BIGSERIAL PRIMARY KEY,
+	idempotency_key TEXT NOT NULL UNIQUE,
+	name TEXT NOT NULL,
+	payload TEXT NOT NULL,
+	attempts INTEGER NOT NULL DEFAULT 0,
+	available_at BIGINT NOT NULL,
+	last_error TEXT,
+	created_at BIGINT NOT NULL
+)`, `CREATE INDEX IF NOT EXISTS %[1]s_available_at ON %[1]s (available_at)`},
+	}
+
+	MySQL = Dialect{
+		Placeholder: func(int) string { return "?" },
+		Lock:        "FOR UPDATE SKIP LOCKED",
+		Schema: []string{`CREATE TABLE IF NOT EXISTS %[1]s (
+	id BIGINT AUTO_INCREMENT PRIMARY KEY,
+	idempotency_key VARCHAR(64) NOT NULL UNIQUE,
+	name VARCHAR(255) NOT NULL,
+	payload LONGTEXT NOT NULL,
+	attempts INT NOT NULL DEFAULT 0,
+	available_at BIGINT NOT NULL,
+	last_error TEXT,
+	created_at BIGINT NOT NULL,
+	INDEX %[1]s_available_at (available_at)
+)`},
+	}
+
+	// SQLite serializes the transactions that write, so a single relay
+	// should run against it.
+	SQLite = Dialect{
+		Placeholder: func(int) string { return "?" },
+		Schema: []string{`CREATE TABLE IF NOT EXISTS %[1]s (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	idempotency_key TEXT NOT NULL UNIQUE,
+	name TEXT NOT NULL,
+	payload TEXT NOT NULL,
+	attempts INTEGER NOT NULL DEFAULT 0,
+	available_at INTEGER NOT NULL,
+	last_error TEXT,
+	created_at INTEGER NOT NULL
+)`, `CREATE INDEX IF NOT EXISTS %[1]s_available_at ON %[1]s (available_at)`},
+	}
+)
+
+var tableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+type SQLOptions struct {
+	Options
+	// Table is the name of the outbox table. It defaults to DefaultTable.
+	Table string
+	// BatchSize is the number of rows claimed by a relay at once. It defaults
+	// to 100.
+	BatchSize int
+	// Bulk sends the claimed rows in bulk triggers rather than one trigger
+	// per row. Bulk requests do not carry the idempotency keys of the rows,
+	// only their transaction ids.
+	Bulk bool
+}
+
+// Execer is implemented by *sql.Tx, *sql.DB and *sql.Conn.
+type Execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// SQLOutbox is a transactional outbox: triggers are inserted in the
+// transaction of the changes they notify about, and relayed to Novu once it
+// is committed. A trigger is therefore sent if and only if its transaction
+// commits, at least once; replays carry the idempotency key of the row.
+type SQLOutbox struct {
+	events  lib.IEvent
+	db      *sql.DB
+	dialect Dialect
+	opts    SQLOptions
+}
+
+// NewSQL returns an outbox storing its triggers in db, relayed with events,
+// usually the EventApi of a client.
+func NewSQL(events lib.IEvent, db *sql.DB, dialect Dialect, opts *SQLOptions) (*SQLOutbox, error) {
+	o := &SQLOutbox{events: events, db: db, dialect: dialect}
+	if opts != nil {
+		o.opts = *opts
+	}
+	o.opts.setDefaults()
+	if o.opts.Table == "" {
+		o.opts.Table = DefaultTable
+	}
+	if !tableName.MatchString(o.opts.Table) {
+		return nil, errors.Errorf("invalid table name %q", o.opts.Table)
+	}
+	if o.opts.BatchSize <= 0 {
+		o.opts.BatchSize = 100
+	}
+	return o, nil
+}
+
+// CreateTable creates the outbox table when it does not exist. Applications
+// managing their schema with migrations can use the statements of the dialect
+// instead.
+func (o *SQLOutbox) CreateTable(ctx context.Context) error {
+	for _, stmt := range o.dialect.Schema {
+		if _, err := o.db.ExecContext(ctx, fmt.Sprintf(stmt, o.opts.Table)); err != nil {
+			return errors.Wrap(err, "unable to create outbox table")
+		}
+	}
+	return nil
+}
+
+// Enqueue inserts the trigger of the workflow name with tx, usually the
+// transaction of the changes it notifies about. The trigger is given a
+// transaction id when it has none.
+func (o *SQLOutbox) Enqueue(ctx context.Context, tx Execer, name string, trigger lib.ITriggerPayloadOptions) (Entry, error) {
+	if trigger.TransactionId == "" {
+		trigger.TransactionId = uuid.New().String()
+	}
+	entry := Entry{Key: uuid.New().String(), Name: name, Trigger: trigger, CreatedAt: o.opts.Now()}
+	payload, err := json.Marshal(trigger)
+	if err != nil {
+		return Entry{}, errors.Wrap(err, "unable to encode trigger")
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s (idempotency_key, name, payload, attempts, available_at, created_at) VALUES (%s)",
+		o.opts.Table, o.placeholders(1, 6))
+	created := entry.CreatedAt.UnixMilli()
+	if _, err := tx.ExecContext(ctx, query, entry.Key, name, string(payload), 0, created, created); err != nil {
+		return Entry{}, errors.Wrap(err, "unable to insert trigger")
+	}
+	return entry, nil
+}
+
+type sqlRow struct {
+	id       int64
+	entry    Entry
+	attempts int
+}
+
+// Relay claims up to BatchSize rows due for delivery, sends them and returns
+// the number delivered. The rows delivered and those Novu rejects, passed to
+// OnFailure, are deleted; the others are delayed with an exponential backoff.
+// Relay stops at the first row that fails because Novu is unreachable,
+// returning that error.
+//
+// The rows stay locked until the triggers are sent, so that concurrent relays
+// skip them. When the process stops before, the transaction is rolled back
+// and the rows are claimed again.
+func (o *SQLOutbox) Relay(ctx context.Context) (int, error) {
+	n, _, err := o.relay(ctx)
+	return n, err
+}
+
+// Run relays the rows until ctx is done, every Interval, right away while
+// whole batches are claimed, and with an exponential backoff while Novu or
+// the database is unreachable. It returns ctx.Err().
+func (o *SQLOutbox) Run(ctx context.Context) error {
+	return relay(ctx, o.opts.Options, nil, func(ctx context.Context) (bool, error) {
+		_, more, err := o.relay(ctx)
+		return more, err
+	})
+}
+
+func (o *SQLOutbox) relay(ctx context.Context) (int, bool, error) {
+	// The transaction outlives ctx, so that the outcome of the triggers sent
+	// before ctx is done is recorded.
+	tx, err := o.db.BeginTx(context.Background(), nil)
+	if err != nil {
+		return 0, false, errors.Wrap(err, "unable to begin relay transaction")
+	}
+	defer tx.Rollback()
+
+	now := o.opts.Now()
+	rows, err := o.claim(tx, now)
+	if err != nil {
+		return 0, false, err
+	}
+	if len(rows) == 0 {
+		return 0, false, nil
+	}
+
+	var results []error
+	if o.opts.Bulk {
+		results = o.sendBulk(ctx, rows)
+	} else {
+		results = o.send(ctx, rows)
+	}
+
+	delivered := 0
+	var rejected []int
+	for i, result := range results {
+		row := rows[i]
+		err = nil
+		switch {
+		case result == nil:
+			delivered++
+			err = o.delete(tx, row.id)
+		case ctx.Err() != nil:
+			// The row is released as it was.
+		case retryable(result):
+			err = o.delay(tx, row, now, result)
+		default:
+			rejected = append(rejected, i)
+			err = o.delete(tx, row.id)
+		}
+		if err != nil {
+			return 0, false, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, false, errors.Wrap(err, "unable to commit relay transaction")
+	}
+	if o.opts.OnFailure != nil {
+		for _, i := range rejected {
+			o.opts.OnFailure(rows[i].entry, results[i])
+		}
+	}
+
+	for i, result := range results {
+		if result != nil && (retryable(result) || ctx.Err() != nil) {
+			return delivered, false, errors.Wrapf(result, "unable to trigger %s", rows[i].entry.Key)
+		}
+	}
+	return delivered, len(rows) == o.opts.BatchSize, nil
+}
+
+func (o *SQLOutbox) claim(tx *sql.Tx, now time.Time) ([]sqlRow, error) {
+	query := fmt.Sprintf("SELECT id, idempotency_key, name, payload, attempts, created_at FROM %s WHERE available_at <= %s ORDER BY id LIMIT %d %s",
+		o.opts.Table, o.dialect.Placeholder(1), o.opts.BatchSize, o.dialect.Lock)
+	rs, err := tx.Query(strings.TrimSpace(query), now.UnixMilli())
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to claim outbox rows")
+	}
+	defer rs.Close()
+
+	var rows []sqlRow
+	for rs.Next() {
+		var row sqlRow
+		var payload string
+		var created int64
+		if err := rs.Scan(&row.id, &row.entry.Key, &row.entry.Name, &payload, &row.attempts, &created); err != nil {
+			return nil, errors.Wrap(err, "unable to read outbox row")
+		}
+		if err := json.Unmarshal([]byte(payload), &row.entry.Trigger); err != nil {
+			return nil, errors.Wrapf(err, "unable to decode trigger %s", row.entry.Key)
+		}
+		row.entry.CreatedAt = time.UnixMilli(created)
+		rows = append(rows, row)
+	}
+	if err := rs.Err(); err != nil {
+		return nil, errors.Wrap(err, "unable to claim outbox rows")
+	}
+	return rows, nil
+}
+
+// send triggers the rows one by one. It stops after the first row that fails
+// because Novu is unreachable, so the results may be fewer than the rows.
+func (o *SQLOutbox) send(ctx context.Context, rows []sqlRow) []error {
+	results := make([]error, 0, len(rows))
+	for _, row := range rows {
+		_, err := o.events.Trigger(lib.WithIdempotencyKey(ctx, row.entry.Key), row.entry.Name, row.entry.Trigger)
+		results = append(results, err)
+		if err != nil && (retryable(err) || ctx.Err() != nil) {
+			break
+		}
+	}
+	return results
+}
+
+func (o *SQLOutbox) sendBulk(ctx context.Context, rows []sqlRow) []error {
+	events := make([]lib.BulkTriggerOptions, len(rows))
+	for i, row := range rows {
+		t := row.entry.Trigger
+		events[i] = lib.BulkTriggerOptions{
+			Name:          row.entry.Name,
+			To:            t.To,
+			Payload:       t.Payload,
+			Overrides:     t.Overrides,
+			TransactionId: t.TransactionId,
+			Actor:         t.Actor,
+			Tenant:        t.Tenant,
+		}
+	}
+	bulk, _ := o.events.TriggerBulkChunked(ctx, events, &lib.TriggerBulkChunkedOptions{Concurrency: 1})
+
+	results := make([]error, len(rows))
+	for i := range results {
+		results[i] = errors.New("no result returned for the trigger")
+		if i < len(bulk) {
+			results[i] = bulk[i].Err
+			// Events Novu answered for are not retried.
+			if results[i] != nil && bulk[i].Status != "" {
+				results[i] = &permanentError{results[i]}
+			}
+		}
+	}
+	return results
+}
+
+func (o *SQLOutbox) delete(tx *sql.Tx, id int64) error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE id = %s", o.opts.Table, o.dialect.Placeholder(1))
+	if _, err := tx.Exec(query, id); err != nil {
+		return errors.Wrap(err, "unable to delete outbox row")
+	}
+	return nil
+}
+
+func (o *SQLOutbox) delay(tx *sql.Tx, row sqlRow, now time.Time, cause error) error {
+	backoff := o.opts.MinBackoff
+	for i := 0; i < row.attempts && backoff < o.opts.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > o.opts.MaxBackoff {
+		backoff = o.opts.MaxBackoff
+	}
+
+	query := fmt.Sprintf("UPDATE %s SET attempts = attempts + 1, available_at = %s, last_error = %s WHERE id = %s",
+		o.opts.Table, o.dialect.Placeholder(1), o.dialect.Placeholder(2), o.dialect.Placeholder(3))
+	if _, err := tx.Exec(query, now.Add(backoff).UnixMilli(), cause.Error(), row.id); err != nil {
+		return errors.Wrap(err, "unable to update outbox row")
+	}
+	return nil
+}
+
+func (o *SQLOutbox) placeholders(from, n int) string {
+	p := make([]string, n)
+	for i := range p {
+		p[i] = o.dialect.Placeholder(from + i)
+	}
+	return strings.Join(p, ", ")
+}
@@ -0,0 +1,4 @@
+// Package sqltest runs the SQL outbox store against SQLite. It is a module of
+// its own so that the cgo SQLite driver stays out of the dependencies of
+// github.com/novuhq/go-novu.
+package sqltest
@@ -0,0 +1,21 @@
+module github.com/novuhq/go-novu/outbox/sqltest
+
+go 1.19
+
+require (
+	github.com/mattn/go-sqlite3 v1.14.33
+	github.com/novuhq/go-novu v0.0.0
+	github.com/stretchr/testify v1.7.5
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/google/uuid v1.3.1 // indirect
+	github.com/hashicorp/go-cleanhttp v0.5.2 // indirect
+	github.com/hashicorp/go-retryablehttp v0.7.4 // indirect
+	github.com/pkg/errors v0.9.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github.com/novuhq/go-novu => ../..
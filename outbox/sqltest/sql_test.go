@@ -0,0 +1,157 @@
+package sqltest_test
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/novuhq/go-novu/lib"
+	"github.com/novuhq/go-novu/outbox"
+	"github.com/novuhq/go-novu/outbox/internal/outboxtest"
+)
+
+func openSQLite(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "outbox.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	if err := db.Ping(); err != nil {
+		t.Skipf("sqlite is not available: %v", err)
+	}
+	return db
+}
+
+type pendingRow struct {
+	Key       string
+	Attempts  int
+	Available int64
+	LastError sql.NullString
+}
+
+func pendingRows(t *testing.T, db *sql.DB) []pendingRow {
+	rs, err := db.Query("SELECT idempotency_key, attempts, available_at, last_error FROM novu_outbox ORDER BY id")
+	require.NoError(t, err)
+	defer rs.Close()
+	var rows []pendingRow
+	for rs.Next() {
+		var row pendingRow
+		require.NoError(t, rs.Scan(&row.Key, &row.Attempts, &row.Available, &row.LastError))
+		rows = append(rows, row)
+	}
+	require.NoError(t, rs.Err())
+	return rows
+}
+
+func TestSQLOutbox(t *testing.T) {
+	down := int32(0)
+	c, received := outboxtest.NovuServer(t, &down)
+	db := openSQLite(t)
+	ctx := context.Background()
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var rejected []string
+	o, err := outbox.NewSQL(c.EventApi, db, outbox.SQLite, &outbox.SQLOptions{
+		Options: outbox.Options{
+			MinBackoff: time.Minute,
+			Now:        func() time.Time { return now },
+			OnFailure:  func(entry outbox.Entry, err error) { rejected = append(rejected, entry.Key) },
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, o.CreateTable(ctx))
+	require.NoError(t, o.CreateTable(ctx))
+
+	// The triggers of a rolled back transaction are never sent.
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	_, err = o.Enqueue(ctx, tx, "welcome", lib.ITriggerPayloadOptions{To: "sub-0"})
+	require.NoError(t, err)
+	require.NoError(t, tx.Rollback())
+
+	tx, err = db.Begin()
+	require.NoError(t, err)
+	first, err := o.Enqueue(ctx, tx, "welcome", lib.ITriggerPayloadOptions{To: "sub-1", TransactionId: "tx-1", Payload: map[string]interface{}{"plan": "pro"}})
+	require.NoError(t, err)
+	second, err := o.Enqueue(ctx, tx, "unknown", lib.ITriggerPayloadOptions{To: "sub-1"})
+	require.NoError(t, err)
+	third, err := o.Enqueue(ctx, tx, "welcome", lib.ITriggerPayloadOptions{To: "sub-2"})
+	require.NoError(t, err)
+	require.NoError(t, tx.Commit())
+
+	// While Novu is down, the first row is delayed and the others wait.
+	atomic.StoreInt32(&down, 1)
+	n, err := o.Relay(ctx)
+	assert.Zero(t, n)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unable to trigger "+first.Key)
+	rows := pendingRows(t, db)
+	require.Len(t, rows, 3)
+	assert.Equal(t, 1, rows[0].Attempts)
+	assert.Equal(t, now.Add(time.Minute).UnixMilli(), rows[0].Available)
+	assert.True(t, rows[0].LastError.Valid)
+	assert.Zero(t, rows[1].Attempts)
+
+	atomic.StoreInt32(&down, 0)
+	n, err = o.Relay(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, []string{second.Key}, rejected)
+	require.Len(t, pendingRows(t, db), 1)
+
+	now = now.Add(time.Minute)
+	n, err = o.Relay(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Empty(t, pendingRows(t, db))
+
+	assert.Equal(t, []outboxtest.Trigger{
+		{Name: "welcome", TransactionId: "tx-1", IdempotencyKey: first.Key},
+		{Name: "unknown", TransactionId: second.Trigger.TransactionId, IdempotencyKey: second.Key},
+		{Name: "welcome", TransactionId: third.Trigger.TransactionId, IdempotencyKey: third.Key},
+		{Name: "welcome", TransactionId: "tx-1", IdempotencyKey: first.Key},
+	}, received())
+}
+
+func TestSQLOutbox_Bulk(t *testing.T) {
+	down := int32(0)
+	c, received := outboxtest.NovuServer(t, &down)
+	db := openSQLite(t)
+	ctx := context.Background()
+
+	var rejected []string
+	o, err := outbox.NewSQL(c.EventApi, db, outbox.SQLite, &outbox.SQLOptions{
+		Options:   outbox.Options{Interval: time.Hour, OnFailure: func(entry outbox.Entry, err error) { rejected = append(rejected, entry.Name) }},
+		BatchSize: 2,
+		Bulk:      true,
+	})
+	require.NoError(t, err)
+	require.NoError(t, o.CreateTable(ctx))
+	for _, name := range []string{"welcome", "unknown", "welcome"} {
+		_, err := o.Enqueue(ctx, db, name, lib.ITriggerPayloadOptions{To: "sub-1"})
+		require.NoError(t, err)
+	}
+
+	// Run claims batches until the table is empty.
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan error)
+	go func() { done <- o.Run(ctx) }()
+	require.Eventually(t, func() bool { return len(pendingRows(t, db)) == 0 }, time.Second, time.Millisecond)
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+
+	assert.Len(t, received(), 3)
+	assert.Equal(t, []string{"unknown"}, rejected)
+}
+
+func TestNewSQL_Table(t *testing.T) {
+	_, err := outbox.NewSQL(nil, nil, outbox.Postgres, &outbox.SQLOptions{Table: "outbox; DROP TABLE users"})
+	assert.ErrorContains(t, err, "invalid table name")
+	_, err = outbox.NewSQL(nil, nil, outbox.Postgres, &outbox.SQLOptions{Table: "app.novu_outbox"})
+	assert.NoError(t, err)
+}
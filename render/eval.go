@@ -0,0 +1,423 @@
+package render
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SafeString is returned by helpers whose output must not be HTML escaped.
+type SafeString string
+
+// Helper is a Handlebars helper. Params holds the evaluated positional
+// arguments, options the hash arguments and, for block helpers, the blocks.
+type Helper func(params []interface{}, options *HelperOptions) (interface{}, error)
+
+// HelperOptions gives helpers access to the hash arguments and the blocks.
+type HelperOptions struct {
+	Hash map[string]interface{}
+	// Context is the current context, this in Handlebars.
+	Context interface{}
+	// Data holds the @ variables of the current scope.
+	Data map[string]interface{}
+
+	state   *state
+	program []node
+	inverse []node
+	params  []string
+}
+
+// IsBlock reports whether the helper is called as a block.
+func (o *HelperOptions) IsBlock() bool {
+	return o.program != nil || o.inverse != nil
+}
+
+// Fn renders the block with ctx as context.
+func (o *HelperOptions) Fn(ctx interface{}) (string, error) {
+	return o.FnWith(ctx, nil, nil)
+}
+
+// FnWith renders the block with ctx as context, extra @ variables and block params.
+func (o *HelperOptions) FnWith(ctx interface{}, data map[string]interface{}, blockParams []interface{}) (string, error) {
+	return o.state.renderBlock(o.program, ctx, data, o.params, blockParams)
+}
+
+// Inverse renders the {{else}} section of the block with ctx as context.
+func (o *HelperOptions) Inverse(ctx interface{}) (string, error) {
+	return o.state.renderBlock(o.inverse, ctx, nil, nil, nil)
+}
+
+type frame struct {
+	ctx         interface{}
+	data        map[string]interface{}
+	blockParams map[string]interface{}
+	// keepsContext is set for the blocks rendered in the context of their
+	// parent, such as those of {{#if}}, which ../ does not count.
+	keepsContext bool
+}
+
+type state struct {
+	helpers map[string]Helper
+	root    interface{}
+	frames  []frame
+	strict  bool
+}
+
+func (s *state) current() frame {
+	return s.frames[len(s.frames)-1]
+}
+
+func (s *state) render(nodes []node, b *strings.Builder) error {
+	for _, n := range nodes {
+		switch n := n.(type) {
+		case *textNode:
+			b.WriteString(n.text)
+		case *mustacheNode:
+			value, err := s.evalCall(n.expr, nil, nil)
+			if err != nil {
+				return fmt.Errorf("line %d: %w", n.line, err)
+			}
+			out, safe := stringify(value)
+			if n.escape && !safe {
+				out = escape(out)
+			}
+			b.WriteString(out)
+		case *blockNode:
+			out, err := s.evalBlock(n)
+			if err != nil {
+				return fmt.Errorf("line %d: %w", n.line, err)
+			}
+			b.WriteString(out)
+		}
+	}
+	return nil
+}
+
+func (s *state) renderBlock(nodes []node, ctx interface{}, data map[string]interface{}, names []string, values []interface{}) (string, error) {
+	if len(nodes) == 0 {
+		return "", nil
+	}
+
+	f := frame{ctx: ctx, data: s.current().data, blockParams: s.current().blockParams, keepsContext: sameContext(ctx, s.current().ctx)}
+	if len(data) > 0 {
+		merged := map[string]interface{}{}
+		for k, v := range f.data {
+			merged[k] = v
+		}
+		for k, v := range data {
+			merged[k] = v
+		}
+		f.data = merged
+	}
+	if len(names) > 0 && len(values) > 0 {
+		merged := map[string]interface{}{}
+		for k, v := range f.blockParams {
+			merged[k] = v
+		}
+		for i, name := range names {
+			if i < len(values) {
+				merged[name] = values[i]
+			}
+		}
+		f.blockParams = merged
+	}
+
+	s.frames = append(s.frames, f)
+	defer func() { s.frames = s.frames[:len(s.frames)-1] }()
+
+	var b strings.Builder
+	if err := s.render(nodes, &b); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+func (s *state) evalBlock(n *blockNode) (string, error) {
+	name := n.expr.name()
+	if helper, ok := s.helpers[name]; ok {
+		value, err := s.evalCall(n.expr, helper, n)
+		if err != nil {
+			return "", err
+		}
+		out, _ := stringify(value)
+		return out, nil
+	}
+	if len(n.expr.params) > 0 || n.expr.hash != nil {
+		return "", fmt.Errorf("missing helper %q", name)
+	}
+
+	// A block on a value behaves like {{#each}} for lists and {{#with}} otherwise.
+	value, err := s.eval(n.expr.head)
+	if err != nil {
+		return "", err
+	}
+	options := &HelperOptions{Context: s.current().ctx, Data: s.current().data, state: s, program: n.program, inverse: nonNil(n.inverse), params: n.blockParams}
+	if isList(value) {
+		out, err := eachHelper([]interface{}{value}, options)
+		if err != nil {
+			return "", err
+		}
+		return out.(SafeString).String(), nil
+	}
+	if !truthy(value) {
+		return options.Inverse(s.current().ctx)
+	}
+	return options.FnWith(value, nil, []interface{}{value})
+}
+
+// evalCall evaluates a mustache or block expression. helper is set when the
+// expression is known to call a helper.
+func (s *state) evalCall(e *sexpr, helper Helper, block *blockNode) (interface{}, error) {
+	if helper == nil {
+		if name := e.name(); name != "" {
+			helper = s.helpers[name]
+		}
+	}
+	if helper == nil {
+		if len(e.params) > 0 || e.hash != nil {
+			return nil, fmt.Errorf("missing helper %q", e.name())
+		}
+		return s.eval(e.head)
+	}
+
+	params := make([]interface{}, 0, len(e.params))
+	for _, p := range e.params {
+		v, err := s.eval(p)
+		if err != nil {
+			return nil, err
+		}
+		params = append(params, v)
+	}
+	hash := map[string]interface{}{}
+	for k, p := range e.hash {
+		v, err := s.eval(p)
+		if err != nil {
+			return nil, err
+		}
+		hash[k] = v
+	}
+
+	options := &HelperOptions{Hash: hash, Context: s.current().ctx, Data: s.current().data, state: s}
+	if block != nil {
+		options.program = nonNil(block.program)
+		options.inverse = nonNil(block.inverse)
+		options.params = block.blockParams
+	}
+
+	value, err := helper(params, options)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", e.name(), err)
+	}
+	return value, nil
+}
+
+func (s *state) eval(e expr) (interface{}, error) {
+	switch e := e.(type) {
+	case *literalExpr:
+		return e.value, nil
+	case *sexpr:
+		return s.evalCall(e, nil, nil)
+	case *pathExpr:
+		return s.resolve(e)
+	}
+	return nil, fmt.Errorf("unknown expression %T", e)
+}
+
+func (s *state) resolve(p *pathExpr) (interface{}, error) {
+	if p.data {
+		if len(p.parts) == 0 {
+			return nil, nil
+		}
+		var value interface{}
+		if p.parts[0] == "root" {
+			value = s.root
+		} else {
+			value = s.current().data[p.parts[0]]
+		}
+		return lookupPath(value, p.parts[1:]), nil
+	}
+
+	if p.depth == 0 && len(p.parts) > 0 {
+		if value, ok := s.current().blockParams[p.parts[0]]; ok {
+			return lookupPath(value, p.parts[1:]), nil
+		}
+	}
+
+	idx := depthIndex(len(s.frames), p.depth, func(i int) bool { return s.frames[i].keepsContext })
+	value := lookupPath(s.frames[idx].ctx, p.parts)
+	if value == nil && s.strict {
+		return nil, fmt.Errorf("%q is not defined", p.original)
+	}
+	return value, nil
+}
+
+// depthIndex returns the index, among n nested scopes, of the scope a path
+// starting with depth ../ segments resolves against. As in Handlebars 4, ../
+// goes up one context: the scopes that keep the context of their parent are
+// skipped.
+func depthIndex(n, depth int, keepsContext func(i int) bool) int {
+	i := n - 1
+	for ; depth > 0 && i > 0; i-- {
+		if !keepsContext(i) {
+			depth--
+		}
+	}
+	return i
+}
+
+// sameContext reports whether a and b are the same context, comparing maps
+// and lists by identity like Handlebars.
+func sameContext(a, b interface{}) bool {
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	if !va.IsValid() || !vb.IsValid() {
+		return !va.IsValid() && !vb.IsValid()
+	}
+	if va.Type() != vb.Type() {
+		return false
+	}
+	switch va.Kind() {
+	case reflect.Map, reflect.Ptr:
+		return va.Pointer() == vb.Pointer()
+	case reflect.Slice:
+		return va.Pointer() == vb.Pointer() && va.Len() == vb.Len()
+	}
+	return va.Type().Comparable() && a == b
+}
+
+func lookupPath(value interface{}, parts []string) interface{} {
+	for _, part := range parts {
+		value = lookup(value, part)
+		if value == nil {
+			return nil
+		}
+	}
+	return value
+}
+
+func lookup(value interface{}, key string) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return v[key]
+	case []interface{}:
+		if key == "length" {
+			return float64(len(v))
+		}
+		if i, err := strconv.Atoi(key); err == nil && i >= 0 && i < len(v) {
+			return v[i]
+		}
+		return nil
+	case string:
+		if key == "length" {
+			return float64(len([]rune(v)))
+		}
+		return nil
+	}
+
+	rv := reflect.ValueOf(value)
+	if rv.Kind() == reflect.Map && rv.Type().Key().Kind() == reflect.String {
+		item := rv.MapIndex(reflect.ValueOf(key).Convert(rv.Type().Key()))
+		if item.IsValid() {
+			return item.Interface()
+		}
+	}
+	return nil
+}
+
+func nonNil(nodes []node) []node {
+	if nodes == nil {
+		return []node{}
+	}
+	return nodes
+}
+
+func isList(value interface{}) bool {
+	_, ok := value.([]interface{})
+	return ok
+}
+
+// truthy follows the Handlebars rules: empty lists are falsy as well.
+func truthy(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case string:
+		return v != ""
+	case SafeString:
+		return v != ""
+	case float64:
+		return v != 0 && !math.IsNaN(v)
+	case int:
+		return v != 0
+	case []interface{}:
+		return len(v) > 0
+	}
+	return true
+}
+
+// stringify converts a value like JavaScript does when concatenating it.
+func stringify(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case nil:
+		return "", false
+	case SafeString:
+		return string(v), true
+	case string:
+		return v, false
+	case bool:
+		return strconv.FormatBool(v), false
+	case float64:
+		return formatNumber(v), false
+	case int:
+		return strconv.Itoa(v), false
+	case []interface{}:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i], _ = stringify(item)
+		}
+		return strings.Join(items, ","), false
+	case map[string]interface{}:
+		return "[object Object]", false
+	}
+	return fmt.Sprint(value), false
+}
+
+func formatNumber(v float64) string {
+	if v == math.Trunc(v) && math.Abs(v) < 1e21 {
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// escaper is the escape table of Handlebars.escapeExpression.
+var escaper = strings.NewReplacer(
+	"&", "&amp;",
+	"<", "&lt;",
+	">", "&gt;",
+	`"`, "&quot;",
+	"'", "&#x27;",
+	"`", "&#x60;",
+	"=", "&#x3D;",
+)
+
+// escape escapes like Handlebars.escapeExpression.
+func escape(s string) string {
+	return escaper.Replace(s)
+}
+
+func (s SafeString) String() string {
+	return string(s)
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,437 @@
+package render
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// builtinHelpers returns the Handlebars built-ins together with the helpers
+// Novu registers for layouts and step content.
+func builtinHelpers() map[string]Helper {
+	return map[string]Helper{
+		"if":           ifHelper,
+		"unless":       unlessHelper,
+		"each":         eachHelper,
+		"with":         withHelper,
+		"lookup":       lookupHelper,
+		"equals":       equalsHelper,
+		"titlecase":    titlecaseHelper,
+		"uppercase":    stringHelper(strings.ToUpper),
+		"lowercase":    stringHelper(strings.ToLower),
+		"pluralize":    pluralizeHelper,
+		"dateFormat":   dateFormatHelper,
+		"unique":       uniqueHelper,
+		"groupBy":      groupByHelper,
+		"sortBy":       sortByHelper,
+		"numberFormat": numberFormatHelper,
+		"eq":           compareHelper(func(c int) bool { return c == 0 }),
+		"ne":           compareHelper(func(c int) bool { return c != 0 }),
+		"gt":           compareHelper(func(c int) bool { return c > 0 }),
+		"gte":          compareHelper(func(c int) bool { return c >= 0 }),
+		"lt":           compareHelper(func(c int) bool { return c < 0 }),
+		"lte":          compareHelper(func(c int) bool { return c <= 0 }),
+	}
+}
+
+func requireParams(params []interface{}, n int) error {
+	if len(params) < n {
+		return fmt.Errorf("expects %d arguments, got %d", n, len(params))
+	}
+	return nil
+}
+
+func conditional(cond bool, options *HelperOptions) (interface{}, error) {
+	if !options.IsBlock() {
+		return cond, nil
+	}
+	var (
+		out string
+		err error
+	)
+	if cond {
+		out, err = options.Fn(options.Context)
+	} else {
+		out, err = options.Inverse(options.Context)
+	}
+	return SafeString(out), err
+}
+
+func ifHelper(params []interface{}, options *HelperOptions) (interface{}, error) {
+	if err := requireParams(params, 1); err != nil {
+		return nil, err
+	}
+	return conditional(truthy(params[0]), options)
+}
+
+func unlessHelper(params []interface{}, options *HelperOptions) (interface{}, error) {
+	if err := requireParams(params, 1); err != nil {
+		return nil, err
+	}
+	return conditional(!truthy(params[0]), options)
+}
+
+func withHelper(params []interface{}, options *HelperOptions) (interface{}, error) {
+	if err := requireParams(params, 1); err != nil {
+		return nil, err
+	}
+	if !truthy(params[0]) {
+		out, err := options.Inverse(options.Context)
+		return SafeString(out), err
+	}
+	out, err := options.FnWith(params[0], nil, []interface{}{params[0]})
+	return SafeString(out), err
+}
+
+func eachHelper(params []interface{}, options *HelperOptions) (interface{}, error) {
+	if err := requireParams(params, 1); err != nil {
+		return nil, err
+	}
+
+	var b strings.Builder
+	switch v := params[0].(type) {
+	case []interface{}:
+		for i, item := range v {
+			data := map[string]interface{}{
+				"index": float64(i),
+				"first": i == 0,
+				"last":  i == len(v)-1,
+			}
+			out, err := options.FnWith(item, data, []interface{}{item, float64(i)})
+			if err != nil {
+				return nil, err
+			}
+			b.WriteString(out)
+		}
+		if len(v) > 0 {
+			return SafeString(b.String()), nil
+		}
+	case map[string]interface{}:
+		keys := sortedKeys(v)
+		for i, key := range keys {
+			data := map[string]interface{}{
+				"key":   key,
+				"index": float64(i),
+				"first": i == 0,
+				"last":  i == len(keys)-1,
+			}
+			out, err := options.FnWith(v[key], data, []interface{}{v[key], key})
+			if err != nil {
+				return nil, err
+			}
+			b.WriteString(out)
+		}
+		if len(keys) > 0 {
+			return SafeString(b.String()), nil
+		}
+	}
+
+	out, err := options.Inverse(options.Context)
+	return SafeString(out), err
+}
+
+// lookupHelper returns the field or item of its first argument named by the
+// second, like the Handlebars built-in.
+func lookupHelper(params []interface{}, options *HelperOptions) (interface{}, error) {
+	if err := requireParams(params, 2); err != nil {
+		return nil, err
+	}
+	key, _ := stringify(params[1])
+	return lookup(params[0], key), nil
+}
+
+func equalsHelper(params []interface{}, options *HelperOptions) (interface{}, error) {
+	if err := requireParams(params, 2); err != nil {
+		return nil, err
+	}
+	a, _ := stringify(params[0])
+	b, _ := stringify(params[1])
+	return conditional(a == b, options)
+}
+
+func stringHelper(fn func(string) string) Helper {
+	return func(params []interface{}, options *HelperOptions) (interface{}, error) {
+		if err := requireParams(params, 1); err != nil {
+			return nil, err
+		}
+		s, _ := stringify(params[0])
+		return fn(s), nil
+	}
+}
+
+func titlecaseHelper(params []interface{}, options *HelperOptions) (interface{}, error) {
+	if err := requireParams(params, 1); err != nil {
+		return nil, err
+	}
+	s, _ := stringify(params[0])
+	words := strings.Split(strings.ToLower(s), " ")
+	for i, w := range words {
+		r := []rune(w)
+		if len(r) > 0 {
+			r[0] = unicode.ToUpper(r[0])
+		}
+		words[i] = string(r)
+	}
+	return strings.Join(words, " "), nil
+}
+
+// pluralizeHelper renders {{pluralize count "item" "items"}} as "2 items".
+func pluralizeHelper(params []interface{}, options *HelperOptions) (interface{}, error) {
+	if err := requireParams(params, 3); err != nil {
+		return nil, err
+	}
+	n, ok := toNumber(params[0])
+	if !ok {
+		return nil, fmt.Errorf("%v is not a number", params[0])
+	}
+	word, _ := stringify(params[2])
+	if n == 1 {
+		word, _ = stringify(params[1])
+	}
+	return formatNumber(n) + " " + word, nil
+}
+
+func uniqueHelper(params []interface{}, options *HelperOptions) (interface{}, error) {
+	if err := requireParams(params, 1); err != nil {
+		return nil, err
+	}
+	list, _ := params[0].([]interface{})
+	var property string
+	if len(params) > 1 {
+		property, _ = stringify(params[1])
+	}
+
+	seen := map[string]bool{}
+	result := []interface{}{}
+	for _, item := range list {
+		if property != "" {
+			item = lookup(item, property)
+		}
+		key, _ := stringify(item)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, item)
+	}
+	return result, nil
+}
+
+// groupByHelper groups a list by property into [{key, items}] in order of
+// first appearance.
+func groupByHelper(params []interface{}, options *HelperOptions) (interface{}, error) {
+	if err := requireParams(params, 2); err != nil {
+		return nil, err
+	}
+	list, _ := params[0].([]interface{})
+	property, _ := stringify(params[1])
+
+	groups := []interface{}{}
+	index := map[string]map[string]interface{}{}
+	for _, item := range list {
+		key, _ := stringify(lookup(item, property))
+		group, ok := index[key]
+		if !ok {
+			group = map[string]interface{}{"key": key, "items": []interface{}{}}
+			index[key] = group
+			groups = append(groups, group)
+		}
+		group["items"] = append(group["items"].([]interface{}), item)
+	}
+	return groups, nil
+}
+
+func sortByHelper(params []interface{}, options *HelperOptions) (interface{}, error) {
+	if err := requireParams(params, 1); err != nil {
+		return nil, err
+	}
+	list, _ := params[0].([]interface{})
+	var property string
+	if len(params) > 1 {
+		property, _ = stringify(params[1])
+	}
+
+	sorted := append([]interface{}{}, list...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if property != "" {
+			a, b = lookup(a, property), lookup(b, property)
+		}
+		return compare(a, b) < 0
+	})
+	return sorted, nil
+}
+
+// numberFormatHelper formats a number, e.g.
+// {{numberFormat price decimalLength=2 thousandsSep="," decimalSep="."}}.
+func numberFormatHelper(params []interface{}, options *HelperOptions) (interface{}, error) {
+	if err := requireParams(params, 1); err != nil {
+		return nil, err
+	}
+	n, ok := toNumber(params[0])
+	if !ok {
+		return nil, fmt.Errorf("%v is not a number", params[0])
+	}
+
+	decimals := 2
+	if v, ok := toNumber(options.Hash["decimalLength"]); ok {
+		decimals = int(v)
+	}
+	thousandsSep, decimalSep := ",", "."
+	if v, ok := options.Hash["thousandsSep"].(string); ok {
+		thousandsSep = v
+	}
+	if v, ok := options.Hash["decimalSep"].(string); ok {
+		decimalSep = v
+	}
+
+	formatted := strconv.FormatFloat(math.Abs(n), 'f', decimals, 64)
+	intPart, fracPart, _ := strings.Cut(formatted, ".")
+
+	var b strings.Builder
+	if n < 0 {
+		b.WriteByte('-')
+	}
+	for i, r := range intPart {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			b.WriteString(thousandsSep)
+		}
+		b.WriteRune(r)
+	}
+	if fracPart != "" {
+		b.WriteString(decimalSep)
+		b.WriteString(fracPart)
+	}
+	return b.String(), nil
+}
+
+func compareHelper(test func(int) bool) Helper {
+	return func(params []interface{}, options *HelperOptions) (interface{}, error) {
+		if err := requireParams(params, 2); err != nil {
+			return nil, err
+		}
+		return conditional(test(compare(params[0], params[1])), options)
+	}
+}
+
+// compare orders numbers numerically and everything else as strings.
+func compare(a, b interface{}) int {
+	if x, ok := toNumber(a); ok {
+		if y, ok := toNumber(b); ok {
+			switch {
+			case x < y:
+				return -1
+			case x > y:
+				return 1
+			}
+			return 0
+		}
+	}
+	x, _ := stringify(a)
+	y, _ := stringify(b)
+	return strings.Compare(x, y)
+}
+
+func toNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case string:
+		n, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return n, err == nil
+	}
+	return 0, false
+}
+
+// dateFormatHelper formats a date with date-fns tokens, e.g.
+// {{dateFormat createdAt "MMMM d, yyyy"}}. Dates are rendered in UTC.
+func dateFormatHelper(params []interface{}, options *HelperOptions) (interface{}, error) {
+	if err := requireParams(params, 2); err != nil {
+		return nil, err
+	}
+	t, err := toTime(params[0])
+	if err != nil {
+		return nil, err
+	}
+	format, _ := stringify(params[1])
+	return formatDate(t.UTC(), format)
+}
+
+func toTime(value interface{}) (time.Time, error) {
+	switch v := value.(type) {
+	case float64:
+		return time.UnixMilli(int64(v)), nil
+	case string:
+		for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02"} {
+			if t, err := time.Parse(layout, v); err == nil {
+				return t, nil
+			}
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %v", value)
+}
+
+var dateTokens = []struct {
+	token  string
+	format func(time.Time) string
+}{
+	{"yyyy", func(t time.Time) string { return t.Format("2006") }},
+	{"yy", func(t time.Time) string { return t.Format("06") }},
+	{"MMMM", func(t time.Time) string { return t.Format("January") }},
+	{"MMM", func(t time.Time) string { return t.Format("Jan") }},
+	{"MM", func(t time.Time) string { return t.Format("01") }},
+	{"M", func(t time.Time) string { return strconv.Itoa(int(t.Month())) }},
+	{"dd", func(t time.Time) string { return t.Format("02") }},
+	{"d", func(t time.Time) string { return strconv.Itoa(t.Day()) }},
+	{"EEEE", func(t time.Time) string { return t.Format("Monday") }},
+	{"EEE", func(t time.Time) string { return t.Format("Mon") }},
+	{"HH", func(t time.Time) string { return t.Format("15") }},
+	{"H", func(t time.Time) string { return strconv.Itoa(t.Hour()) }},
+	{"hh", func(t time.Time) string { return t.Format("03") }},
+	{"h", func(t time.Time) string { return t.Format("3") }},
+	{"mm", func(t time.Time) string { return t.Format("04") }},
+	{"m", func(t time.Time) string { return strconv.Itoa(t.Minute()) }},
+	{"ss", func(t time.Time) string { return t.Format("05") }},
+	{"s", func(t time.Time) string { return strconv.Itoa(t.Second()) }},
+	{"a", func(t time.Time) string { return t.Format("PM") }},
+}
+
+func formatDate(t time.Time, format string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(format); {
+		if format[i] == '\'' {
+			end := strings.IndexByte(format[i+1:], '\'')
+			if end < 0 {
+				return "", errors.New("unterminated quote in date format")
+			}
+			if end == 0 {
+				b.WriteByte('\'')
+			}
+			b.WriteString(format[i+1 : i+1+end])
+			i += end + 2
+			continue
+		}
+
+		matched := false
+		for _, tok := range dateTokens {
+			if strings.HasPrefix(format[i:], tok.token) {
+				b.WriteString(tok.format(t))
+				i += len(tok.token)
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			b.WriteByte(format[i])
+			i++
+		}
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,68 @@
+package render_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/novuhq/go-novu/render"
+)
+
+func TestHelpers(t *testing.T) {
+	data := map[string]interface{}{
+		"date":   "2023-03-05T14:07:09Z",
+		"millis": 1678025229000,
+		"amount": 1234567.891,
+		"people": []map[string]interface{}{
+			{"name": "Cid", "team": "b", "age": 40},
+			{"name": "Ann", "team": "a", "age": 30},
+			{"name": "Bob", "team": "b", "age": 25},
+		},
+		"tags": []string{"x", "y", "x", "z", "y"},
+	}
+
+	tests := map[string]struct {
+		template string
+		want     string
+	}{
+		"uppercase":           {`{{uppercase "abc"}}`, "ABC"},
+		"lowercase":           {`{{lowercase "ABC"}}`, "abc"},
+		"titlecase":           {`{{titlecase "jOHN doe"}}`, "John Doe"},
+		"pluralize one":       {`{{pluralize 1 "item" "items"}}`, "1 item"},
+		"pluralize many":      {`{{pluralize 0 "item" "items"}}`, "0 items"},
+		"date":                {`{{dateFormat date "MMMM d, yyyy 'at' HH:mm"}}`, "March 5, 2023 at 14:07"},
+		"date short":          {`{{dateFormat date "dd/MM/yy h:mm a EEE"}}`, "05/03/23 2:07 PM Sun"},
+		"date timestamp":      {`{{dateFormat millis "yyyy-MM-dd'T'HH:mm:ss"}}`, "2023-03-05T14:07:09"},
+		"date only":           {`{{dateFormat "2023-12-31" "EEEE"}}`, "Sunday"},
+		"number format":       {`{{numberFormat amount}}`, "1,234,567.89"},
+		"number format hash":  {`{{numberFormat amount decimalLength=1 thousandsSep="." decimalSep=","}}`, "1.234.567,9"},
+		"number format int":   {`{{numberFormat 999 decimalLength=0}}`, "999"},
+		"unique":              {`{{#each (unique tags)}}{{this}}{{/each}}`, "xyz"},
+		"unique property":     {`{{#each (unique people "team")}}{{this}}{{/each}}`, "ba"},
+		"sortBy":              {`{{#each (sortBy people "age")}}{{name}} {{/each}}`, "Bob Ann Cid "},
+		"sortBy strings":      {`{{#each (sortBy people "name")}}{{name}} {{/each}}`, "Ann Bob Cid "},
+		"groupBy":             {`{{#each (groupBy people "team")}}{{key}}:{{#each items}}{{name}}{{/each}} {{/each}}`, "b:CidBob a:Ann "},
+		"equals strings":      {`{{#equals "a" "a"}}same{{/equals}}`, "same"},
+		"equals number":       {`{{#equals 2 "2"}}same{{/equals}}`, "same"},
+		"gte":                 {`{{#gte 3 3}}yes{{/gte}}`, "yes"},
+		"ne inline":           {`{{ne 1 2}}`, "true"},
+		"eq strings":          {`{{#eq "b" "a"}}{{else}}different{{/eq}}`, "different"},
+		"if with literal":     {`{{#if true}}t{{/if}}{{#if null}}n{{/if}}{{#if 0}}z{{/if}}`, "t"},
+		"comparisons in each": {`{{#each people}}{{#if (lt age 35)}}{{name}}{{/if}}{{/each}}`, "AnnBob"},
+		"lookup":              {`{{lookup people.[1] "name"}} {{#each tags}}{{lookup ../tags @index}}{{/each}}`, "Ann xyxzy"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := render.Render(tc.template, data)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestDateFormatInvalid(t *testing.T) {
+	_, err := render.Render(`{{dateFormat "not a date" "yyyy"}}`, nil)
+	assert.Error(t, err)
+}
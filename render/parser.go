@@ -0,0 +1,623 @@
+package render
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// node is an element of a parsed template.
+type node interface{}
+
+type textNode struct {
+	text string
+}
+
+type mustacheNode struct {
+	expr   *sexpr
+	escape bool
+	line   int
+}
+
+type blockNode struct {
+	expr        *sexpr
+	program     []node
+	inverse     []node
+	blockParams []string
+	line        int
+}
+
+// closeTag is the {{/name}} tag ending a block.
+type closeTag = tag
+
+// expr is one of *pathExpr, *literalExpr or *sexpr.
+type expr interface{}
+
+type pathExpr struct {
+	original string
+	// data is set for @ variables such as @index.
+	data bool
+	// depth counts the ../ segments.
+	depth int
+	parts []string
+}
+
+type literalExpr struct {
+	value interface{}
+}
+
+type sexpr struct {
+	head   expr
+	params []expr
+	hash   map[string]expr
+}
+
+// name returns the helper name of the expression, if its head is a simple path.
+func (s *sexpr) name() string {
+	if p, ok := s.head.(*pathExpr); ok && !p.data && p.depth == 0 && len(p.parts) == 1 {
+		return p.parts[0]
+	}
+	return ""
+}
+
+type tagKind int
+
+const (
+	tagMustache tagKind = iota
+	tagUnescaped
+	tagOpen
+	tagClose
+	tagElse
+	tagComment
+)
+
+type tag struct {
+	kind       tagKind
+	content    string
+	line       int
+	stripLeft  bool
+	stripRight bool
+}
+
+type token struct {
+	text    string
+	literal bool
+	line    int
+	tag     *tag
+}
+
+// lex splits the source in text and tag tokens.
+func lex(src string) ([]token, error) {
+	var tokens []token
+	line := 1
+
+	for len(src) > 0 {
+		start := strings.Index(src, "{{")
+		if start < 0 {
+			tokens = append(tokens, token{text: src, literal: true, line: line})
+			break
+		}
+		// \{{ escapes a mustache
+		if start > 0 && src[start-1] == '\\' {
+			tokens = append(tokens, token{text: src[:start-1] + "{{", literal: true, line: line})
+			line += strings.Count(src[:start], "\n")
+			src = src[start+2:]
+			continue
+		}
+		if start > 0 {
+			tokens = append(tokens, token{text: src[:start], literal: true, line: line})
+			line += strings.Count(src[:start], "\n")
+			src = src[start:]
+		}
+
+		t, n, err := lexTag(src, line)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token{tag: t, line: line})
+		line += strings.Count(src[:n], "\n")
+		src = src[n:]
+	}
+
+	return tokens, nil
+}
+
+// lexTag reads the tag at the beginning of src and returns it with its length.
+func lexTag(src string, line int) (*tag, int, error) {
+	t := &tag{line: line}
+
+	if strings.HasPrefix(src, "{{{") {
+		end := strings.Index(src, "}}}")
+		if end < 0 {
+			return nil, 0, fmt.Errorf("line %d: unclosed {{{", line)
+		}
+		t.kind = tagUnescaped
+		t.content = src[3:end]
+		n := end + 3
+		if strings.HasPrefix(t.content, "~") {
+			t.stripLeft = true
+			t.content = t.content[1:]
+		}
+		if strings.HasSuffix(t.content, "~") {
+			t.stripRight = true
+			t.content = t.content[:len(t.content)-1]
+		}
+		t.content = strings.TrimSpace(t.content)
+		return t, n, nil
+	}
+
+	inner := src[2:]
+	if strings.HasPrefix(inner, "~") {
+		t.stripLeft = true
+		inner = inner[1:]
+	}
+
+	if strings.HasPrefix(inner, "!--") {
+		end := strings.Index(inner, "--}}")
+		if end < 0 {
+			return nil, 0, fmt.Errorf("line %d: unclosed comment", line)
+		}
+		t.kind = tagComment
+		n := len(src) - len(inner) + end + 4
+		if end > 0 && inner[end-1] == '~' {
+			t.stripRight = true
+		}
+		return t, n, nil
+	}
+
+	end := strings.Index(inner, "}}")
+	if end < 0 {
+		return nil, 0, fmt.Errorf("line %d: unclosed {{", line)
+	}
+	n := len(src) - len(inner) + end + 2
+	content := inner[:end]
+	if strings.HasSuffix(content, "~") {
+		t.stripRight = true
+		content = content[:len(content)-1]
+	}
+	content = strings.TrimSpace(content)
+
+	switch {
+	case strings.HasPrefix(content, "!"):
+		t.kind = tagComment
+	case strings.HasPrefix(content, "#"):
+		if strings.HasPrefix(content, "#>") || strings.HasPrefix(content, "#*") {
+			return nil, 0, fmt.Errorf("line %d: partial blocks and decorators are not supported", line)
+		}
+		t.kind = tagOpen
+		t.content = strings.TrimSpace(content[1:])
+	case strings.HasPrefix(content, "/"):
+		t.kind = tagClose
+		t.content = strings.TrimSpace(content[1:])
+	case content == "^" || content == "else":
+		t.kind = tagElse
+	case strings.HasPrefix(content, "else "):
+		t.kind = tagElse
+		t.content = strings.TrimSpace(content[len("else "):])
+	case strings.HasPrefix(content, "^"):
+		return nil, 0, fmt.Errorf("line %d: inverse sections are not supported, use {{#unless}}", line)
+	case strings.HasPrefix(content, ">"):
+		return nil, 0, fmt.Errorf("line %d: partials are not supported", line)
+	case strings.HasPrefix(content, "&"):
+		t.kind = tagUnescaped
+		t.content = strings.TrimSpace(content[1:])
+	default:
+		t.kind = tagMustache
+		t.content = content
+	}
+
+	return t, n, nil
+}
+
+// applyWhitespaceControl trims the text around tags using ~.
+func applyWhitespaceControl(tokens []token) {
+	for i, tok := range tokens {
+		if tok.tag == nil {
+			continue
+		}
+		if tok.tag.stripLeft && i > 0 && tokens[i-1].tag == nil {
+			tokens[i-1].text = strings.TrimRightFunc(tokens[i-1].text, unicode.IsSpace)
+		}
+		if tok.tag.stripRight && i+1 < len(tokens) && tokens[i+1].tag == nil {
+			tokens[i+1].text = strings.TrimLeftFunc(tokens[i+1].text, unicode.IsSpace)
+		}
+	}
+}
+
+// applyStandalone removes the indentation and line break around block, else
+// and comment tags that stand alone on their line, like Handlebars does.
+func applyStandalone(tokens []token) {
+	// Cuts are computed on the original text first, as one text token can
+	// follow a standalone tag and precede the next one.
+	type cut struct{ start, end int }
+	cuts := make([]cut, len(tokens))
+	for i, tok := range tokens {
+		cuts[i] = cut{0, len(tok.text)}
+	}
+
+	for i, tok := range tokens {
+		if tok.tag == nil || tok.tag.kind == tagMustache || tok.tag.kind == tagUnescaped {
+			continue
+		}
+
+		prefix := -1
+		switch {
+		case i == 0:
+			prefix = 0
+		case tokens[i-1].tag == nil:
+			text := tokens[i-1].text
+			start := strings.LastIndexByte(text, '\n') + 1
+			if (start > 0 || i == 1) && isBlank(text[start:]) {
+				prefix = start
+			}
+		}
+		if prefix < 0 {
+			continue
+		}
+
+		suffix := -1
+		switch {
+		case i == len(tokens)-1:
+			suffix = 0
+		case tokens[i+1].tag == nil:
+			text := tokens[i+1].text
+			end := strings.IndexByte(text, '\n')
+			if end >= 0 && isBlank(text[:end]) {
+				suffix = end + 1
+			} else if end < 0 && i+1 == len(tokens)-1 && isBlank(text) {
+				suffix = len(text)
+			}
+		}
+		if suffix < 0 {
+			continue
+		}
+
+		if i > 0 {
+			cuts[i-1].end = prefix
+		}
+		if i+1 < len(tokens) {
+			cuts[i+1].start = suffix
+		}
+	}
+
+	for i, c := range cuts {
+		if tokens[i].tag == nil {
+			if c.start > c.end {
+				c.start = c.end
+			}
+			tokens[i].text = tokens[i].text[c.start:c.end]
+		}
+	}
+}
+
+func isBlank(s string) bool {
+	return strings.Trim(s, " \t\r") == ""
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func parse(src string) ([]node, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	applyStandalone(tokens)
+	applyWhitespaceControl(tokens)
+
+	p := &parser{tokens: tokens}
+	nodes, stop, err := p.parseProgram()
+	if err != nil {
+		return nil, err
+	}
+	if stop != nil {
+		if stop.kind == tagClose {
+			return nil, fmt.Errorf("line %d: unexpected {{/%s}}", stop.line, stop.content)
+		}
+		return nil, fmt.Errorf("line %d: unexpected {{else}}", stop.line)
+	}
+
+	return nodes, nil
+}
+
+// parseProgram reads nodes until a close or else tag, which is returned.
+func (p *parser) parseProgram() ([]node, *tag, error) {
+	var nodes []node
+
+	for p.pos < len(p.tokens) {
+		tok := p.tokens[p.pos]
+		p.pos++
+
+		if tok.tag == nil {
+			if tok.text != "" {
+				nodes = append(nodes, &textNode{text: tok.text})
+			}
+			continue
+		}
+
+		switch tok.tag.kind {
+		case tagComment:
+		case tagMustache, tagUnescaped:
+			e, _, err := parseExpression(tok.tag.content, tok.tag.line)
+			if err != nil {
+				return nil, nil, err
+			}
+			nodes = append(nodes, &mustacheNode{expr: e, escape: tok.tag.kind == tagMustache, line: tok.tag.line})
+		case tagOpen:
+			block, stop, err := p.parseBlock(tok.tag)
+			if err != nil {
+				return nil, nil, err
+			}
+			if name := block.expr.name(); name != "" && stop.content != name {
+				return nil, nil, fmt.Errorf("line %d: {{#%s}} closed by {{/%s}}", tok.tag.line, name, stop.content)
+			}
+			nodes = append(nodes, block)
+		case tagClose, tagElse:
+			return nodes, tok.tag, nil
+		}
+	}
+
+	return nodes, nil, nil
+}
+
+// parseBlock reads a block up to its closing tag, which is returned so the
+// caller can check it against the name of the outermost block of an else chain.
+func (p *parser) parseBlock(open *tag) (*blockNode, *closeTag, error) {
+	e, blockParams, err := parseExpression(open.content, open.line)
+	if err != nil {
+		return nil, nil, err
+	}
+	block := &blockNode{expr: e, blockParams: blockParams, line: open.line}
+
+	program, stop, err := p.parseProgram()
+	if err != nil {
+		return nil, nil, err
+	}
+	block.program = program
+
+	if stop != nil && stop.kind == tagElse {
+		if stop.content != "" {
+			// {{else if ...}} chains a nested block sharing the closing tag
+			chained, close, err := p.parseBlock(&tag{kind: tagOpen, content: stop.content, line: stop.line})
+			if err != nil {
+				return nil, nil, err
+			}
+			block.inverse = []node{chained}
+			return block, close, nil
+		}
+		inverse, next, err := p.parseProgram()
+		if err != nil {
+			return nil, nil, err
+		}
+		block.inverse = inverse
+		stop = next
+	}
+
+	if stop == nil {
+		return nil, nil, fmt.Errorf("line %d: {{#%s}} is never closed", open.line, open.content)
+	}
+	if stop.kind == tagElse {
+		return nil, nil, fmt.Errorf("line %d: duplicate {{else}}", stop.line)
+	}
+	return block, stop, nil
+}
+
+// parseExpression parses the content of a tag, returning the block params of
+// "as |a b|" when present.
+func parseExpression(content string, line int) (*sexpr, []string, error) {
+	var blockParams []string
+	if i := strings.Index(content, " as |"); i >= 0 {
+		rest := content[i+len(" as |"):]
+		end := strings.Index(rest, "|")
+		if end < 0 {
+			return nil, nil, fmt.Errorf("line %d: unclosed block params", line)
+		}
+		blockParams = strings.Fields(rest[:end])
+		content = content[:i]
+	}
+
+	words, err := tokenizeExpression(content, line)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(words) == 0 {
+		return nil, nil, fmt.Errorf("line %d: empty expression", line)
+	}
+
+	ep := &exprParser{words: words, line: line}
+	e, err := ep.parseSexpr(false)
+	if err != nil {
+		return nil, nil, err
+	}
+	if ep.pos < len(ep.words) {
+		return nil, nil, fmt.Errorf("line %d: unexpected %q", line, ep.words[ep.pos].text)
+	}
+
+	return e, blockParams, nil
+}
+
+type word struct {
+	text   string
+	quoted bool
+}
+
+func tokenizeExpression(content string, line int) ([]word, error) {
+	var words []word
+	i := 0
+	for i < len(content) {
+		c := content[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(' || c == ')' || c == '=':
+			words = append(words, word{text: string(c)})
+			i++
+		case c == '"' || c == '\'':
+			end := strings.IndexByte(content[i+1:], c)
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unclosed string", line)
+			}
+			words = append(words, word{text: content[i+1 : i+1+end], quoted: true})
+			i += end + 2
+		default:
+			start := i
+			for i < len(content) {
+				c := content[i]
+				if c == '[' {
+					end := strings.IndexByte(content[i:], ']')
+					if end < 0 {
+						return nil, fmt.Errorf("line %d: unclosed [", line)
+					}
+					i += end + 1
+					continue
+				}
+				if c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '(' || c == ')' || c == '=' {
+					break
+				}
+				i++
+			}
+			words = append(words, word{text: content[start:i]})
+		}
+	}
+	return words, nil
+}
+
+type exprParser struct {
+	words []word
+	pos   int
+	line  int
+}
+
+func (p *exprParser) parseSexpr(nested bool) (*sexpr, error) {
+	head, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	s := &sexpr{head: head}
+
+	for p.pos < len(p.words) {
+		w := p.words[p.pos]
+		if !w.quoted && w.text == ")" {
+			if !nested {
+				return nil, fmt.Errorf("line %d: unexpected )", p.line)
+			}
+			break
+		}
+		if p.pos+1 < len(p.words) && !w.quoted && p.words[p.pos+1].text == "=" && !p.words[p.pos+1].quoted {
+			p.pos += 2
+			value, err := p.parseValue()
+			if err != nil {
+				return nil, err
+			}
+			if s.hash == nil {
+				s.hash = map[string]expr{}
+			}
+			s.hash[w.text] = value
+			continue
+		}
+		if s.hash != nil {
+			return nil, fmt.Errorf("line %d: positional parameter %q after hash arguments", p.line, w.text)
+		}
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		s.params = append(s.params, value)
+	}
+
+	return s, nil
+}
+
+func (p *exprParser) parseValue() (expr, error) {
+	if p.pos >= len(p.words) {
+		return nil, fmt.Errorf("line %d: unexpected end of expression", p.line)
+	}
+	w := p.words[p.pos]
+	p.pos++
+
+	if w.quoted {
+		return &literalExpr{value: w.text}, nil
+	}
+
+	switch w.text {
+	case "(":
+		s, err := p.parseSexpr(true)
+		if err != nil {
+			return nil, err
+		}
+		if p.pos >= len(p.words) || p.words[p.pos].text != ")" {
+			return nil, fmt.Errorf("line %d: unclosed (", p.line)
+		}
+		p.pos++
+		return s, nil
+	case ")", "=":
+		return nil, fmt.Errorf("line %d: unexpected %q", p.line, w.text)
+	case "true":
+		return &literalExpr{value: true}, nil
+	case "false":
+		return &literalExpr{value: false}, nil
+	case "null", "undefined":
+		return &literalExpr{value: nil}, nil
+	}
+
+	if n, err := strconv.ParseFloat(w.text, 64); err == nil && (w.text[0] == '-' || (w.text[0] >= '0' && w.text[0] <= '9')) {
+		return &literalExpr{value: n}, nil
+	}
+
+	return parsePath(w.text, p.line)
+}
+
+func parsePath(text string, line int) (*pathExpr, error) {
+	path := &pathExpr{original: text}
+
+	if strings.HasPrefix(text, "@") {
+		path.data = true
+		text = text[1:]
+	}
+	for strings.HasPrefix(text, "../") {
+		path.depth++
+		text = text[3:]
+	}
+	switch text {
+	case "..":
+		path.depth++
+		return path, nil
+	case ".", "this", "":
+		return path, nil
+	}
+	text = strings.TrimPrefix(text, "this.")
+	text = strings.TrimPrefix(text, "this/")
+
+	var parts []string
+	for len(text) > 0 {
+		var part string
+		if text[0] == '[' {
+			end := strings.IndexByte(text, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unclosed [ in %q", line, path.original)
+			}
+			part = text[1:end]
+			text = text[end+1:]
+		} else {
+			end := strings.IndexAny(text, "./")
+			if end < 0 {
+				end = len(text)
+			}
+			part = text[:end]
+			text = text[end:]
+		}
+		if len(text) > 0 && (text[0] == '.' || text[0] == '/') {
+			text = text[1:]
+		}
+		if part == "" {
+			return nil, fmt.Errorf("line %d: invalid path %q", line, path.original)
+		}
+		parts = append(parts, part)
+	}
+
+	path.parts = parts
+	return path, nil
+}
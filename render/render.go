@@ -0,0 +1,219 @@
+// Package render renders Novu layouts and step content offline.
+//
+// Templates use the Handlebars syntax supported by Novu together with its
+// helper set (if, unless, each, with, equals, titlecase, pluralize,
+// dateFormat, ...), so previews and snapshot tests produce the same output
+// as a real delivery without sending anything.
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/novuhq/go-novu/lib"
+)
+
+// Renderer parses and executes templates with a set of helpers.
+type Renderer struct {
+	helpers map[string]Helper
+	// Strict makes references to missing variables an error instead of
+	// rendering them as empty strings.
+	Strict bool
+}
+
+// Template is a parsed template ready to be executed.
+type Template struct {
+	renderer *Renderer
+	nodes    []node
+}
+
+// Context holds the variables available to Novu templates. Payload keys are
+// available at the top level, the rest under subscriber, step, tenant and
+// branding.
+type Context struct {
+	Payload    map[string]interface{}
+	Subscriber *lib.SubscriberPayload
+	Step       map[string]interface{}
+	Tenant     *lib.TriggerTenant
+	Branding   map[string]interface{}
+}
+
+// Output is the result of rendering a step or a layout.
+type Output struct {
+	HTML string
+	Text string
+}
+
+// New returns a Renderer with Novu's helpers registered.
+func New() *Renderer {
+	return &Renderer{helpers: builtinHelpers()}
+}
+
+var defaultRenderer = New()
+
+// RegisterHelper registers a helper, replacing any helper with the same name.
+func (r *Renderer) RegisterHelper(name string, helper Helper) {
+	r.helpers[name] = helper
+}
+
+// Parse parses src into a Template.
+func (r *Renderer) Parse(src string) (*Template, error) {
+	nodes, err := parse(src)
+	if err != nil {
+		return nil, err
+	}
+	return &Template{renderer: r, nodes: nodes}, nil
+}
+
+// Execute renders the template with data. Structs and maps are converted
+// through their JSON representation, so json tags decide the variable names.
+func (t *Template) Execute(data interface{}) (string, error) {
+	root, err := normalize(data)
+	if err != nil {
+		return "", err
+	}
+	s := &state{
+		helpers: t.renderer.helpers,
+		root:    root,
+		frames:  []frame{{ctx: root, data: map[string]interface{}{}}},
+		strict:  t.renderer.Strict,
+	}
+	var b strings.Builder
+	if err := s.render(t.nodes, &b); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// Render parses and executes src with data.
+func (r *Renderer) Render(src string, data interface{}) (string, error) {
+	tmpl, err := r.Parse(src)
+	if err != nil {
+		return "", err
+	}
+	return tmpl.Execute(data)
+}
+
+// RenderStep renders step content, such as an email body or an SMS text.
+func (r *Renderer) RenderStep(content string, ctx Context) (*Output, error) {
+	out, err := r.Render(content, ctx.variables())
+	if err != nil {
+		return nil, err
+	}
+	return &Output{HTML: out, Text: HTMLToText(out)}, nil
+}
+
+// RenderLayout renders a layout with body as the {{{body}}} variable.
+func (r *Renderer) RenderLayout(layout, body string, ctx Context) (*Output, error) {
+	vars := ctx.variables()
+	vars["body"] = body
+	out, err := r.Render(layout, vars)
+	if err != nil {
+		return nil, err
+	}
+	return &Output{HTML: out, Text: HTMLToText(out)}, nil
+}
+
+// RenderEmail renders the step content and wraps it in the layout. An empty
+// layout renders the content alone.
+func (r *Renderer) RenderEmail(content, layout string, ctx Context) (*Output, error) {
+	step, err := r.RenderStep(content, ctx)
+	if err != nil {
+		return nil, err
+	}
+	if layout == "" {
+		return step, nil
+	}
+	out, err := r.RenderLayout(layout, step.HTML, ctx)
+	if err != nil {
+		return nil, fmt.Errorf("layout: %w", err)
+	}
+	return out, nil
+}
+
+// Render parses and executes src with data using Novu's helpers.
+func Render(src string, data interface{}) (string, error) {
+	return defaultRenderer.Render(src, data)
+}
+
+// RenderStep renders step content using Novu's helpers.
+func RenderStep(content string, ctx Context) (*Output, error) {
+	return defaultRenderer.RenderStep(content, ctx)
+}
+
+// RenderLayout renders a layout with body using Novu's helpers.
+func RenderLayout(layout, body string, ctx Context) (*Output, error) {
+	return defaultRenderer.RenderLayout(layout, body, ctx)
+}
+
+// RenderEmail renders the step content inside the layout using Novu's helpers.
+func RenderEmail(content, layout string, ctx Context) (*Output, error) {
+	return defaultRenderer.RenderEmail(content, layout, ctx)
+}
+
+func (c Context) variables() map[string]interface{} {
+	vars := map[string]interface{}{}
+	for k, v := range c.Payload {
+		vars[k] = v
+	}
+	if c.Subscriber != nil {
+		vars["subscriber"] = c.Subscriber
+	}
+	if c.Step != nil {
+		vars["step"] = c.Step
+	}
+	if c.Tenant != nil {
+		vars["tenant"] = map[string]interface{}{
+			"identifier": c.Tenant.Identifier,
+			"name":       c.Tenant.Name,
+			"data":       c.Tenant.Data,
+		}
+	}
+	if c.Branding != nil {
+		vars["branding"] = c.Branding
+	}
+	return vars
+}
+
+func normalize(data interface{}) (interface{}, error) {
+	if data == nil {
+		return map[string]interface{}{}, nil
+	}
+	bb, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("render data: %w", err)
+	}
+	var out interface{}
+	if err := json.Unmarshal(bb, &out); err != nil {
+		return nil, fmt.Errorf("render data: %w", err)
+	}
+	return out, nil
+}
+
+var (
+	dropElements = regexp.MustCompile(`(?is)<(head|style|script)\b.*?</(head|style|script)>`)
+	lineBreaks   = regexp.MustCompile(`(?i)<br\s*/?>|</(p|div|h[1-6]|li|tr|table)>`)
+	tags         = regexp.MustCompile(`(?s)<[^>]*>`)
+	spaces       = regexp.MustCompile(`[ \t\r\f\v]+`)
+	blankLines   = regexp.MustCompile(`\n\s*\n+`)
+)
+
+// HTMLToText converts rendered HTML to a plain text alternative.
+func HTMLToText(s string) string {
+	s = dropElements.ReplaceAllString(s, "")
+	s = lineBreaks.ReplaceAllString(s, "\n")
+	s = tags.ReplaceAllString(s, "")
+	s = html.UnescapeString(s)
+	s = spaces.ReplaceAllString(s, " ")
+
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	s = strings.Join(lines, "\n")
+	s = blankLines.ReplaceAllString(s, "\n\n")
+	return strings.TrimSpace(s)
+}
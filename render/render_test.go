@@ -0,0 +1,186 @@
+package render_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/novuhq/go-novu/lib"
+	"github.com/novuhq/go-novu/render"
+)
+
+func TestRender(t *testing.T) {
+	data := map[string]interface{}{
+		"name":   "<Jane>",
+		"count":  3,
+		"price":  12.5,
+		"active": true,
+		"empty":  []string{},
+		"items":  []string{"a", "b", "c"},
+		"user":   map[string]interface{}{"first": "Jane", "tags": []string{"x", "y"}},
+		"html":   "<b>bold</b>",
+		"quotes": `'a' "b" ` + "`c` = &",
+	}
+
+	tests := map[string]struct {
+		template string
+		want     string
+	}{
+		"text":                {"Hello", "Hello"},
+		"escaped":             {"Hi {{name}}", "Hi &lt;Jane&gt;"},
+		"triple stash":        {"{{{html}}}", "<b>bold</b>"},
+		"ampersand":           {"{{& html}}", "<b>bold</b>"},
+		"numbers":             {"{{count}} {{price}}", "3 12.5"},
+		"nested path":         {"{{user.first}}", "Jane"},
+		"array index":         {"{{items.[1]}} {{items.length}}", "b 3"},
+		"missing":             {"[{{missing.value}}]", "[]"},
+		"comment":             {"a{{! note }}b{{!-- {{x}} --}}c", "abc"},
+		"escaped mustache":    {`\{{name}}`, "{{name}}"},
+		"whitespace control":  {"a  {{~name~}}  b", "a&lt;Jane&gt;b"},
+		"if":                  {"{{#if active}}yes{{else}}no{{/if}}", "yes"},
+		"if empty list":       {"{{#if empty}}yes{{else}}no{{/if}}", "no"},
+		"unless":              {"{{#unless active}}yes{{else}}no{{/unless}}", "no"},
+		"else if":             {"{{#if missing}}a{{else if active}}b{{else}}c{{/if}}", "b"},
+		"each":                {"{{#each items}}{{@index}}{{this}}{{#unless @last}},{{/unless}}{{/each}}", "0a,1b,2c"},
+		"each first":          {"{{#each items}}{{#if @first}}[{{/if}}{{.}}{{/each}}", "[abc"},
+		"each else":           {"{{#each empty}}x{{else}}none{{/each}}", "none"},
+		"each object":         {"{{#each user}}{{@key}};{{/each}}", "first;tags;"},
+		"each block params":   {"{{#each items as |item i|}}{{i}}={{item}} {{/each}}", "0=a 1=b 2=c "},
+		"parent scope":        {"{{#each user.tags}}{{../user.first}}:{{this}} {{/each}}", "Jane:x Jane:y "},
+		"root":                {"{{#with user}}{{@root.count}}{{/with}}", "3"},
+		"with":                {"{{#with user}}{{first}}{{/with}}", "Jane"},
+		"section on value":    {"{{#user}}{{first}}{{/user}}", "Jane"},
+		"subexpression":       {"{{#if (gt count 2)}}many{{/if}}", "many"},
+		"inline helper":       {"{{uppercase (lowercase name)}}", "&lt;JANE&gt;"},
+		"string literal":      {`{{titlecase "hello big world"}}`, "Hello Big World"},
+		"standalone lines":    {"<ul>\n{{#each items}}\n<li>{{this}}</li>\n{{/each}}\n</ul>", "<ul>\n<li>a</li>\n<li>b</li>\n<li>c</li>\n</ul>"},
+		"nested standalone":   {"{{#each items}}\n  {{#if @first}}\n  {{this}}\n  {{/if}}\n{{/each}}\n", "  a\n"},
+		"equals block":        {`{{#equals count 3}}three{{else}}other{{/equals}}`, "three"},
+		"comparison inverse":  {`{{#lt count 2}}few{{else}}lots{{/lt}}`, "lots"},
+		"escaped equals sign": {"{{{html}}}{{html}}", "<b>bold</b>&lt;b&gt;bold&lt;/b&gt;"},
+		"escape table":        {"{{quotes}}", "&#x27;a&#x27; &quot;b&quot; &#x60;c&#x60; &#x3D; &amp;"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := render.Render(tc.template, data)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestRenderParentScope(t *testing.T) {
+	data := map[string]interface{}{
+		"title": "root",
+		"items": []map[string]interface{}{{"title": "item", "x": true, "sub": map[string]interface{}{"title": "sub"}}},
+	}
+
+	// ../ skips the blocks that keep the context, as in Handlebars 4.
+	tests := map[string]struct {
+		template string
+		want     string
+	}{
+		"if":            {"{{#each items}}{{#if x}}{{../title}}{{/if}}{{/each}}", "root"},
+		"unless":        {"{{#each items}}{{#unless missing}}{{../title}}{{/unless}}{{/each}}", "root"},
+		"else":          {"{{#each items}}{{#if missing}}{{else}}{{../title}}{{/if}}{{/each}}", "root"},
+		"with":          {"{{#each items}}{{#with sub}}{{title}} {{../title}} {{../../title}}{{/with}}{{/each}}", "sub item root"},
+		"with under if": {"{{#each items}}{{#if x}}{{#with sub}}{{../title}}{{/with}}{{/if}}{{/each}}", "item"},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := render.Render(tc.template, data)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestRenderErrors(t *testing.T) {
+	tests := map[string]string{
+		"unclosed block":    "{{#if a}}x",
+		"mismatched block":  "{{#if a}}x{{/each}}",
+		"unclosed mustache": "{{name",
+		"missing helper":    "{{nope a b}}",
+		"partial":           "{{> header}}",
+		"helper error":      "{{pluralize count}}",
+	}
+	for name, template := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := render.Render(template, map[string]interface{}{"count": 1})
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestRenderStrict(t *testing.T) {
+	r := render.New()
+	r.Strict = true
+
+	_, err := r.Render("{{firstName}} {{lastName}}", map[string]interface{}{"firstName": "Jane"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "lastName")
+}
+
+func TestRegisterHelper(t *testing.T) {
+	r := render.New()
+	r.RegisterHelper("link", func(params []interface{}, options *render.HelperOptions) (interface{}, error) {
+		if len(params) != 1 {
+			return nil, errors.New("link expects a url")
+		}
+		text, _ := options.Hash["text"].(string)
+		return render.SafeString(`<a href="` + params[0].(string) + `">` + text + `</a>`), nil
+	})
+	r.RegisterHelper("repeat", func(params []interface{}, options *render.HelperOptions) (interface{}, error) {
+		out, err := options.Fn(options.Context)
+		return strings.Repeat(out, int(params[0].(float64))), err
+	})
+
+	got, err := r.Render(`{{link url text="Open"}} {{#repeat 3}}{{x}}{{/repeat}}`, map[string]interface{}{"url": "https://novu.co", "x": "-"})
+	require.NoError(t, err)
+	assert.Equal(t, `<a href="https://novu.co">Open</a> ---`, got)
+}
+
+func TestRenderEmail(t *testing.T) {
+	layout := `<html><head><style>p { color: red; }</style></head><body>` +
+		`<img src="{{branding.logo}}"/>{{{body}}}<footer>{{tenant.name}}</footer></body></html>`
+	content := `<p>Hi {{titlecase subscriber.firstName}},</p>` +
+		`<p>You have {{pluralize step.total_count "new comment" "new comments"}} on {{project}}.</p>` +
+		`{{#each step.events}}<p>{{payload.author}} &amp; co</p>{{/each}}`
+
+	ctx := render.Context{
+		Payload:    map[string]interface{}{"project": "Docs"},
+		Subscriber: &lib.SubscriberPayload{SubscriberId: "sub", FirstName: "jane"},
+		Step: map[string]interface{}{
+			"total_count": 2,
+			"events": []map[string]interface{}{
+				{"payload": map[string]interface{}{"author": "Ann"}},
+				{"payload": map[string]interface{}{"author": "Bob"}},
+			},
+		},
+		Tenant:   &lib.TriggerTenant{Identifier: "acme", Name: "Acme"},
+		Branding: map[string]interface{}{"logo": "https://example.com/logo.png"},
+	}
+
+	out, err := render.RenderEmail(content, layout, ctx)
+	require.NoError(t, err)
+	assert.Equal(t, `<html><head><style>p { color: red; }</style></head><body>`+
+		`<img src="https://example.com/logo.png"/>`+
+		`<p>Hi Jane,</p><p>You have 2 new comments on Docs.</p><p>Ann &amp; co</p><p>Bob &amp; co</p>`+
+		`<footer>Acme</footer></body></html>`, out.HTML)
+	assert.Equal(t, "Hi Jane,\nYou have 2 new comments on Docs.\nAnn & co\nBob & co\nAcme", out.Text)
+}
+
+func TestParse(t *testing.T) {
+	tmpl, err := render.New().Parse("Hello {{name}}")
+	require.NoError(t, err)
+
+	for _, name := range []string{"Ann", "Bob"} {
+		got, err := tmpl.Execute(map[string]string{"name": name})
+		require.NoError(t, err)
+		assert.Equal(t, "Hello "+name, got)
+	}
+}
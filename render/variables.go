@@ -0,0 +1,239 @@
+package render
+
+import "strings"
+
+// VariableType is the kind of value a template expects for a variable,
+// inferred from how the variable is used.
+type VariableType string
+
+const (
+	// TypeAny is used when the usage doesn't constrain the value.
+	TypeAny VariableType = "any"
+	// TypeBoolean is used for variables tested with {{#if}} or {{#unless}}.
+	TypeBoolean VariableType = "boolean"
+	// TypeScalar is used for variables rendered with {{variable}}.
+	TypeScalar VariableType = "scalar"
+	TypeNumber VariableType = "number"
+	TypeDate   VariableType = "date"
+	TypeList   VariableType = "list"
+	TypeObject VariableType = "object"
+)
+
+// precedence orders the types from the least to the most specific.
+var precedence = map[VariableType]int{
+	TypeAny:     0,
+	TypeBoolean: 1,
+	TypeScalar:  2,
+	TypeNumber:  3,
+	TypeDate:    3,
+	TypeList:    4,
+	TypeObject:  4,
+}
+
+// helperParamTypes gives the types expected by the positional parameters
+// of the built-in helpers.
+var helperParamTypes = map[string][]VariableType{
+	"if":           {TypeBoolean},
+	"unless":       {TypeBoolean},
+	"each":         {TypeList},
+	"with":         {TypeObject},
+	"lookup":       {TypeAny, TypeScalar},
+	"titlecase":    {TypeScalar},
+	"uppercase":    {TypeScalar},
+	"lowercase":    {TypeScalar},
+	"pluralize":    {TypeNumber},
+	"dateFormat":   {TypeDate},
+	"unique":       {TypeList},
+	"groupBy":      {TypeList},
+	"sortBy":       {TypeList},
+	"numberFormat": {TypeNumber},
+}
+
+// Variable is a variable referenced by a template. Path is relative to the
+// template data, with [] marking the items of a list, e.g. items[].name.
+type Variable struct {
+	Path string
+	Type VariableType
+	// Line is the line of the first reference.
+	Line int
+}
+
+// Variables returns the variables referenced by src in order of appearance.
+func (r *Renderer) Variables(src string) ([]Variable, error) {
+	nodes, err := parse(src)
+	if err != nil {
+		return nil, err
+	}
+	c := &collector{helpers: r.helpers, index: map[string]int{}}
+	c.visit(nodes, []scope{{}})
+	return c.vars, nil
+}
+
+// Variables returns the variables referenced by src using Novu's helpers.
+func Variables(src string) ([]Variable, error) {
+	return defaultRenderer.Variables(src)
+}
+
+// scope is the context of a block. An opaque scope has a context that
+// can't be traced back to the template data, such as the items of a list
+// computed by a helper.
+type scope struct {
+	prefix      string
+	opaque      bool
+	blockParams map[string]*scope
+	// keepsContext is set for the blocks of helpers rendered in the context
+	// of their parent, which ../ does not count.
+	keepsContext bool
+}
+
+type collector struct {
+	helpers map[string]Helper
+	vars    []Variable
+	index   map[string]int
+}
+
+func (c *collector) add(path string, typ VariableType, line int) {
+	if i, ok := c.index[path]; ok {
+		if precedence[typ] > precedence[c.vars[i].Type] {
+			c.vars[i].Type = typ
+		}
+		return
+	}
+	c.index[path] = len(c.vars)
+	c.vars = append(c.vars, Variable{Path: path, Type: typ, Line: line})
+}
+
+func (c *collector) visit(nodes []node, scopes []scope) {
+	for _, n := range nodes {
+		switch n := n.(type) {
+		case *mustacheNode:
+			c.visitCall(n.expr, scopes, TypeScalar, n.line)
+		case *blockNode:
+			c.visitBlock(n, scopes)
+		}
+	}
+}
+
+func (c *collector) isHelper(e *sexpr) bool {
+	if _, ok := c.helpers[e.name()]; ok {
+		return true
+	}
+	return len(e.params) > 0 || e.hash != nil
+}
+
+// visitCall records the variables of an expression whose result is used as typ.
+func (c *collector) visitCall(e *sexpr, scopes []scope, typ VariableType, line int) {
+	if !c.isHelper(e) {
+		c.visitExpr(e.head, scopes, typ, line)
+		return
+	}
+	types := helperParamTypes[e.name()]
+	for i, p := range e.params {
+		paramType := TypeAny
+		if i < len(types) {
+			paramType = types[i]
+		}
+		c.visitExpr(p, scopes, paramType, line)
+	}
+	for _, p := range e.hash {
+		c.visitExpr(p, scopes, TypeAny, line)
+	}
+}
+
+func (c *collector) visitExpr(e expr, scopes []scope, typ VariableType, line int) {
+	switch e := e.(type) {
+	case *sexpr:
+		c.visitCall(e, scopes, typ, line)
+	case *pathExpr:
+		if path, ok := resolveScope(e, scopes); ok {
+			c.add(path, typ, line)
+		}
+	}
+}
+
+func (c *collector) visitBlock(n *blockNode, scopes []scope) {
+	inner := scope{opaque: true}
+
+	switch name := n.expr.name(); {
+	case !c.isHelper(n.expr):
+		c.visitExpr(n.expr.head, scopes, TypeAny, n.line)
+	case (name == "each" || name == "with") && len(n.expr.params) > 0:
+		c.visitCall(n.expr, scopes, TypeAny, n.line)
+		if path, ok := listOrObject(n.expr.params[0], scopes); ok {
+			inner = scope{prefix: path}
+			if name == "each" {
+				inner.prefix += "[]"
+			}
+		}
+	default:
+		// Other helpers, such as {{#if}}, render their block in the same context.
+		c.visitCall(n.expr, scopes, TypeAny, n.line)
+		inner = scopes[len(scopes)-1]
+		inner.keepsContext = true
+	}
+
+	if len(n.blockParams) > 0 {
+		item := inner
+		inner.blockParams = map[string]*scope{n.blockParams[0]: &item}
+		for _, name := range n.blockParams[1:] {
+			inner.blockParams[name] = &scope{opaque: true}
+		}
+	}
+
+	c.visit(n.program, append(scopes, inner))
+	c.visit(n.inverse, scopes)
+}
+
+// listOrObject returns the path of the value given to {{#each}} or {{#with}},
+// looking through the helpers that keep the items of a list.
+func listOrObject(e expr, scopes []scope) (string, bool) {
+	if s, ok := e.(*sexpr); ok {
+		switch s.name() {
+		case "sortBy", "unique":
+			if len(s.params) == 1 || (s.name() == "sortBy" && len(s.params) > 0) {
+				return listOrObject(s.params[0], scopes)
+			}
+		}
+		return "", false
+	}
+	if p, ok := e.(*pathExpr); ok {
+		return resolveScope(p, scopes)
+	}
+	return "", false
+}
+
+// resolveScope returns the path of p relative to the template data.
+func resolveScope(p *pathExpr, scopes []scope) (string, bool) {
+	parts := p.parts
+	var base scope
+
+	switch {
+	case p.data:
+		if len(parts) == 0 || parts[0] != "root" {
+			return "", false
+		}
+		parts = parts[1:]
+	default:
+		base = scopes[depthIndex(len(scopes), p.depth, func(i int) bool { return scopes[i].keepsContext })]
+		if p.depth == 0 && len(parts) > 0 {
+			for i := len(scopes) - 1; i >= 0; i-- {
+				if bp, ok := scopes[i].blockParams[parts[0]]; ok {
+					base, parts = *bp, parts[1:]
+					break
+				}
+			}
+		}
+	}
+
+	if base.opaque {
+		return "", false
+	}
+	path := strings.Join(parts, ".")
+	switch {
+	case base.prefix == "":
+		return path, path != ""
+	case path == "":
+		return base.prefix, true
+	}
+	return base.prefix + "." + path, true
+}
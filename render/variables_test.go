@@ -0,0 +1,64 @@
+package render_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/novuhq/go-novu/render"
+)
+
+func TestVariables(t *testing.T) {
+	template := `<h1>Hi {{titlecase subscriber.firstName}}</h1>
+{{#if showTotal}}{{numberFormat order.total}}{{/if}}
+{{#each (sortBy order.items "price") as |item|}}
+  {{item.name}} {{dateFormat ../order.date "yyyy"}} {{@index}} {{@root.currency}}
+{{/each}}
+{{#each (groupBy order.items "category")}}{{key}}{{/each}}
+{{#with company}}{{name}}{{/with}}
+{{#each tags}}{{this}}{{else}}{{fallback}}{{/each}}
+{{{body}}} {{order.total}}`
+
+	vars, err := render.Variables(template)
+	require.NoError(t, err)
+
+	assert.Equal(t, []render.Variable{
+		{Path: "subscriber.firstName", Type: render.TypeScalar, Line: 1},
+		{Path: "showTotal", Type: render.TypeBoolean, Line: 2},
+		{Path: "order.total", Type: render.TypeNumber, Line: 2},
+		{Path: "order.items", Type: render.TypeList, Line: 3},
+		{Path: "order.items[].name", Type: render.TypeScalar, Line: 4},
+		{Path: "order.date", Type: render.TypeDate, Line: 4},
+		{Path: "currency", Type: render.TypeScalar, Line: 4},
+		{Path: "company", Type: render.TypeObject, Line: 7},
+		{Path: "company.name", Type: render.TypeScalar, Line: 7},
+		{Path: "tags", Type: render.TypeList, Line: 8},
+		{Path: "tags[]", Type: render.TypeScalar, Line: 8},
+		{Path: "fallback", Type: render.TypeScalar, Line: 8},
+		{Path: "body", Type: render.TypeScalar, Line: 9},
+	}, vars)
+}
+
+func TestVariablesParentScope(t *testing.T) {
+	vars, err := render.Variables("{{#each items}}{{#if x}}{{../title}}{{/if}}{{#with sub}}{{../name}}{{/with}}{{/each}}")
+	require.NoError(t, err)
+	assert.Equal(t, []render.Variable{
+		{Path: "items", Type: render.TypeList, Line: 1},
+		{Path: "items[].x", Type: render.TypeBoolean, Line: 1},
+		{Path: "title", Type: render.TypeScalar, Line: 1},
+		{Path: "items[].sub", Type: render.TypeObject, Line: 1},
+		{Path: "items[].name", Type: render.TypeScalar, Line: 1},
+	}, vars)
+}
+
+func TestVariablesCustomHelper(t *testing.T) {
+	r := render.New()
+	r.RegisterHelper("logo", func(params []interface{}, options *render.HelperOptions) (interface{}, error) {
+		return "", nil
+	})
+
+	vars, err := r.Variables("{{logo}} {{name}}")
+	require.NoError(t, err)
+	assert.Equal(t, []render.Variable{{Path: "name", Type: render.TypeScalar, Line: 1}}, vars)
+}